@@ -3,33 +3,175 @@ package main
 import (
 	"bookstore/api/api/internal/handlers"
 	"bookstore/api/api/internal/json"
+	"bookstore/api/api/internal/model"
 	"bookstore/api/api/internal/service"
 	"context"
+	encodingjson "encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 )
 
 func main() {
+	exchangeRatesFile := os.Getenv("EXCHANGE_RATES_FILE")
+	if exchangeRatesFile == "" {
+		exchangeRatesFile = "../data/exchange_rates.json"
+	}
+	currencyService, err := service.NewCurrencyService(exchangeRatesFile)
+	if err != nil {
+		fmt.Println("Error configuring currencies:", err)
+		return
+	}
+	// amounts written as bare numbers are read in the base currency
+	model.SetBaseCurrency(currencyService.BaseCurrency())
+
 	bookRepo := json.NewJsonBookStore()
 	authorRepo := json.NewJsonAuthorStore()
+	publisherRepo := json.NewJsonPublisherStore()
+	seriesRepo := json.NewJsonSeriesStore()
+	genreRepo := json.NewJsonGenreStore()
+	reviewRepo := json.NewJsonReviewStore()
+	wishlistRepo := json.NewJsonWishlistStore()
 	customerRepo := json.NewJsonCustomerStore()
 	orderRepo := json.NewJsonOrderStore()
+	supplierRepo := json.NewJsonSupplierStore()
+	purchaseOrderRepo := json.NewJsonPurchaseOrderStore()
+	stockMovementRepo := json.NewJsonStockMovementStore()
+	warehouseRepo := json.NewJsonWarehouseStore()
+	transferRepo := json.NewJsonTransferStore()
+	cartRepo := json.NewJsonCartStore()
+	reservationRepo := json.NewJsonReservationStore()
+	paymentRepo := json.NewJsonPaymentStore()
+	refundRepo := json.NewJsonRefundStore()
+	returnRequestRepo := json.NewJsonReturnRequestStore()
+	couponRepo := json.NewJsonCouponStore()
+	promotionRuleRepo := json.NewJsonPromotionRuleStore()
+	shippingMethodRepo := json.NewJsonShippingMethodStore()
+	shipmentRepo := json.NewJsonShipmentStore()
+	invoiceRepo := json.NewJsonInvoiceStore()
+	giftCardRepo := json.NewJsonGiftCardStore()
+	creditTransactionRepo := json.NewJsonCreditTransactionStore()
+	loyaltyTransactionRepo := json.NewJsonLoyaltyTransactionStore()
+	notificationRepo := json.NewJsonNotificationStore()
+
+	allocationStrategy, err := service.NewAllocationStrategy(os.Getenv("ALLOCATION_STRATEGY"))
+	if err != nil {
+		fmt.Println("Error configuring stock allocation:", err)
+		return
+	}
+	cartTTL, err := durationFromEnv("CART_TTL", 72*time.Hour)
+	if err != nil {
+		fmt.Println("Error configuring cart expiry:", err)
+		return
+	}
+	reservationTTL, err := durationFromEnv("RESERVATION_TTL", 30*time.Minute)
+	if err != nil {
+		fmt.Println("Error configuring stock reservations:", err)
+		return
+	}
+	recommendationsInterval, err := durationFromEnv("RECOMMENDATIONS_INTERVAL", time.Hour)
+	if err != nil {
+		fmt.Println("Error configuring recommendations:", err)
+		return
+	}
+	taxRatesFile := os.Getenv("TAX_RATES_FILE")
+	if taxRatesFile == "" {
+		taxRatesFile = "../data/tax_rates.json"
+	}
+	taxService, err := service.NewTaxService(bookRepo, taxRatesFile)
+	if err != nil {
+		fmt.Println("Error configuring taxes:", err)
+		return
+	}
+	loyaltyFile := os.Getenv("LOYALTY_FILE")
+	if loyaltyFile == "" {
+		loyaltyFile = "../data/loyalty.json"
+	}
+	loyaltyService, err := service.NewLoyaltyService(loyaltyTransactionRepo, customerRepo, orderRepo, currencyService, loyaltyFile)
+	if err != nil {
+		fmt.Println("Error configuring the loyalty program:", err)
+		return
+	}
+	paymentGateway, err := service.NewPaymentGateway(os.Getenv("PAYMENT_GATEWAY"), os.Getenv("PAYMENT_GATEWAY_MODE"), os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+	if err != nil {
+		fmt.Println("Error configuring the payment gateway:", err)
+		return
+	}
 
-	bookService := service.NewBookService(bookRepo, authorRepo)
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "../data/blobs"
+	}
+	blobStorage, err := service.NewBlobStorage(os.Getenv("BLOB_STORAGE"), blobDir)
+	if err != nil {
+		fmt.Println("Error configuring the blob storage:", err)
+		return
+	}
+
+	notificationService := service.NewNotificationService(notificationRepo, customerRepo)
+	promotionService := service.NewPromotionService(couponRepo, promotionRuleRepo, bookRepo, authorRepo, currencyService)
+	wishlistService := service.NewWishlistService(wishlistRepo, bookRepo, customerRepo, notificationService, promotionService, currencyService)
+	stockService := service.NewStockService(stockMovementRepo, bookRepo, warehouseRepo, reservationRepo, orderRepo, notificationService, wishlistService, allocationStrategy, reservationTTL)
+	genreService := service.NewGenreService(genreRepo, bookRepo)
+	coverService := service.NewCoverService(bookRepo, blobStorage)
+	reviewService := service.NewReviewService(reviewRepo, bookRepo, customerRepo, orderRepo)
+	recommendationService := service.NewRecommendationService(orderRepo, bookRepo, customerRepo)
+	bookService := service.NewBookService(bookRepo, authorRepo, publisherRepo, seriesRepo, genreService, stockService, wishlistService, currencyService)
 	authorService := service.NewAuthorService(authorRepo)
-	customerService := service.NewCustomerService(customerRepo)
-	orderService := service.NewOrderService(orderRepo, customerRepo, bookRepo)
-	reportService := service.NewReportService(orderRepo, bookRepo)
+	importService := service.NewImportService(bookService, authorService, genreService, bookRepo, authorRepo, publisherRepo)
+	publisherService := service.NewPublisherService(publisherRepo, bookRepo)
+	seriesService := service.NewSeriesService(seriesRepo, bookRepo, stockService)
+	customerService := service.NewCustomerService(customerRepo, currencyService)
+	shippingService := service.NewShippingService(shippingMethodRepo, shipmentRepo, orderRepo, bookRepo, currencyService, loyaltyService)
+	creditService := service.NewCreditService(giftCardRepo, creditTransactionRepo, customerRepo, currencyService)
+	orderService := service.NewOrderService(orderRepo, customerRepo, bookRepo, stockService, promotionService, taxService, shippingService, currencyService, creditService, loyaltyService)
+	reportService := service.NewReportService(orderRepo, bookRepo, refundRepo, seriesRepo, currencyService)
+	supplierService := service.NewSupplierService(supplierRepo, bookRepo, currencyService)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, bookRepo, warehouseRepo, stockService, currencyService)
+	warehouseService := service.NewWarehouseService(warehouseRepo, bookRepo)
+	transferService := service.NewTransferService(transferRepo, bookRepo, warehouseRepo, stockService)
+	cartService := service.NewCartService(cartRepo, bookRepo, customerRepo, orderService, stockService, promotionService, currencyService, cartTTL)
+	invoiceService := service.NewInvoiceService(invoiceRepo, orderRepo, customerRepo, bookRepo, refundRepo, currencyService, "./invoices")
+	paymentService := service.NewPaymentService(paymentRepo, refundRepo, orderRepo, orderService, invoiceService, creditService, loyaltyService, paymentGateway)
+	returnService := service.NewReturnService(returnRequestRepo, orderRepo, bookRepo, warehouseRepo, stockService, paymentService, currencyService)
 
 	bookHandler := handlers.NewBookHandler(bookService)
 	authorHandler := handlers.NewAuthorHandler(authorService)
+	publisherHandler := handlers.NewPublisherHandler(publisherService)
+	seriesHandler := handlers.NewSeriesHandler(seriesService)
+	genreHandler := handlers.NewGenreHandler(genreService)
+	coverHandler := handlers.NewCoverHandler(coverService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	wishlistHandler := handlers.NewWishlistHandler(wishlistService)
+	importHandler := handlers.NewImportHandler(importService)
 	customerHandler := handlers.NewCustomerHandler(customerService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 	reportHandler := handlers.NewReportHandler("./reports")
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	stockHandler := handlers.NewStockHandler(stockService)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseService)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	cartHandler := handlers.NewCartHandler(cartService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	returnHandler := handlers.NewReturnHandler(returnService)
+	couponHandler := handlers.NewCouponHandler(promotionService)
+	promotionRuleHandler := handlers.NewPromotionRuleHandler(promotionService)
+	shippingMethodHandler := handlers.NewShippingMethodHandler(shippingService)
+	shipmentHandler := handlers.NewShipmentHandler(shippingService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	creditHandler := handlers.NewCreditHandler(creditService)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
 
 	//logging
 	logFile, err := os.OpenFile("api.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -40,6 +182,79 @@ func main() {
 	defer logFile.Close()
 	logger := log.New(logFile, "", log.LstdFlags)
 
+	if err := warehouseService.EnsureDefaultWarehouse(context.Background()); err != nil {
+		logger.Printf("Error creating the default warehouse: %v\n", err)
+	}
+	if err := stockService.ReconcileStock(context.Background(), logger); err != nil {
+		logger.Printf("Error reconciling stock with the ledger: %v\n", err)
+	}
+	if err := genreService.MigrateBookGenres(context.Background(), logger); err != nil {
+		logger.Printf("Error migrating book genres: %v\n", err)
+	}
+
+	// every store is saved even when another fails, so that one bad file
+	// doesn't lose the data of the others
+	stores := []struct {
+		name  string
+		store interface{ SaveToFile() error }
+	}{
+		{"authors", authorRepo},
+		{"customers", customerRepo},
+		{"books", bookRepo},
+		{"orders", orderRepo},
+		{"suppliers", supplierRepo},
+		{"purchase orders", purchaseOrderRepo},
+		{"stock movements", stockMovementRepo},
+		{"warehouses", warehouseRepo},
+		{"transfers", transferRepo},
+		{"carts", cartRepo},
+		{"reservations", reservationRepo},
+		{"payments", paymentRepo},
+		{"refunds", refundRepo},
+		{"return requests", returnRequestRepo},
+		{"coupons", couponRepo},
+		{"promotion rules", promotionRuleRepo},
+		{"shipping methods", shippingMethodRepo},
+		{"shipments", shipmentRepo},
+		{"invoices", invoiceRepo},
+		{"gift cards", giftCardRepo},
+		{"credit transactions", creditTransactionRepo},
+		{"loyalty transactions", loyaltyTransactionRepo},
+		{"notifications", notificationRepo},
+		{"publishers", publisherRepo},
+		{"series", seriesRepo},
+		{"genres", genreRepo},
+		{"reviews", reviewRepo},
+		{"wishlists", wishlistRepo},
+	}
+	saveData := func() error {
+		var errs []error
+		for _, store := range stores {
+			if err := store.store.SaveToFile(); err != nil {
+				logger.Printf("Error saving %s: %v\n", store.name, err)
+				errs = append(errs, fmt.Errorf("saving %s: %w", store.name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	// the import command loads a catalog file into the data files instead of
+	// serving
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		report, err := runImport(importService, os.Args[2:])
+		if err != nil {
+			fmt.Println("Error importing:", err)
+			os.Exit(1)
+		}
+		if !report.DryRun {
+			if err := saveData(); err != nil {
+				fmt.Println("Error saving the import:", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
 	//Middleware for logging http request
 	logRequest := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -51,18 +266,92 @@ func main() {
 	// mux instead of default serve mux for security purposes !!
 	http.Handle("/books", logRequest(http.HandlerFunc(bookHandler.ServeHTTP)))
 	http.Handle("/books/{id}", logRequest(http.HandlerFunc(bookHandler.ServeHTTPById)))
+	http.Handle("/books/import", logRequest(http.HandlerFunc(importHandler.ServeHTTP)))
+	http.Handle("/books/{id}/stock-movements", logRequest(http.HandlerFunc(stockHandler.ServeHTTPMovements)))
+	http.Handle("/books/{id}/stock-adjustments", logRequest(http.HandlerFunc(stockHandler.ServeHTTPAdjustments)))
+	http.Handle("/books/{id}/availability", logRequest(http.HandlerFunc(stockHandler.ServeHTTPAvailability)))
+	http.Handle("/books/{id}/cover", logRequest(http.HandlerFunc(coverHandler.ServeHTTP)))
+	http.Handle("/books/{id}/reviews", logRequest(http.HandlerFunc(reviewHandler.ServeHTTPBookReviews)))
+	http.Handle("/books/{id}/recommendations", logRequest(http.HandlerFunc(recommendationHandler.ServeHTTPBookRecommendations)))
+	http.Handle("/reviews", logRequest(http.HandlerFunc(reviewHandler.ServeHTTP)))
+	http.Handle("/reviews/{id}", logRequest(http.HandlerFunc(reviewHandler.ServeHTTPById)))
+	http.Handle("/reviews/{id}/moderation", logRequest(http.HandlerFunc(reviewHandler.ServeHTTPModeration)))
+	http.Handle("/inventory", logRequest(http.HandlerFunc(stockHandler.ServeHTTPInventory)))
 	http.Handle("/authors", logRequest(http.HandlerFunc(authorHandler.ServeHTTP)))
 	http.Handle("/authors/{id}", logRequest(http.HandlerFunc(authorHandler.ServeHTTPById)))
+	http.Handle("/authors/{id}/books", logRequest(http.HandlerFunc(bookHandler.ServeHTTPAuthorBooks)))
+	http.Handle("/publishers", logRequest(http.HandlerFunc(publisherHandler.ServeHTTP)))
+	http.Handle("/publishers/{id}", logRequest(http.HandlerFunc(publisherHandler.ServeHTTPById)))
+	http.Handle("/publishers/{id}/books", logRequest(http.HandlerFunc(bookHandler.ServeHTTPPublisherBooks)))
+	http.Handle("/series", logRequest(http.HandlerFunc(seriesHandler.ServeHTTP)))
+	http.Handle("/series/{id}", logRequest(http.HandlerFunc(seriesHandler.ServeHTTPById)))
+	http.Handle("/genres", logRequest(http.HandlerFunc(genreHandler.ServeHTTP)))
+	http.Handle("/genres/{id}", logRequest(http.HandlerFunc(genreHandler.ServeHTTPById)))
 	http.Handle("/customers", logRequest(http.HandlerFunc(customerHandler.ServeHTTP)))
 	http.Handle("/customers/{id}", logRequest(http.HandlerFunc(customerHandler.ServeHTTPById)))
+	http.Handle("/customers/{id}/store-credit", logRequest(http.HandlerFunc(creditHandler.ServeHTTPStoreCredit)))
+	http.Handle("/customers/{id}/loyalty", logRequest(http.HandlerFunc(loyaltyHandler.ServeHTTPCustomerLoyalty)))
+	http.Handle("/customers/{id}/notifications", logRequest(http.HandlerFunc(notificationHandler.ServeHTTPCustomerNotifications)))
+	http.Handle("/customers/{id}/recommendations", logRequest(http.HandlerFunc(recommendationHandler.ServeHTTPCustomerRecommendations)))
+	http.Handle("/customers/{id}/wishlist", logRequest(http.HandlerFunc(wishlistHandler.ServeHTTP)))
+	http.Handle("/customers/{id}/wishlist/{bookId}", logRequest(http.HandlerFunc(wishlistHandler.ServeHTTPByBook)))
 	http.Handle("/orders", logRequest(http.HandlerFunc(orderHandler.ServeHTTP)))
 	http.Handle("/orders/{id}", logRequest(http.HandlerFunc(orderHandler.ServeHTTPById)))
+	http.Handle("/orders/{id}/payments", logRequest(http.HandlerFunc(paymentHandler.ServeHTTPOrderPayments)))
+	http.Handle("/orders/{id}/refunds", logRequest(http.HandlerFunc(paymentHandler.ServeHTTPOrderRefunds)))
+	http.Handle("/orders/{id}/shipments", logRequest(http.HandlerFunc(shipmentHandler.ServeHTTPOrderShipments)))
+	http.Handle("/orders/{id}/invoice", logRequest(http.HandlerFunc(invoiceHandler.ServeHTTPOrderInvoice)))
+	http.Handle("/orders/{id}/credit-notes", logRequest(http.HandlerFunc(invoiceHandler.ServeHTTPOrderCreditNotes)))
+	http.Handle("/payments/webhook", logRequest(http.HandlerFunc(paymentHandler.ServeHTTPWebhook)))
+	http.Handle("/returns", logRequest(http.HandlerFunc(returnHandler.ServeHTTP)))
+	http.Handle("/returns/{id}", logRequest(http.HandlerFunc(returnHandler.ServeHTTPById)))
+	http.Handle("/returns/{id}/approve", logRequest(http.HandlerFunc(returnHandler.ServeHTTPApprove)))
+	http.Handle("/returns/{id}/reject", logRequest(http.HandlerFunc(returnHandler.ServeHTTPReject)))
+	http.Handle("/returns/{id}/receive", logRequest(http.HandlerFunc(returnHandler.ServeHTTPReceive)))
+	http.Handle("/returns/{id}/refund", logRequest(http.HandlerFunc(returnHandler.ServeHTTPRefund)))
 	http.Handle("/reports", logRequest(http.HandlerFunc(reportHandler.ServeHTTP)))
+	http.Handle("/suppliers", logRequest(http.HandlerFunc(supplierHandler.ServeHTTP)))
+	http.Handle("/suppliers/{id}", logRequest(http.HandlerFunc(supplierHandler.ServeHTTPById)))
+	http.Handle("/purchase-orders", logRequest(http.HandlerFunc(purchaseOrderHandler.ServeHTTP)))
+	http.Handle("/purchase-orders/{id}", logRequest(http.HandlerFunc(purchaseOrderHandler.ServeHTTPById)))
+	http.Handle("/purchase-orders/{id}/receipts", logRequest(http.HandlerFunc(purchaseOrderHandler.ServeHTTPReceipts)))
+	http.Handle("/warehouses", logRequest(http.HandlerFunc(warehouseHandler.ServeHTTP)))
+	http.Handle("/warehouses/{id}", logRequest(http.HandlerFunc(warehouseHandler.ServeHTTPById)))
+	http.Handle("/transfers", logRequest(http.HandlerFunc(transferHandler.ServeHTTP)))
+	http.Handle("/transfers/{id}", logRequest(http.HandlerFunc(transferHandler.ServeHTTPById)))
+	http.Handle("/transfers/{id}/receive", logRequest(http.HandlerFunc(transferHandler.ServeHTTPReceive)))
+	http.Handle("/transfers/{id}/cancel", logRequest(http.HandlerFunc(transferHandler.ServeHTTPCancel)))
+	http.Handle("/carts", logRequest(http.HandlerFunc(cartHandler.ServeHTTP)))
+	http.Handle("/carts/{id}", logRequest(http.HandlerFunc(cartHandler.ServeHTTPById)))
+	http.Handle("/carts/{id}/items", logRequest(http.HandlerFunc(cartHandler.ServeHTTPItems)))
+	http.Handle("/carts/{id}/items/{bookId}", logRequest(http.HandlerFunc(cartHandler.ServeHTTPItem)))
+	http.Handle("/carts/{id}/merge", logRequest(http.HandlerFunc(cartHandler.ServeHTTPMerge)))
+	http.Handle("/carts/{id}/checkout", logRequest(http.HandlerFunc(cartHandler.ServeHTTPCheckout)))
+	http.Handle("/coupons", logRequest(http.HandlerFunc(couponHandler.ServeHTTP)))
+	http.Handle("/coupons/{id}", logRequest(http.HandlerFunc(couponHandler.ServeHTTPById)))
+	http.Handle("/promotions", logRequest(http.HandlerFunc(promotionRuleHandler.ServeHTTP)))
+	http.Handle("/promotions/{id}", logRequest(http.HandlerFunc(promotionRuleHandler.ServeHTTPById)))
+	http.Handle("/shipping-methods", logRequest(http.HandlerFunc(shippingMethodHandler.ServeHTTP)))
+	http.Handle("/shipping-methods/{id}", logRequest(http.HandlerFunc(shippingMethodHandler.ServeHTTPById)))
+	http.Handle("/shipments", logRequest(http.HandlerFunc(shipmentHandler.ServeHTTP)))
+	http.Handle("/shipments/{id}", logRequest(http.HandlerFunc(shipmentHandler.ServeHTTPById)))
+	http.Handle("/shipments/{id}/deliver", logRequest(http.HandlerFunc(shipmentHandler.ServeHTTPDeliver)))
+	http.Handle("/invoices", logRequest(http.HandlerFunc(invoiceHandler.ServeHTTP)))
+	http.Handle("/invoices/{id}", logRequest(http.HandlerFunc(invoiceHandler.ServeHTTPById)))
+	http.Handle("/gift-cards", logRequest(http.HandlerFunc(creditHandler.ServeHTTP)))
+	http.Handle("/gift-cards/{id}", logRequest(http.HandlerFunc(creditHandler.ServeHTTPById)))
+	http.Handle("/gift-cards/{id}/transactions", logRequest(http.HandlerFunc(creditHandler.ServeHTTPTransactions)))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go reportService.StartSalesReportGenrator(ctx, logger)
+	go cartService.StartCartSweeper(ctx, logger, time.Minute)
+	go orderService.StartReservationSweeper(ctx, logger, time.Minute)
+	go stockService.StartBackorderSweeper(ctx, logger, time.Minute)
+	go creditService.StartGiftCardSweeper(ctx, logger, time.Hour)
+	go recommendationService.StartRecommendationRefresher(ctx, logger, recommendationsInterval)
+	go wishlistService.StartWishlistSweeper(ctx, logger, time.Minute)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -71,23 +360,70 @@ func main() {
 		<-stop
 		fmt.Println("\nSaving data to files...")
 
-		if err := authorRepo.SaveToFile(); err != nil {
-			logger.Printf("Error saving authors: %v\n", err)
-		} else if err := customerRepo.SaveToFile(); err != nil {
-			logger.Printf("Error saving customers: %v\n", err)
-		} else if err := bookRepo.SaveToFile(); err != nil {
-			logger.Printf("Error saving books: %v\n", err)
-		} else if err := orderRepo.SaveToFile(); err != nil {
-			logger.Printf("Error saving orders: %v\n", err)
+		if err := saveData(); err != nil {
+			fmt.Println("Some data could not be saved:", err)
+			os.Exit(1)
 		}
 
 		fmt.Println("Data saved successfully")
 		os.Exit(0)
 	}()
 
-	err1 := http.ListenAndServe(":8080", nil)
+	// /books/isbn/{isbn} overlaps the /books/{id}/... routes, which the default
+	// mux refuses to register, so ISBN lookups are routed in front of it
+	mux := http.NewServeMux()
+	mux.Handle("/books/isbn/{isbn}", logRequest(http.HandlerFunc(bookHandler.ServeHTTPByISBN)))
+	mux.Handle("/", http.DefaultServeMux)
+
+	err1 := http.ListenAndServe(":8080", mux)
 	if err1 != nil {
 		logger.Println("Error serving:", err1)
 	}
 
 }
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// runImport runs the import command, `import [-format csv|onix] [-dry-run]
+// FILE`, and prints its report. The format defaults to the one of the file
+// extension, .xml and .onix files being ONIX messages.
+func runImport(importService *service.ImportService, args []string) (model.ImportReport, error) {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "format of the file, csv or onix")
+	dryRun := flags.Bool("dry-run", false, "report what the import would do without saving anything")
+	if err := flags.Parse(args); err != nil {
+		return model.ImportReport{}, err
+	}
+	if flags.NArg() != 1 {
+		return model.ImportReport{}, errors.New("usage: import [-format csv|onix] [-dry-run] FILE")
+	}
+
+	filename := flags.Arg(0)
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			*format = model.ImportFormatCSV
+		case ".xml", ".onix":
+			*format = model.ImportFormatONIX
+		}
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	defer file.Close()
+
+	report, err := importService.ImportBooks(context.Background(), *format, file, *dryRun)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	encoder := encodingjson.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return report, encoder.Encode(report)
+}
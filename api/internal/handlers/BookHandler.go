@@ -45,6 +45,33 @@ func (h *BookHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *BookHandler) ServeHTTPByISBN(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetBookByISBN(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *BookHandler) ServeHTTPAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetAuthorBooks(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *BookHandler) ServeHTTPPublisherBooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetPublisherBooks(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
 func (h *BookHandler) GetBooks(w http.ResponseWriter, r *http.Request) {
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -93,6 +120,65 @@ func (h *BookHandler) GetBook(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(book)
 }
 
+func (h *BookHandler) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	book, err := h.bookService.GetBookByISBN(ctx, r.PathValue("isbn"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "book not found"})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(book)
+}
+
+func (h *BookHandler) GetAuthorBooks(w http.ResponseWriter, r *http.Request) {
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	books, err := h.bookService.GetAuthorBooks(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Author not found"})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(books)
+}
+
+func (h *BookHandler) GetPublisherBooks(w http.ResponseWriter, r *http.Request) {
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	books, err := h.bookService.GetPublisherBooks(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Publisher not found"})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(books)
+}
+
 func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -105,12 +191,18 @@ func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err1 := h.bookService.DeleteBook(ctx, int(id))
-	if err1 != nil {
+	if _, err := h.bookService.GetBook(ctx, int(id)); err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(errors.Error{Message: "Book not found"})
 		return
 	}
+
+	err1 := h.bookService.DeleteBook(ctx, int(id))
+	if err1 != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err1.Error()})
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CartHandler struct {
+	cartService *service.CartService
+}
+
+func NewCartHandler(cartService *service.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+func (h *CartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.CreateCart(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetCarts(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetCart(w, r)
+	} else if r.Method == http.MethodDelete {
+		h.DeleteCart(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) ServeHTTPItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.AddItem(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) ServeHTTPItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		h.UpdateItem(w, r)
+	} else if r.Method == http.MethodDelete {
+		h.RemoveItem(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) ServeHTTPMerge(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.MergeCart(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) ServeHTTPCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.Checkout(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	decoder := json.NewDecoder(r.Body)
+	var cartInput model.CartInput
+	err := decoder.Decode(&cartInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid cart payload"})
+		return
+	}
+
+	cart, err := h.cartService.CreateCart(ctx, cartInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.cartService.GetCart(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Cart not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) GetCarts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params := r.URL.Query()
+	searchParams := make(map[string]string)
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			searchParams[key] = value[0]
+		}
+	}
+
+	carts, err := h.cartService.SearchCarts(ctx, searchParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(carts)
+}
+
+func (h *CartHandler) DeleteCart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = h.cartService.DeleteCart(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Cart not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var item model.CartItem
+	err = decoder.Decode(&item)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid cart item payload"})
+		return
+	}
+
+	cart, err := h.cartService.AddItem(ctx, id, item)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+	bookID, err := strconv.Atoi(r.PathValue("bookId"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var update model.CartItemUpdate
+	err = decoder.Decode(&update)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid cart item payload"})
+		return
+	}
+
+	cart, err := h.cartService.UpdateItem(ctx, id, bookID, update.Quantity)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+	bookID, err := strconv.Atoi(r.PathValue("bookId"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(ctx, id, bookID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) MergeCart(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var mergeInput model.CartMergeInput
+	err = decoder.Decode(&mergeInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid cart merge payload"})
+		return
+	}
+
+	cart, err := h.cartService.MergeCart(ctx, id, mergeInput.CustomerID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var checkoutInput model.CartCheckoutInput
+	// the body is optional, it only carries a coupon code
+	if err := json.NewDecoder(r.Body).Decode(&checkoutInput); err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid checkout payload"})
+		return
+	}
+
+	order, err := h.cartService.Checkout(ctx, id, checkoutInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
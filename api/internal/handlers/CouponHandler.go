@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CouponHandler struct {
+	promotionService *service.PromotionService
+}
+
+func NewCouponHandler(promotionService *service.PromotionService) *CouponHandler {
+	return &CouponHandler{
+		promotionService: promotionService,
+	}
+}
+
+func (h *CouponHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.CreateCoupon(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetCoupons(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CouponHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		h.UpdateCoupon(w, r)
+	} else if r.Method == http.MethodDelete {
+		h.DeleteCoupon(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetCoupon(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	decoder := json.NewDecoder(r.Body)
+	var couponInput model.CouponInput
+	err := decoder.Decode(&couponInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid coupon payload"})
+		return
+	}
+
+	coupon, err := h.promotionService.CreateCoupon(ctx, couponInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(coupon)
+}
+
+func (h *CouponHandler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	coupon, err := h.promotionService.GetCoupon(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Coupon not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(coupon)
+}
+
+func (h *CouponHandler) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var couponInput model.CouponInput
+	err = decoder.Decode(&couponInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid coupon payload"})
+		return
+	}
+
+	coupon, err := h.promotionService.UpdateCoupon(ctx, id, couponInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(coupon)
+}
+
+func (h *CouponHandler) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = h.promotionService.DeleteCoupon(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Coupon not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CouponHandler) GetCoupons(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params := r.URL.Query()
+	searchParams := make(map[string]string)
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			searchParams[key] = value[0]
+		}
+	}
+
+	coupons, err := h.promotionService.SearchCoupons(ctx, searchParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(coupons)
+}
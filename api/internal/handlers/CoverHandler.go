@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CoverHandler struct {
+	coverService *service.CoverService
+}
+
+func NewCoverHandler(coverService *service.CoverService) *CoverHandler {
+	return &CoverHandler{
+		coverService: coverService,
+	}
+}
+
+func (h *CoverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		h.UploadCover(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetCover(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+// UploadCover takes the image as the request body, or as the cover field of a
+// multipart form.
+func (h *CoverHandler) UploadCover(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	// leave room for the multipart headers around the image
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxCoverSize+64<<10)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("cover")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errors.Error{Message: "Invalid cover upload"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: fmt.Sprintf("cover image is larger than %d MB", service.MaxCoverSize>>20)})
+		return
+	}
+
+	book, err := h.coverService.UploadCover(ctx, id, data)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(book)
+}
+
+// GetCover serves the cover with caching headers, answering conditional
+// requests with 304 Not Modified.
+func (h *CoverHandler) GetCover(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	size := r.URL.Query().Get("size")
+	data, cover, err := h.coverService.GetCover(ctx, id, size)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Cover not found"})
+		return
+	}
+
+	if size == "" {
+		size = service.CoverSizeOriginal
+	}
+	w.Header().Set("Content-Type", cover.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", fmt.Sprintf(`"%d-%s-%d"`, id, size, cover.UpdatedAt.Unix()))
+	http.ServeContent(w, r, "", cover.UpdatedAt, bytes.NewReader(data))
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreditHandler struct {
+	creditService *service.CreditService
+}
+
+func NewCreditHandler(creditService *service.CreditService) *CreditHandler {
+	return &CreditHandler{
+		creditService: creditService,
+	}
+}
+
+func (h *CreditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.IssueGiftCard(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetGiftCards(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CreditHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetGiftCard(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CreditHandler) ServeHTTPTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetGiftCardTransactions(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CreditHandler) ServeHTTPStoreCredit(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.IssueStoreCredit(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetStoreCredit(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *CreditHandler) IssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	decoder := json.NewDecoder(r.Body)
+	var giftCardInput model.GiftCardInput
+	err := decoder.Decode(&giftCardInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid gift card payload"})
+		return
+	}
+
+	giftCard, err := h.creditService.IssueGiftCard(ctx, giftCardInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(giftCard)
+}
+
+func (h *CreditHandler) GetGiftCard(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	giftCard, err := h.creditService.GetGiftCard(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Gift card not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(giftCard)
+}
+
+func (h *CreditHandler) GetGiftCards(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params := r.URL.Query()
+	searchParams := make(map[string]string)
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			searchParams[key] = value[0]
+		}
+	}
+
+	giftCards, err := h.creditService.SearchGiftCards(ctx, searchParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(giftCards)
+}
+
+func (h *CreditHandler) GetGiftCardTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	transactions, err := h.creditService.GetGiftCardTransactions(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Gift card not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transactions)
+}
+
+func (h *CreditHandler) IssueStoreCredit(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var creditInput model.StoreCreditInput
+	err = decoder.Decode(&creditInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid store credit payload"})
+		return
+	}
+
+	transaction, err := h.creditService.IssueStoreCredit(ctx, id, creditInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *CreditHandler) GetStoreCredit(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	storeCredit, err := h.creditService.GetStoreCredit(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Customer not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(storeCredit)
+}
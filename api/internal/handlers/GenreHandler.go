@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type GenreHandler struct {
+	genreService *service.GenreService
+}
+
+func NewGenreHandler(genreService *service.GenreService) *GenreHandler {
+	return &GenreHandler{
+		genreService: genreService,
+	}
+}
+
+func (h *GenreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.CreateGenre(w, r)
+	} else if r.Method == http.MethodGet {
+		h.GetGenres(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *GenreHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetGenre(w, r)
+	} else if r.Method == http.MethodPut {
+		h.UpdateGenre(w, r)
+	} else if r.Method == http.MethodDelete {
+		h.DeleteGenre(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *GenreHandler) CreateGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	decoder := json.NewDecoder(r.Body)
+	var genreInput model.GenreInput
+	err := decoder.Decode(&genreInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid genre payload"})
+		return
+	}
+
+	genre, err := h.genreService.CreateGenre(ctx, genreInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *GenreHandler) GetGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	genre, err := h.genreService.GetGenre(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Genre not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *GenreHandler) GetGenres(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params := r.URL.Query()
+	searchParams := make(map[string]string)
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			searchParams[key] = value[0]
+		}
+	}
+
+	genres, err := h.genreService.SearchGenres(ctx, searchParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genres)
+}
+
+func (h *GenreHandler) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var genreInput model.GenreInput
+	err = decoder.Decode(&genreInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invalid genre payload"})
+		return
+	}
+
+	genre, err := h.genreService.UpdateGenre(ctx, id, genreInput)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *GenreHandler) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.genreService.GetGenre(ctx, id); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Genre not found"})
+		return
+	}
+
+	err = h.genreService.DeleteGenre(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.ImportBooks(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+// ImportBooks takes the file as the raw request body or as the "file" field of
+// a multipart form. The format comes from the format parameter, or else from
+// the content type of the file; dry_run=true only reports.
+func (h *ImportHandler) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	// large catalogs take longer than the other requests
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxImportSize)
+	var file io.Reader = r.Body
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		part, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errors.Error{Message: "Invalid import payload"})
+			return
+		}
+		defer part.Close()
+		file = part
+		contentType = header.Header.Get("Content-Type")
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(contentType)
+	}
+
+	report, err := h.importService.ImportBooks(ctx, format, file, dryRun)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// importFormat guesses the format of an import from its content type.
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return model.ImportFormatCSV
+	case "application/xml", "text/xml", "application/onix+xml":
+		return model.ImportFormatONIX
+	}
+	return ""
+}
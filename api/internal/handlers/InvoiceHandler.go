@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bookstore/api/api/internal/errors"
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type InvoiceHandler struct {
+	invoiceService *service.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+	}
+}
+
+func (h *InvoiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetInvoices(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *InvoiceHandler) ServeHTTPById(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetInvoice(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *InvoiceHandler) ServeHTTPOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetOrderInvoice(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *InvoiceHandler) ServeHTTPOrderCreditNotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		h.GetOrderCreditNotes(w, r)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Request not allowed"})
+	}
+}
+
+func (h *InvoiceHandler) GetOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	invoice, err := h.invoiceService.GetOrderInvoice(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	h.writeInvoice(ctx, w, r, invoice)
+}
+
+func (h *InvoiceHandler) GetOrderCreditNotes(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	creditNotes, err := h.invoiceService.GetOrderCreditNotes(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Order not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(creditNotes)
+}
+
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	idString := r.PathValue("id")
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	invoice, err := h.invoiceService.GetInvoice(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(errors.Error{Message: "Invoice not found"})
+		return
+	}
+
+	h.writeInvoice(ctx, w, r, invoice)
+}
+
+func (h *InvoiceHandler) GetInvoices(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	params := r.URL.Query()
+	searchParams := make(map[string]string)
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			searchParams[key] = value[0]
+		}
+	}
+
+	invoices, err := h.invoiceService.SearchInvoices(ctx, searchParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invoices)
+}
+
+// writeInvoice writes the invoice as JSON, or as the HTML or PDF document
+// asked for with the format query parameter.
+func (h *InvoiceHandler) writeInvoice(ctx context.Context, w http.ResponseWriter, r *http.Request, invoice model.Invoice) {
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "json" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(invoice)
+		return
+	}
+
+	document, err := h.invoiceService.Document(ctx, invoice, format)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errors.Error{Message: err.Error()})
+		return
+	}
+
+	contentType := "text/html; charset=utf-8"
+	if format == service.InvoiceFormatPDF {
+		contentType = "application/pdf"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoice.Number+"."+format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type JsonBookStore struct {
@@ -62,7 +63,11 @@ func (s *JsonBookStore) loadFromFile() error {
 
 	s.books = booksData.Books
 
-	for _, book := range s.books {
+	for i, book := range s.books {
+		// books from before contributors only have an author ID
+		if len(book.Contributors) == 0 && book.AuthorID != 0 {
+			s.books[i].Contributors = []model.Contributor{{AuthorID: book.AuthorID, Role: model.ContributorAuthor, Position: 1}}
+		}
 		if book.ID > s.lastID {
 			s.lastID = book.ID
 		}
@@ -96,6 +101,9 @@ func (s *JsonBookStore) CreateBook(ctx context.Context, book model.Book) (model.
 		s.mutex.Lock()
 		defer s.mutex.Unlock()
 
+		if err := s.checkISBN(0, book.ISBN); err != nil {
+			return model.Book{}, err
+		}
 		book.ID = s.getNextID()
 		s.books = append(s.books, book)
 
@@ -128,6 +136,9 @@ func (s *JsonBookStore) UpdateBook(ctx context.Context, id int, updatedBook mode
 		s.mutex.Lock()
 		defer s.mutex.Unlock()
 
+		if err := s.checkISBN(id, updatedBook.ISBN); err != nil {
+			return model.Book{}, err
+		}
 		for i, book := range s.books {
 			if book.ID == id {
 				s.books[i] = updatedBook
@@ -138,6 +149,20 @@ func (s *JsonBookStore) UpdateBook(ctx context.Context, id int, updatedBook mode
 	}
 }
 
+// checkISBN rejects an ISBN already used by a book other than the book with the
+// id. Callers hold the mutex.
+func (s *JsonBookStore) checkISBN(id int, isbn string) error {
+	if isbn == "" {
+		return nil
+	}
+	for _, book := range s.books {
+		if book.ID != id && book.ISBN == isbn {
+			return fmt.Errorf("book with isbn %s already exists", isbn)
+		}
+	}
+	return nil
+}
+
 func (s *JsonBookStore) DeleteBook(ctx context.Context, id int) error {
 	select {
 	case <-ctx.Done():
@@ -181,19 +206,38 @@ func (s *JsonBookStore) SearchBooks(ctx context.Context, params map[string]strin
 					if !strings.EqualFold(book.Title, value) {
 						matches = false
 					}
-				case "author":
+				case "author", "contributor":
 					authorID, err := strconv.Atoi(value)
-					if err != nil || book.AuthorID != authorID {
+					contributed := false
+					for _, contributor := range book.Contributors {
+						if contributor.AuthorID == authorID && (key == "contributor" || contributor.Role == model.ContributorAuthor) {
+							contributed = true
+							break
+						}
+					}
+					if err != nil || !contributed {
+						matches = false
+					}
+				case "role":
+					roleMatches := false
+					for _, contributor := range book.Contributors {
+						if strings.EqualFold(contributor.Role, value) {
+							roleMatches = true
+							break
+						}
+					}
+					if !roleMatches {
 						matches = false
 					}
 				case "genre":
-					value := strings.ToLower(value)
+					// several genres are separated by commas, any of them matches
 					genreMatches := false
 
 					for _, genre := range book.Genres {
-						if strings.ToLower(genre) == value {
-							genreMatches = true
-							break
+						for _, name := range strings.Split(value, ",") {
+							if strings.EqualFold(genre, name) {
+								genreMatches = true
+							}
 						}
 					}
 					if !genreMatches {
@@ -203,8 +247,45 @@ func (s *JsonBookStore) SearchBooks(ctx context.Context, params map[string]strin
 					if book.PublishedAt.Format("2006-01-02") != value {
 						matches = false
 					}
-				default:
-					matches = true
+				case "upcoming":
+					if book.PublishedAt.After(time.Now()) != (value == "true") {
+						matches = false
+					}
+				case "isbn":
+					if book.ISBN != value {
+						matches = false
+					}
+				case "publisher_id":
+					publisherID, err := strconv.Atoi(value)
+					if err != nil || book.PublisherID != publisherID {
+						matches = false
+					}
+				case "min_rating":
+					rating, err := strconv.ParseFloat(value, 64)
+					if err != nil || book.RatingCount == 0 || book.RatingAverage < rating {
+						matches = false
+					}
+				case "series_id":
+					seriesID, err := strconv.Atoi(value)
+					if err != nil || book.SeriesID != seriesID {
+						matches = false
+					}
+				case "publisher":
+					if !strings.EqualFold(book.Publisher, value) {
+						matches = false
+					}
+				case "language":
+					if !strings.EqualFold(book.Language, value) {
+						matches = false
+					}
+				case "format":
+					if !strings.EqualFold(book.Format, value) {
+						matches = false
+					}
+				case "edition":
+					if !strings.EqualFold(book.Edition, value) {
+						matches = false
+					}
 				}
 			}
 
@@ -0,0 +1,187 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonCartStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	carts    []model.Cart
+}
+
+type CartsData struct {
+	Carts []model.Cart `json:"carts"`
+}
+
+func NewJsonCartStore() *JsonCartStore {
+	store := &JsonCartStore{
+		filename: "../data/carts.json",
+		carts:    make([]model.Cart, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonCartStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := CartsData{Carts: []model.Cart{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var cartsData CartsData
+	if err := json.Unmarshal(data, &cartsData); err != nil {
+		return err
+	}
+
+	s.carts = cartsData.Carts
+
+	for _, cart := range s.carts {
+		if cart.ID > s.lastID {
+			s.lastID = cart.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonCartStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(CartsData{Carts: s.carts}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonCartStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonCartStore) CreateCart(ctx context.Context, cart model.Cart) (model.Cart, error) {
+	select {
+	case <-ctx.Done():
+		return model.Cart{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		cart.ID = s.getNextID()
+		s.carts = append(s.carts, cart)
+		return cart, nil
+	}
+}
+
+func (s *JsonCartStore) GetCart(ctx context.Context, id int) (model.Cart, error) {
+	select {
+	case <-ctx.Done():
+		return model.Cart{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, cart := range s.carts {
+			if cart.ID == id {
+				return cart, nil
+			}
+		}
+		return model.Cart{}, fmt.Errorf("cart with id %d not found", id)
+	}
+}
+
+func (s *JsonCartStore) UpdateCart(ctx context.Context, id int, updatedCart model.Cart) (model.Cart, error) {
+	select {
+	case <-ctx.Done():
+		return model.Cart{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, cart := range s.carts {
+			if cart.ID == id {
+				s.carts[i] = updatedCart
+				return updatedCart, nil
+			}
+		}
+		return model.Cart{}, fmt.Errorf("cart with id %d not found", id)
+	}
+}
+
+func (s *JsonCartStore) DeleteCart(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, cart := range s.carts {
+			if cart.ID == id {
+				s.carts = append(s.carts[:i], s.carts[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("cart with id %d not found", id)
+	}
+}
+
+func (s *JsonCartStore) SearchCarts(ctx context.Context, params map[string]string) ([]model.Cart, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.carts, nil
+		}
+
+		result := []model.Cart{}
+		for _, cart := range s.carts {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "customer_id":
+					if strconv.Itoa(cart.CustomerID) != value {
+						matches = false
+					}
+				case "status":
+					if cart.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, cart)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,187 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonCouponStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	coupons  []model.Coupon
+}
+
+type CouponsData struct {
+	Coupons []model.Coupon `json:"coupons"`
+}
+
+func NewJsonCouponStore() *JsonCouponStore {
+	store := &JsonCouponStore{
+		filename: "../data/coupons.json",
+		coupons:  make([]model.Coupon, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonCouponStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := CouponsData{Coupons: []model.Coupon{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var couponsData CouponsData
+	if err := json.Unmarshal(data, &couponsData); err != nil {
+		return err
+	}
+
+	s.coupons = couponsData.Coupons
+
+	for _, coupon := range s.coupons {
+		if coupon.ID > s.lastID {
+			s.lastID = coupon.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonCouponStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(CouponsData{Coupons: s.coupons}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonCouponStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonCouponStore) CreateCoupon(ctx context.Context, coupon model.Coupon) (model.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return model.Coupon{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		coupon.ID = s.getNextID()
+		s.coupons = append(s.coupons, coupon)
+		return coupon, nil
+	}
+}
+
+func (s *JsonCouponStore) GetCoupon(ctx context.Context, id int) (model.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return model.Coupon{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, coupon := range s.coupons {
+			if coupon.ID == id {
+				return coupon, nil
+			}
+		}
+		return model.Coupon{}, fmt.Errorf("coupon with id %d not found", id)
+	}
+}
+
+func (s *JsonCouponStore) UpdateCoupon(ctx context.Context, id int, updatedCoupon model.Coupon) (model.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return model.Coupon{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, coupon := range s.coupons {
+			if coupon.ID == id {
+				s.coupons[i] = updatedCoupon
+				return updatedCoupon, nil
+			}
+		}
+		return model.Coupon{}, fmt.Errorf("coupon with id %d not found", id)
+	}
+}
+
+func (s *JsonCouponStore) DeleteCoupon(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, coupon := range s.coupons {
+			if coupon.ID == id {
+				s.coupons = append(s.coupons[:i], s.coupons[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("coupon with id %d not found", id)
+	}
+}
+
+func (s *JsonCouponStore) SearchCoupons(ctx context.Context, params map[string]string) ([]model.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.coupons, nil
+		}
+
+		result := []model.Coupon{}
+		for _, coupon := range s.coupons {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "code":
+					if !strings.EqualFold(coupon.Code, value) {
+						matches = false
+					}
+				case "type":
+					if coupon.Type != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, coupon)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,163 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonCreditTransactionStore struct {
+	filename     string
+	mutex        sync.RWMutex
+	lastID       int
+	transactions []model.CreditTransaction
+}
+
+type CreditTransactionsData struct {
+	CreditTransactions []model.CreditTransaction `json:"credit_transactions"`
+}
+
+func NewJsonCreditTransactionStore() *JsonCreditTransactionStore {
+	store := &JsonCreditTransactionStore{
+		filename:     "../data/credit_transactions.json",
+		transactions: make([]model.CreditTransaction, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonCreditTransactionStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := CreditTransactionsData{CreditTransactions: []model.CreditTransaction{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var transactionsData CreditTransactionsData
+	if err := json.Unmarshal(data, &transactionsData); err != nil {
+		return err
+	}
+
+	s.transactions = transactionsData.CreditTransactions
+
+	for _, transaction := range s.transactions {
+		if transaction.ID > s.lastID {
+			s.lastID = transaction.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonCreditTransactionStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(CreditTransactionsData{CreditTransactions: s.transactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonCreditTransactionStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonCreditTransactionStore) CreateCreditTransaction(ctx context.Context, transaction model.CreditTransaction) (model.CreditTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return model.CreditTransaction{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		transaction.ID = s.getNextID()
+		s.transactions = append(s.transactions, transaction)
+		return transaction, nil
+	}
+}
+
+func (s *JsonCreditTransactionStore) GetCreditTransaction(ctx context.Context, id int) (model.CreditTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return model.CreditTransaction{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, transaction := range s.transactions {
+			if transaction.ID == id {
+				return transaction, nil
+			}
+		}
+		return model.CreditTransaction{}, fmt.Errorf("credit transaction with id %d not found", id)
+	}
+}
+
+func (s *JsonCreditTransactionStore) SearchCreditTransactions(ctx context.Context, params map[string]string) ([]model.CreditTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.transactions, nil
+		}
+
+		result := []model.CreditTransaction{}
+		for _, transaction := range s.transactions {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "account":
+					if transaction.Account != value {
+						matches = false
+					}
+				case "gift_card_id":
+					if strconv.Itoa(transaction.GiftCardID) != value {
+						matches = false
+					}
+				case "customer_id":
+					if strconv.Itoa(transaction.CustomerID) != value {
+						matches = false
+					}
+				case "order_id":
+					if strconv.Itoa(transaction.OrderID) != value {
+						matches = false
+					}
+				case "type":
+					if transaction.Type != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, transaction)
+			}
+		}
+		return result, nil
+	}
+}
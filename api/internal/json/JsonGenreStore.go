@@ -0,0 +1,194 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type JsonGenreStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	genres   []model.Genre
+}
+
+type GenresData struct {
+	Genres []model.Genre `json:"genres"`
+}
+
+func NewJsonGenreStore() *JsonGenreStore {
+	store := &JsonGenreStore{
+		filename: "../data/genres.json",
+		genres:   make([]model.Genre, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonGenreStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonGenreStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := GenresData{Genres: []model.Genre{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var genresData GenresData
+	if err := json.Unmarshal(data, &genresData); err != nil {
+		return err
+	}
+
+	s.genres = genresData.Genres
+
+	for _, genre := range s.genres {
+		if genre.ID > s.lastID {
+			s.lastID = genre.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonGenreStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(GenresData{Genres: s.genres}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonGenreStore) CreateGenre(ctx context.Context, genre model.Genre) (model.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return model.Genre{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		genre.ID = s.getNextID()
+		s.genres = append(s.genres, genre)
+		return genre, nil
+	}
+}
+
+func (s *JsonGenreStore) GetGenre(ctx context.Context, id int) (model.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return model.Genre{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, genre := range s.genres {
+			if genre.ID == id {
+				return genre, nil
+			}
+		}
+		return model.Genre{}, fmt.Errorf("genre with id %d not found", id)
+	}
+}
+
+func (s *JsonGenreStore) UpdateGenre(ctx context.Context, id int, updatedGenre model.Genre) (model.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return model.Genre{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, genre := range s.genres {
+			if genre.ID == id {
+				s.genres[i] = updatedGenre
+				return updatedGenre, nil
+			}
+		}
+		return model.Genre{}, fmt.Errorf("genre with id %d not found", id)
+	}
+}
+
+func (s *JsonGenreStore) DeleteGenre(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, genre := range s.genres {
+			if genre.ID == id {
+				s.genres = append(s.genres[:i], s.genres[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("genre with id %d not found", id)
+	}
+}
+
+func (s *JsonGenreStore) SearchGenres(ctx context.Context, params map[string]string) ([]model.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if params == nil {
+			return s.genres, nil
+		}
+
+		result := []model.Genre{}
+		for _, genre := range s.genres {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(genre.Name, value) {
+						matches = false
+					}
+				case "slug":
+					if genre.Slug != value {
+						matches = false
+					}
+				case "parent_id":
+					parentID, err := strconv.Atoi(value)
+					if err != nil || genre.ParentID != parentID {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, genre)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,174 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type JsonGiftCardStore struct {
+	filename  string
+	mutex     sync.RWMutex
+	lastID    int
+	giftCards []model.GiftCard
+}
+
+type GiftCardsData struct {
+	GiftCards []model.GiftCard `json:"gift_cards"`
+}
+
+func NewJsonGiftCardStore() *JsonGiftCardStore {
+	store := &JsonGiftCardStore{
+		filename:  "../data/gift_cards.json",
+		giftCards: make([]model.GiftCard, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonGiftCardStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := GiftCardsData{GiftCards: []model.GiftCard{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var giftCardsData GiftCardsData
+	if err := json.Unmarshal(data, &giftCardsData); err != nil {
+		return err
+	}
+
+	s.giftCards = giftCardsData.GiftCards
+
+	for _, giftCard := range s.giftCards {
+		if giftCard.ID > s.lastID {
+			s.lastID = giftCard.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonGiftCardStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(GiftCardsData{GiftCards: s.giftCards}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonGiftCardStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonGiftCardStore) CreateGiftCard(ctx context.Context, giftCard model.GiftCard) (model.GiftCard, error) {
+	select {
+	case <-ctx.Done():
+		return model.GiftCard{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		giftCard.ID = s.getNextID()
+		s.giftCards = append(s.giftCards, giftCard)
+		return giftCard, nil
+	}
+}
+
+func (s *JsonGiftCardStore) GetGiftCard(ctx context.Context, id int) (model.GiftCard, error) {
+	select {
+	case <-ctx.Done():
+		return model.GiftCard{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, giftCard := range s.giftCards {
+			if giftCard.ID == id {
+				return giftCard, nil
+			}
+		}
+		return model.GiftCard{}, fmt.Errorf("gift card with id %d not found", id)
+	}
+}
+
+func (s *JsonGiftCardStore) UpdateGiftCard(ctx context.Context, id int, updatedGiftCard model.GiftCard) (model.GiftCard, error) {
+	select {
+	case <-ctx.Done():
+		return model.GiftCard{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, giftCard := range s.giftCards {
+			if giftCard.ID == id {
+				s.giftCards[i] = updatedGiftCard
+				return updatedGiftCard, nil
+			}
+		}
+		return model.GiftCard{}, fmt.Errorf("gift card with id %d not found", id)
+	}
+}
+
+func (s *JsonGiftCardStore) SearchGiftCards(ctx context.Context, params map[string]string) ([]model.GiftCard, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.giftCards, nil
+		}
+
+		result := []model.GiftCard{}
+		for _, giftCard := range s.giftCards {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "code":
+					if !strings.EqualFold(giftCard.Code, value) {
+						matches = false
+					}
+				case "customer_id":
+					if strconv.Itoa(giftCard.CustomerID) != value {
+						matches = false
+					}
+				case "status":
+					if giftCard.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, giftCard)
+			}
+		}
+		return result, nil
+	}
+}
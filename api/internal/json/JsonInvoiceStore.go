@@ -0,0 +1,163 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonInvoiceStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	invoices []model.Invoice
+}
+
+type InvoicesData struct {
+	Invoices []model.Invoice `json:"invoices"`
+}
+
+func NewJsonInvoiceStore() *JsonInvoiceStore {
+	store := &JsonInvoiceStore{
+		filename: "../data/invoices.json",
+		invoices: make([]model.Invoice, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonInvoiceStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := InvoicesData{Invoices: []model.Invoice{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var invoicesData InvoicesData
+	if err := json.Unmarshal(data, &invoicesData); err != nil {
+		return err
+	}
+
+	s.invoices = invoicesData.Invoices
+
+	for _, invoice := range s.invoices {
+		if invoice.ID > s.lastID {
+			s.lastID = invoice.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonInvoiceStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(InvoicesData{Invoices: s.invoices}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonInvoiceStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonInvoiceStore) CreateInvoice(ctx context.Context, invoice model.Invoice) (model.Invoice, error) {
+	select {
+	case <-ctx.Done():
+		return model.Invoice{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		invoice.ID = s.getNextID()
+		s.invoices = append(s.invoices, invoice)
+		return invoice, nil
+	}
+}
+
+func (s *JsonInvoiceStore) GetInvoice(ctx context.Context, id int) (model.Invoice, error) {
+	select {
+	case <-ctx.Done():
+		return model.Invoice{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, invoice := range s.invoices {
+			if invoice.ID == id {
+				return invoice, nil
+			}
+		}
+		return model.Invoice{}, fmt.Errorf("invoice with id %d not found", id)
+	}
+}
+
+func (s *JsonInvoiceStore) SearchInvoices(ctx context.Context, params map[string]string) ([]model.Invoice, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.invoices, nil
+		}
+
+		result := []model.Invoice{}
+		for _, invoice := range s.invoices {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(invoice.OrderID) != value {
+						matches = false
+					}
+				case "refund_id":
+					if strconv.Itoa(invoice.RefundID) != value {
+						matches = false
+					}
+				case "type":
+					if invoice.Type != value {
+						matches = false
+					}
+				case "number":
+					if invoice.Number != value {
+						matches = false
+					}
+				case "customer_id":
+					if strconv.Itoa(invoice.Customer.CustomerID) != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, invoice)
+			}
+		}
+		return result, nil
+	}
+}
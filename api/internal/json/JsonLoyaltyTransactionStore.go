@@ -0,0 +1,159 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonLoyaltyTransactionStore struct {
+	filename     string
+	mutex        sync.RWMutex
+	lastID       int
+	transactions []model.LoyaltyTransaction
+}
+
+type LoyaltyTransactionsData struct {
+	LoyaltyTransactions []model.LoyaltyTransaction `json:"loyalty_transactions"`
+}
+
+func NewJsonLoyaltyTransactionStore() *JsonLoyaltyTransactionStore {
+	store := &JsonLoyaltyTransactionStore{
+		filename:     "../data/loyalty_transactions.json",
+		transactions: make([]model.LoyaltyTransaction, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonLoyaltyTransactionStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := LoyaltyTransactionsData{LoyaltyTransactions: []model.LoyaltyTransaction{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var transactionsData LoyaltyTransactionsData
+	if err := json.Unmarshal(data, &transactionsData); err != nil {
+		return err
+	}
+
+	s.transactions = transactionsData.LoyaltyTransactions
+
+	for _, transaction := range s.transactions {
+		if transaction.ID > s.lastID {
+			s.lastID = transaction.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonLoyaltyTransactionStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(LoyaltyTransactionsData{LoyaltyTransactions: s.transactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonLoyaltyTransactionStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonLoyaltyTransactionStore) CreateLoyaltyTransaction(ctx context.Context, transaction model.LoyaltyTransaction) (model.LoyaltyTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return model.LoyaltyTransaction{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		transaction.ID = s.getNextID()
+		s.transactions = append(s.transactions, transaction)
+		return transaction, nil
+	}
+}
+
+func (s *JsonLoyaltyTransactionStore) GetLoyaltyTransaction(ctx context.Context, id int) (model.LoyaltyTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return model.LoyaltyTransaction{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, transaction := range s.transactions {
+			if transaction.ID == id {
+				return transaction, nil
+			}
+		}
+		return model.LoyaltyTransaction{}, fmt.Errorf("loyalty transaction with id %d not found", id)
+	}
+}
+
+func (s *JsonLoyaltyTransactionStore) SearchLoyaltyTransactions(ctx context.Context, params map[string]string) ([]model.LoyaltyTransaction, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.transactions, nil
+		}
+
+		result := []model.LoyaltyTransaction{}
+		for _, transaction := range s.transactions {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "customer_id":
+					if strconv.Itoa(transaction.CustomerID) != value {
+						matches = false
+					}
+				case "order_id":
+					if strconv.Itoa(transaction.OrderID) != value {
+						matches = false
+					}
+				case "refund_id":
+					if strconv.Itoa(transaction.RefundID) != value {
+						matches = false
+					}
+				case "type":
+					if transaction.Type != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, transaction)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,155 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonNotificationStore struct {
+	filename      string
+	mutex         sync.RWMutex
+	lastID        int
+	notifications []model.Notification
+}
+
+type NotificationsData struct {
+	Notifications []model.Notification `json:"notifications"`
+}
+
+func NewJsonNotificationStore() *JsonNotificationStore {
+	store := &JsonNotificationStore{
+		filename:      "../data/notifications.json",
+		notifications: make([]model.Notification, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonNotificationStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := NotificationsData{Notifications: []model.Notification{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var notificationsData NotificationsData
+	if err := json.Unmarshal(data, &notificationsData); err != nil {
+		return err
+	}
+
+	s.notifications = notificationsData.Notifications
+
+	for _, notification := range s.notifications {
+		if notification.ID > s.lastID {
+			s.lastID = notification.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonNotificationStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(NotificationsData{Notifications: s.notifications}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonNotificationStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonNotificationStore) CreateNotification(ctx context.Context, notification model.Notification) (model.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return model.Notification{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		notification.ID = s.getNextID()
+		s.notifications = append(s.notifications, notification)
+		return notification, nil
+	}
+}
+
+func (s *JsonNotificationStore) GetNotification(ctx context.Context, id int) (model.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return model.Notification{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, notification := range s.notifications {
+			if notification.ID == id {
+				return notification, nil
+			}
+		}
+		return model.Notification{}, fmt.Errorf("notification with id %d not found", id)
+	}
+}
+
+func (s *JsonNotificationStore) SearchNotifications(ctx context.Context, params map[string]string) ([]model.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.notifications, nil
+		}
+
+		result := []model.Notification{}
+		for _, notification := range s.notifications {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "customer_id":
+					if strconv.Itoa(notification.CustomerID) != value {
+						matches = false
+					}
+				case "order_id":
+					if strconv.Itoa(notification.OrderID) != value {
+						matches = false
+					}
+				case "type":
+					if notification.Type != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, notification)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,191 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonPaymentStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	payments []model.Payment
+}
+
+type PaymentsData struct {
+	Payments []model.Payment `json:"payments"`
+}
+
+func NewJsonPaymentStore() *JsonPaymentStore {
+	store := &JsonPaymentStore{
+		filename: "../data/payments.json",
+		payments: make([]model.Payment, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonPaymentStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := PaymentsData{Payments: []model.Payment{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var paymentsData PaymentsData
+	if err := json.Unmarshal(data, &paymentsData); err != nil {
+		return err
+	}
+
+	s.payments = paymentsData.Payments
+
+	for _, payment := range s.payments {
+		if payment.ID > s.lastID {
+			s.lastID = payment.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonPaymentStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(PaymentsData{Payments: s.payments}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonPaymentStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonPaymentStore) CreatePayment(ctx context.Context, payment model.Payment) (model.Payment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Payment{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		payment.ID = s.getNextID()
+		s.payments = append(s.payments, payment)
+		return payment, nil
+	}
+}
+
+func (s *JsonPaymentStore) GetPayment(ctx context.Context, id int) (model.Payment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Payment{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, payment := range s.payments {
+			if payment.ID == id {
+				return payment, nil
+			}
+		}
+		return model.Payment{}, fmt.Errorf("payment with id %d not found", id)
+	}
+}
+
+func (s *JsonPaymentStore) UpdatePayment(ctx context.Context, id int, updatedPayment model.Payment) (model.Payment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Payment{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, payment := range s.payments {
+			if payment.ID == id {
+				s.payments[i] = updatedPayment
+				return updatedPayment, nil
+			}
+		}
+		return model.Payment{}, fmt.Errorf("payment with id %d not found", id)
+	}
+}
+
+func (s *JsonPaymentStore) DeletePayment(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, payment := range s.payments {
+			if payment.ID == id {
+				s.payments = append(s.payments[:i], s.payments[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("payment with id %d not found", id)
+	}
+}
+
+func (s *JsonPaymentStore) SearchPayments(ctx context.Context, params map[string]string) ([]model.Payment, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.payments, nil
+		}
+
+		result := []model.Payment{}
+		for _, payment := range s.payments {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(payment.OrderID) != value {
+						matches = false
+					}
+				case "reference":
+					if payment.Reference != value {
+						matches = false
+					}
+				case "status":
+					if payment.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, payment)
+			}
+		}
+		return result, nil
+	}
+}
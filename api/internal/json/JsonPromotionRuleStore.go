@@ -0,0 +1,192 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type JsonPromotionRuleStore struct {
+	filename       string
+	mutex          sync.RWMutex
+	lastID         int
+	promotionRules []model.PromotionRule
+}
+
+type PromotionRulesData struct {
+	PromotionRules []model.PromotionRule `json:"promotion_rules"`
+}
+
+func NewJsonPromotionRuleStore() *JsonPromotionRuleStore {
+	store := &JsonPromotionRuleStore{
+		filename:       "../data/promotion_rules.json",
+		promotionRules: make([]model.PromotionRule, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonPromotionRuleStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := PromotionRulesData{PromotionRules: []model.PromotionRule{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var promotionRulesData PromotionRulesData
+	if err := json.Unmarshal(data, &promotionRulesData); err != nil {
+		return err
+	}
+
+	s.promotionRules = promotionRulesData.PromotionRules
+
+	for _, promotionRule := range s.promotionRules {
+		if promotionRule.ID > s.lastID {
+			s.lastID = promotionRule.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonPromotionRuleStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(PromotionRulesData{PromotionRules: s.promotionRules}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonPromotionRuleStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonPromotionRuleStore) CreatePromotionRule(ctx context.Context, promotionRule model.PromotionRule) (model.PromotionRule, error) {
+	select {
+	case <-ctx.Done():
+		return model.PromotionRule{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		promotionRule.ID = s.getNextID()
+		s.promotionRules = append(s.promotionRules, promotionRule)
+		return promotionRule, nil
+	}
+}
+
+func (s *JsonPromotionRuleStore) GetPromotionRule(ctx context.Context, id int) (model.PromotionRule, error) {
+	select {
+	case <-ctx.Done():
+		return model.PromotionRule{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, promotionRule := range s.promotionRules {
+			if promotionRule.ID == id {
+				return promotionRule, nil
+			}
+		}
+		return model.PromotionRule{}, fmt.Errorf("promotion rule with id %d not found", id)
+	}
+}
+
+func (s *JsonPromotionRuleStore) UpdatePromotionRule(ctx context.Context, id int, updatedPromotionRule model.PromotionRule) (model.PromotionRule, error) {
+	select {
+	case <-ctx.Done():
+		return model.PromotionRule{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, promotionRule := range s.promotionRules {
+			if promotionRule.ID == id {
+				s.promotionRules[i] = updatedPromotionRule
+				return updatedPromotionRule, nil
+			}
+		}
+		return model.PromotionRule{}, fmt.Errorf("promotion rule with id %d not found", id)
+	}
+}
+
+func (s *JsonPromotionRuleStore) DeletePromotionRule(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, promotionRule := range s.promotionRules {
+			if promotionRule.ID == id {
+				s.promotionRules = append(s.promotionRules[:i], s.promotionRules[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("promotion rule with id %d not found", id)
+	}
+}
+
+func (s *JsonPromotionRuleStore) SearchPromotionRules(ctx context.Context, params map[string]string) ([]model.PromotionRule, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.promotionRules, nil
+		}
+
+		result := []model.PromotionRule{}
+		for _, promotionRule := range s.promotionRules {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "type":
+					if promotionRule.Type != value {
+						matches = false
+					}
+				case "genre":
+					if !strings.EqualFold(promotionRule.Genre, value) {
+						matches = false
+					}
+				case "book_id":
+					if strconv.Itoa(promotionRule.BookID) != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, promotionRule)
+			}
+		}
+		return result, nil
+	}
+}
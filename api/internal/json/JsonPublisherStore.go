@@ -0,0 +1,188 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonPublisherStore struct {
+	filename   string
+	mutex      sync.RWMutex
+	lastID     int
+	publishers []model.Publisher
+}
+
+type PublishersData struct {
+	Publishers []model.Publisher `json:"publishers"`
+}
+
+func NewJsonPublisherStore() *JsonPublisherStore {
+	store := &JsonPublisherStore{
+		filename:   "../data/publishers.json",
+		publishers: make([]model.Publisher, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonPublisherStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonPublisherStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := PublishersData{Publishers: []model.Publisher{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var publishersData PublishersData
+	if err := json.Unmarshal(data, &publishersData); err != nil {
+		return err
+	}
+
+	s.publishers = publishersData.Publishers
+
+	for _, publisher := range s.publishers {
+		if publisher.ID > s.lastID {
+			s.lastID = publisher.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonPublisherStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(PublishersData{Publishers: s.publishers}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonPublisherStore) CreatePublisher(ctx context.Context, publisher model.Publisher) (model.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return model.Publisher{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		publisher.ID = s.getNextID()
+		s.publishers = append(s.publishers, publisher)
+		return publisher, nil
+	}
+}
+
+func (s *JsonPublisherStore) GetPublisher(ctx context.Context, id int) (model.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return model.Publisher{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, publisher := range s.publishers {
+			if publisher.ID == id {
+				return publisher, nil
+			}
+		}
+		return model.Publisher{}, fmt.Errorf("publisher with id %d not found", id)
+	}
+}
+
+func (s *JsonPublisherStore) UpdatePublisher(ctx context.Context, id int, updatedPublisher model.Publisher) (model.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return model.Publisher{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, publisher := range s.publishers {
+			if publisher.ID == id {
+				s.publishers[i] = updatedPublisher
+				return updatedPublisher, nil
+			}
+		}
+		return model.Publisher{}, fmt.Errorf("publisher with id %d not found", id)
+	}
+}
+
+func (s *JsonPublisherStore) DeletePublisher(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, publisher := range s.publishers {
+			if publisher.ID == id {
+				s.publishers = append(s.publishers[:i], s.publishers[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("publisher with id %d not found", id)
+	}
+}
+
+func (s *JsonPublisherStore) SearchPublishers(ctx context.Context, params map[string]string) ([]model.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if params == nil {
+			return s.publishers, nil
+		}
+
+		result := []model.Publisher{}
+		for _, publisher := range s.publishers {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(publisher.Name, value) {
+						matches = false
+					}
+				case "country":
+					if !strings.EqualFold(publisher.Country, value) {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, publisher)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,188 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonPurchaseOrderStore struct {
+	filename       string
+	mutex          sync.RWMutex
+	lastID         int
+	purchaseOrders []model.PurchaseOrder
+}
+
+type PurchaseOrdersData struct {
+	PurchaseOrders []model.PurchaseOrder `json:"purchase_orders"`
+}
+
+func NewJsonPurchaseOrderStore() *JsonPurchaseOrderStore {
+	store := &JsonPurchaseOrderStore{
+		filename:       "../data/purchase_orders.json",
+		purchaseOrders: make([]model.PurchaseOrder, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonPurchaseOrderStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := PurchaseOrdersData{PurchaseOrders: []model.PurchaseOrder{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var purchaseOrdersData PurchaseOrdersData
+	if err := json.Unmarshal(data, &purchaseOrdersData); err != nil {
+		return err
+	}
+
+	s.purchaseOrders = purchaseOrdersData.PurchaseOrders
+
+	for _, purchaseOrder := range s.purchaseOrders {
+		if purchaseOrder.ID > s.lastID {
+			s.lastID = purchaseOrder.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonPurchaseOrderStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(PurchaseOrdersData{PurchaseOrders: s.purchaseOrders}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonPurchaseOrderStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonPurchaseOrderStore) CreatePurchaseOrder(ctx context.Context, purchaseOrder model.PurchaseOrder) (model.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return model.PurchaseOrder{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		purchaseOrder.ID = s.getNextID()
+		s.purchaseOrders = append(s.purchaseOrders, purchaseOrder)
+		return purchaseOrder, nil
+	}
+}
+
+func (s *JsonPurchaseOrderStore) GetPurchaseOrder(ctx context.Context, id int) (model.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return model.PurchaseOrder{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, purchaseOrder := range s.purchaseOrders {
+			if purchaseOrder.ID == id {
+				return purchaseOrder, nil
+			}
+		}
+		return model.PurchaseOrder{}, fmt.Errorf("purchase order with id %d not found", id)
+	}
+}
+
+func (s *JsonPurchaseOrderStore) UpdatePurchaseOrder(ctx context.Context, id int, updatedPurchaseOrder model.PurchaseOrder) (model.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return model.PurchaseOrder{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, purchaseOrder := range s.purchaseOrders {
+			if purchaseOrder.ID == id {
+				s.purchaseOrders[i] = updatedPurchaseOrder
+				return updatedPurchaseOrder, nil
+			}
+		}
+		return model.PurchaseOrder{}, fmt.Errorf("purchase order with id %d not found", id)
+	}
+}
+
+func (s *JsonPurchaseOrderStore) DeletePurchaseOrder(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, purchaseOrder := range s.purchaseOrders {
+			if purchaseOrder.ID == id {
+				s.purchaseOrders = append(s.purchaseOrders[:i], s.purchaseOrders[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("purchase order with id %d not found", id)
+	}
+}
+
+func (s *JsonPurchaseOrderStore) SearchPurchaseOrders(ctx context.Context, params map[string]string) ([]model.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.purchaseOrders, nil
+		}
+
+		result := []model.PurchaseOrder{}
+		for _, purchaseOrder := range s.purchaseOrders {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "supplier_id":
+					if strconv.Itoa(purchaseOrder.SupplierID) != value {
+						matches = false
+					}
+				case "status":
+					if purchaseOrder.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, purchaseOrder)
+			}
+		}
+		return result, nil
+	}
+}
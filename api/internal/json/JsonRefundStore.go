@@ -0,0 +1,191 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonRefundStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	refunds  []model.Refund
+}
+
+type RefundsData struct {
+	Refunds []model.Refund `json:"refunds"`
+}
+
+func NewJsonRefundStore() *JsonRefundStore {
+	store := &JsonRefundStore{
+		filename: "../data/refunds.json",
+		refunds:  make([]model.Refund, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonRefundStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := RefundsData{Refunds: []model.Refund{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var refundsData RefundsData
+	if err := json.Unmarshal(data, &refundsData); err != nil {
+		return err
+	}
+
+	s.refunds = refundsData.Refunds
+
+	for _, refund := range s.refunds {
+		if refund.ID > s.lastID {
+			s.lastID = refund.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonRefundStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(RefundsData{Refunds: s.refunds}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonRefundStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonRefundStore) CreateRefund(ctx context.Context, refund model.Refund) (model.Refund, error) {
+	select {
+	case <-ctx.Done():
+		return model.Refund{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		refund.ID = s.getNextID()
+		s.refunds = append(s.refunds, refund)
+		return refund, nil
+	}
+}
+
+func (s *JsonRefundStore) GetRefund(ctx context.Context, id int) (model.Refund, error) {
+	select {
+	case <-ctx.Done():
+		return model.Refund{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, refund := range s.refunds {
+			if refund.ID == id {
+				return refund, nil
+			}
+		}
+		return model.Refund{}, fmt.Errorf("refund with id %d not found", id)
+	}
+}
+
+func (s *JsonRefundStore) UpdateRefund(ctx context.Context, id int, updatedRefund model.Refund) (model.Refund, error) {
+	select {
+	case <-ctx.Done():
+		return model.Refund{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, refund := range s.refunds {
+			if refund.ID == id {
+				s.refunds[i] = updatedRefund
+				return updatedRefund, nil
+			}
+		}
+		return model.Refund{}, fmt.Errorf("refund with id %d not found", id)
+	}
+}
+
+func (s *JsonRefundStore) DeleteRefund(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, refund := range s.refunds {
+			if refund.ID == id {
+				s.refunds = append(s.refunds[:i], s.refunds[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("refund with id %d not found", id)
+	}
+}
+
+func (s *JsonRefundStore) SearchRefunds(ctx context.Context, params map[string]string) ([]model.Refund, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.refunds, nil
+		}
+
+		result := []model.Refund{}
+		for _, refund := range s.refunds {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(refund.OrderID) != value {
+						matches = false
+					}
+				case "return_id":
+					if strconv.Itoa(refund.ReturnID) != value {
+						matches = false
+					}
+				case "payment_id":
+					if strconv.Itoa(refund.PaymentID) != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, refund)
+			}
+		}
+		return result, nil
+	}
+}
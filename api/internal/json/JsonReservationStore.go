@@ -0,0 +1,191 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonReservationStore struct {
+	filename     string
+	mutex        sync.RWMutex
+	lastID       int
+	reservations []model.Reservation
+}
+
+type ReservationsData struct {
+	Reservations []model.Reservation `json:"reservations"`
+}
+
+func NewJsonReservationStore() *JsonReservationStore {
+	store := &JsonReservationStore{
+		filename:     "../data/reservations.json",
+		reservations: make([]model.Reservation, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonReservationStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := ReservationsData{Reservations: []model.Reservation{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var reservationsData ReservationsData
+	if err := json.Unmarshal(data, &reservationsData); err != nil {
+		return err
+	}
+
+	s.reservations = reservationsData.Reservations
+
+	for _, reservation := range s.reservations {
+		if reservation.ID > s.lastID {
+			s.lastID = reservation.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonReservationStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(ReservationsData{Reservations: s.reservations}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonReservationStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonReservationStore) CreateReservation(ctx context.Context, reservation model.Reservation) (model.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return model.Reservation{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		reservation.ID = s.getNextID()
+		s.reservations = append(s.reservations, reservation)
+		return reservation, nil
+	}
+}
+
+func (s *JsonReservationStore) GetReservation(ctx context.Context, id int) (model.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return model.Reservation{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, reservation := range s.reservations {
+			if reservation.ID == id {
+				return reservation, nil
+			}
+		}
+		return model.Reservation{}, fmt.Errorf("reservation with id %d not found", id)
+	}
+}
+
+func (s *JsonReservationStore) UpdateReservation(ctx context.Context, id int, updatedReservation model.Reservation) (model.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return model.Reservation{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, reservation := range s.reservations {
+			if reservation.ID == id {
+				s.reservations[i] = updatedReservation
+				return updatedReservation, nil
+			}
+		}
+		return model.Reservation{}, fmt.Errorf("reservation with id %d not found", id)
+	}
+}
+
+func (s *JsonReservationStore) DeleteReservation(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, reservation := range s.reservations {
+			if reservation.ID == id {
+				s.reservations = append(s.reservations[:i], s.reservations[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("reservation with id %d not found", id)
+	}
+}
+
+func (s *JsonReservationStore) SearchReservations(ctx context.Context, params map[string]string) ([]model.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.reservations, nil
+		}
+
+		result := []model.Reservation{}
+		for _, reservation := range s.reservations {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(reservation.OrderID) != value {
+						matches = false
+					}
+				case "book_id":
+					if strconv.Itoa(reservation.BookID) != value {
+						matches = false
+					}
+				case "status":
+					if reservation.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, reservation)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,191 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonReturnRequestStore struct {
+	filename       string
+	mutex          sync.RWMutex
+	lastID         int
+	returnRequests []model.ReturnRequest
+}
+
+type ReturnRequestsData struct {
+	ReturnRequests []model.ReturnRequest `json:"return_requests"`
+}
+
+func NewJsonReturnRequestStore() *JsonReturnRequestStore {
+	store := &JsonReturnRequestStore{
+		filename:       "../data/return_requests.json",
+		returnRequests: make([]model.ReturnRequest, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonReturnRequestStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := ReturnRequestsData{ReturnRequests: []model.ReturnRequest{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var returnRequestsData ReturnRequestsData
+	if err := json.Unmarshal(data, &returnRequestsData); err != nil {
+		return err
+	}
+
+	s.returnRequests = returnRequestsData.ReturnRequests
+
+	for _, returnRequest := range s.returnRequests {
+		if returnRequest.ID > s.lastID {
+			s.lastID = returnRequest.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonReturnRequestStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(ReturnRequestsData{ReturnRequests: s.returnRequests}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonReturnRequestStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonReturnRequestStore) CreateReturnRequest(ctx context.Context, returnRequest model.ReturnRequest) (model.ReturnRequest, error) {
+	select {
+	case <-ctx.Done():
+		return model.ReturnRequest{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		returnRequest.ID = s.getNextID()
+		s.returnRequests = append(s.returnRequests, returnRequest)
+		return returnRequest, nil
+	}
+}
+
+func (s *JsonReturnRequestStore) GetReturnRequest(ctx context.Context, id int) (model.ReturnRequest, error) {
+	select {
+	case <-ctx.Done():
+		return model.ReturnRequest{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, returnRequest := range s.returnRequests {
+			if returnRequest.ID == id {
+				return returnRequest, nil
+			}
+		}
+		return model.ReturnRequest{}, fmt.Errorf("return request with id %d not found", id)
+	}
+}
+
+func (s *JsonReturnRequestStore) UpdateReturnRequest(ctx context.Context, id int, updatedReturnRequest model.ReturnRequest) (model.ReturnRequest, error) {
+	select {
+	case <-ctx.Done():
+		return model.ReturnRequest{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, returnRequest := range s.returnRequests {
+			if returnRequest.ID == id {
+				s.returnRequests[i] = updatedReturnRequest
+				return updatedReturnRequest, nil
+			}
+		}
+		return model.ReturnRequest{}, fmt.Errorf("return request with id %d not found", id)
+	}
+}
+
+func (s *JsonReturnRequestStore) DeleteReturnRequest(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, returnRequest := range s.returnRequests {
+			if returnRequest.ID == id {
+				s.returnRequests = append(s.returnRequests[:i], s.returnRequests[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("return request with id %d not found", id)
+	}
+}
+
+func (s *JsonReturnRequestStore) SearchReturnRequests(ctx context.Context, params map[string]string) ([]model.ReturnRequest, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.returnRequests, nil
+		}
+
+		result := []model.ReturnRequest{}
+		for _, returnRequest := range s.returnRequests {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(returnRequest.OrderID) != value {
+						matches = false
+					}
+				case "customer_id":
+					if strconv.Itoa(returnRequest.CustomerID) != value {
+						matches = false
+					}
+				case "status":
+					if returnRequest.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, returnRequest)
+			}
+		}
+		return result, nil
+	}
+}
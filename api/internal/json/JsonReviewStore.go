@@ -0,0 +1,180 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonReviewStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	reviews  []model.Review
+}
+
+type ReviewsData struct {
+	Reviews []model.Review `json:"reviews"`
+}
+
+func NewJsonReviewStore() *JsonReviewStore {
+	store := &JsonReviewStore{
+		filename: "../data/reviews.json",
+		reviews:  make([]model.Review, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonReviewStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonReviewStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := ReviewsData{Reviews: []model.Review{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var reviewsData ReviewsData
+	if err := json.Unmarshal(data, &reviewsData); err != nil {
+		return err
+	}
+
+	s.reviews = reviewsData.Reviews
+
+	for _, review := range s.reviews {
+		if review.ID > s.lastID {
+			s.lastID = review.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonReviewStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(ReviewsData{Reviews: s.reviews}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonReviewStore) CreateReview(ctx context.Context, review model.Review) (model.Review, error) {
+	select {
+	case <-ctx.Done():
+		return model.Review{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		review.ID = s.getNextID()
+		s.reviews = append(s.reviews, review)
+		return review, nil
+	}
+}
+
+func (s *JsonReviewStore) GetReview(ctx context.Context, id int) (model.Review, error) {
+	select {
+	case <-ctx.Done():
+		return model.Review{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, review := range s.reviews {
+			if review.ID == id {
+				return review, nil
+			}
+		}
+		return model.Review{}, fmt.Errorf("review with id %d not found", id)
+	}
+}
+
+func (s *JsonReviewStore) UpdateReview(ctx context.Context, id int, updatedReview model.Review) (model.Review, error) {
+	select {
+	case <-ctx.Done():
+		return model.Review{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, review := range s.reviews {
+			if review.ID == id {
+				s.reviews[i] = updatedReview
+				return updatedReview, nil
+			}
+		}
+		return model.Review{}, fmt.Errorf("review with id %d not found", id)
+	}
+}
+
+func (s *JsonReviewStore) SearchReviews(ctx context.Context, params map[string]string) ([]model.Review, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if params == nil {
+			return s.reviews, nil
+		}
+
+		result := []model.Review{}
+		for _, review := range s.reviews {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "book_id":
+					bookID, err := strconv.Atoi(value)
+					if err != nil || review.BookID != bookID {
+						matches = false
+					}
+				case "customer_id":
+					customerID, err := strconv.Atoi(value)
+					if err != nil || review.CustomerID != customerID {
+						matches = false
+					}
+				case "status":
+					if review.Status != value {
+						matches = false
+					}
+				case "verified":
+					if review.VerifiedPurchase != (value == "true") {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, review)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,188 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonSeriesStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	series   []model.Series
+}
+
+type SeriesData struct {
+	Series []model.Series `json:"series"`
+}
+
+func NewJsonSeriesStore() *JsonSeriesStore {
+	store := &JsonSeriesStore{
+		filename: "../data/series.json",
+		series:   make([]model.Series, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonSeriesStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonSeriesStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := SeriesData{Series: []model.Series{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var seriesData SeriesData
+	if err := json.Unmarshal(data, &seriesData); err != nil {
+		return err
+	}
+
+	s.series = seriesData.Series
+
+	for _, series := range s.series {
+		if series.ID > s.lastID {
+			s.lastID = series.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonSeriesStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(SeriesData{Series: s.series}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonSeriesStore) CreateSeries(ctx context.Context, series model.Series) (model.Series, error) {
+	select {
+	case <-ctx.Done():
+		return model.Series{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		series.ID = s.getNextID()
+		s.series = append(s.series, series)
+		return series, nil
+	}
+}
+
+func (s *JsonSeriesStore) GetSeries(ctx context.Context, id int) (model.Series, error) {
+	select {
+	case <-ctx.Done():
+		return model.Series{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, series := range s.series {
+			if series.ID == id {
+				return series, nil
+			}
+		}
+		return model.Series{}, fmt.Errorf("series with id %d not found", id)
+	}
+}
+
+func (s *JsonSeriesStore) UpdateSeries(ctx context.Context, id int, updatedSeries model.Series) (model.Series, error) {
+	select {
+	case <-ctx.Done():
+		return model.Series{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, series := range s.series {
+			if series.ID == id {
+				s.series[i] = updatedSeries
+				return updatedSeries, nil
+			}
+		}
+		return model.Series{}, fmt.Errorf("series with id %d not found", id)
+	}
+}
+
+func (s *JsonSeriesStore) DeleteSeries(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, series := range s.series {
+			if series.ID == id {
+				s.series = append(s.series[:i], s.series[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("series with id %d not found", id)
+	}
+}
+
+func (s *JsonSeriesStore) SearchSeries(ctx context.Context, params map[string]string) ([]model.Series, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if params == nil {
+			return s.series, nil
+		}
+
+		result := []model.Series{}
+		for _, series := range s.series {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(series.Name, value) {
+						matches = false
+					}
+				case "q":
+					if !strings.Contains(strings.ToLower(series.Name), strings.ToLower(value)) {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, series)
+			}
+		}
+		return result, nil
+	}
+}
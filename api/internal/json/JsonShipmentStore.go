@@ -0,0 +1,191 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonShipmentStore struct {
+	filename  string
+	mutex     sync.RWMutex
+	lastID    int
+	shipments []model.Shipment
+}
+
+type ShipmentsData struct {
+	Shipments []model.Shipment `json:"shipments"`
+}
+
+func NewJsonShipmentStore() *JsonShipmentStore {
+	store := &JsonShipmentStore{
+		filename:  "../data/shipments.json",
+		shipments: make([]model.Shipment, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonShipmentStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := ShipmentsData{Shipments: []model.Shipment{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var shipmentsData ShipmentsData
+	if err := json.Unmarshal(data, &shipmentsData); err != nil {
+		return err
+	}
+
+	s.shipments = shipmentsData.Shipments
+
+	for _, shipment := range s.shipments {
+		if shipment.ID > s.lastID {
+			s.lastID = shipment.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonShipmentStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(ShipmentsData{Shipments: s.shipments}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonShipmentStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonShipmentStore) CreateShipment(ctx context.Context, shipment model.Shipment) (model.Shipment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Shipment{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		shipment.ID = s.getNextID()
+		s.shipments = append(s.shipments, shipment)
+		return shipment, nil
+	}
+}
+
+func (s *JsonShipmentStore) GetShipment(ctx context.Context, id int) (model.Shipment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Shipment{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, shipment := range s.shipments {
+			if shipment.ID == id {
+				return shipment, nil
+			}
+		}
+		return model.Shipment{}, fmt.Errorf("shipment with id %d not found", id)
+	}
+}
+
+func (s *JsonShipmentStore) UpdateShipment(ctx context.Context, id int, updatedShipment model.Shipment) (model.Shipment, error) {
+	select {
+	case <-ctx.Done():
+		return model.Shipment{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, shipment := range s.shipments {
+			if shipment.ID == id {
+				s.shipments[i] = updatedShipment
+				return updatedShipment, nil
+			}
+		}
+		return model.Shipment{}, fmt.Errorf("shipment with id %d not found", id)
+	}
+}
+
+func (s *JsonShipmentStore) DeleteShipment(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, shipment := range s.shipments {
+			if shipment.ID == id {
+				s.shipments = append(s.shipments[:i], s.shipments[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("shipment with id %d not found", id)
+	}
+}
+
+func (s *JsonShipmentStore) SearchShipments(ctx context.Context, params map[string]string) ([]model.Shipment, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.shipments, nil
+		}
+
+		result := []model.Shipment{}
+		for _, shipment := range s.shipments {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "order_id":
+					if strconv.Itoa(shipment.OrderID) != value {
+						matches = false
+					}
+				case "tracking_number":
+					if shipment.TrackingNumber != value {
+						matches = false
+					}
+				case "status":
+					if shipment.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, shipment)
+			}
+		}
+		return result, nil
+	}
+}
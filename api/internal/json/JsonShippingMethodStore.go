@@ -0,0 +1,187 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonShippingMethodStore struct {
+	filename        string
+	mutex           sync.RWMutex
+	lastID          int
+	shippingMethods []model.ShippingMethod
+}
+
+type ShippingMethodsData struct {
+	ShippingMethods []model.ShippingMethod `json:"shipping_methods"`
+}
+
+func NewJsonShippingMethodStore() *JsonShippingMethodStore {
+	store := &JsonShippingMethodStore{
+		filename:        "../data/shipping_methods.json",
+		shippingMethods: make([]model.ShippingMethod, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonShippingMethodStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := ShippingMethodsData{ShippingMethods: []model.ShippingMethod{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var shippingMethodsData ShippingMethodsData
+	if err := json.Unmarshal(data, &shippingMethodsData); err != nil {
+		return err
+	}
+
+	s.shippingMethods = shippingMethodsData.ShippingMethods
+
+	for _, shippingMethod := range s.shippingMethods {
+		if shippingMethod.ID > s.lastID {
+			s.lastID = shippingMethod.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonShippingMethodStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(ShippingMethodsData{ShippingMethods: s.shippingMethods}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonShippingMethodStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonShippingMethodStore) CreateShippingMethod(ctx context.Context, shippingMethod model.ShippingMethod) (model.ShippingMethod, error) {
+	select {
+	case <-ctx.Done():
+		return model.ShippingMethod{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		shippingMethod.ID = s.getNextID()
+		s.shippingMethods = append(s.shippingMethods, shippingMethod)
+		return shippingMethod, nil
+	}
+}
+
+func (s *JsonShippingMethodStore) GetShippingMethod(ctx context.Context, id int) (model.ShippingMethod, error) {
+	select {
+	case <-ctx.Done():
+		return model.ShippingMethod{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, shippingMethod := range s.shippingMethods {
+			if shippingMethod.ID == id {
+				return shippingMethod, nil
+			}
+		}
+		return model.ShippingMethod{}, fmt.Errorf("shippingMethod with id %d not found", id)
+	}
+}
+
+func (s *JsonShippingMethodStore) UpdateShippingMethod(ctx context.Context, id int, updatedShippingMethod model.ShippingMethod) (model.ShippingMethod, error) {
+	select {
+	case <-ctx.Done():
+		return model.ShippingMethod{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, shippingMethod := range s.shippingMethods {
+			if shippingMethod.ID == id {
+				s.shippingMethods[i] = updatedShippingMethod
+				return updatedShippingMethod, nil
+			}
+		}
+		return model.ShippingMethod{}, fmt.Errorf("shippingMethod with id %d not found", id)
+	}
+}
+
+func (s *JsonShippingMethodStore) DeleteShippingMethod(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, shippingMethod := range s.shippingMethods {
+			if shippingMethod.ID == id {
+				s.shippingMethods = append(s.shippingMethods[:i], s.shippingMethods[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("shippingMethod with id %d not found", id)
+	}
+}
+
+func (s *JsonShippingMethodStore) SearchShippingMethods(ctx context.Context, params map[string]string) ([]model.ShippingMethod, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.shippingMethods, nil
+		}
+
+		result := []model.ShippingMethod{}
+		for _, shippingMethod := range s.shippingMethods {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(shippingMethod.Name, value) {
+						matches = false
+					}
+				case "carrier":
+					if !strings.EqualFold(shippingMethod.Carrier, value) {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, shippingMethod)
+			}
+		}
+		return result, nil
+	}
+}
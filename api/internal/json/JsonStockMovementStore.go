@@ -0,0 +1,156 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonStockMovementStore struct {
+	filename  string
+	mutex     sync.RWMutex
+	lastID    int
+	movements []model.StockMovement
+}
+
+type StockMovementsData struct {
+	StockMovements []model.StockMovement `json:"stock_movements"`
+}
+
+func NewJsonStockMovementStore() *JsonStockMovementStore {
+	store := &JsonStockMovementStore{
+		filename:  "../data/stock_movements.json",
+		movements: make([]model.StockMovement, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonStockMovementStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := StockMovementsData{StockMovements: []model.StockMovement{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var movementsData StockMovementsData
+	if err := json.Unmarshal(data, &movementsData); err != nil {
+		return err
+	}
+
+	s.movements = movementsData.StockMovements
+
+	for _, movement := range s.movements {
+		if movement.ID > s.lastID {
+			s.lastID = movement.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonStockMovementStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(StockMovementsData{StockMovements: s.movements}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonStockMovementStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonStockMovementStore) CreateStockMovement(ctx context.Context, movement model.StockMovement) (model.StockMovement, error) {
+	select {
+	case <-ctx.Done():
+		return model.StockMovement{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		movement.ID = s.getNextID()
+		s.movements = append(s.movements, movement)
+		return movement, nil
+	}
+}
+
+func (s *JsonStockMovementStore) GetStockMovement(ctx context.Context, id int) (model.StockMovement, error) {
+	select {
+	case <-ctx.Done():
+		return model.StockMovement{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, movement := range s.movements {
+			if movement.ID == id {
+				return movement, nil
+			}
+		}
+		return model.StockMovement{}, fmt.Errorf("stock movement with id %d not found", id)
+	}
+}
+
+func (s *JsonStockMovementStore) SearchStockMovements(ctx context.Context, params map[string]string) ([]model.StockMovement, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.movements, nil
+		}
+
+		result := []model.StockMovement{}
+		for _, movement := range s.movements {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "book_id":
+					if strconv.Itoa(movement.BookID) != value {
+						matches = false
+					}
+				case "type":
+					if movement.Type != value {
+						matches = false
+					}
+				case "reference":
+					if movement.Reference != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, movement)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,187 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonSupplierStore struct {
+	filename  string
+	mutex     sync.RWMutex
+	lastID    int
+	suppliers []model.Supplier
+}
+
+type SuppliersData struct {
+	Suppliers []model.Supplier `json:"suppliers"`
+}
+
+func NewJsonSupplierStore() *JsonSupplierStore {
+	store := &JsonSupplierStore{
+		filename:  "../data/suppliers.json",
+		suppliers: make([]model.Supplier, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonSupplierStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := SuppliersData{Suppliers: []model.Supplier{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var suppliersData SuppliersData
+	if err := json.Unmarshal(data, &suppliersData); err != nil {
+		return err
+	}
+
+	s.suppliers = suppliersData.Suppliers
+
+	for _, supplier := range s.suppliers {
+		if supplier.ID > s.lastID {
+			s.lastID = supplier.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonSupplierStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(SuppliersData{Suppliers: s.suppliers}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonSupplierStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonSupplierStore) CreateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return model.Supplier{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		supplier.ID = s.getNextID()
+		s.suppliers = append(s.suppliers, supplier)
+		return supplier, nil
+	}
+}
+
+func (s *JsonSupplierStore) GetSupplier(ctx context.Context, id int) (model.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return model.Supplier{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, supplier := range s.suppliers {
+			if supplier.ID == id {
+				return supplier, nil
+			}
+		}
+		return model.Supplier{}, fmt.Errorf("supplier with id %d not found", id)
+	}
+}
+
+func (s *JsonSupplierStore) UpdateSupplier(ctx context.Context, id int, updatedSupplier model.Supplier) (model.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return model.Supplier{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, supplier := range s.suppliers {
+			if supplier.ID == id {
+				s.suppliers[i] = updatedSupplier
+				return updatedSupplier, nil
+			}
+		}
+		return model.Supplier{}, fmt.Errorf("supplier with id %d not found", id)
+	}
+}
+
+func (s *JsonSupplierStore) DeleteSupplier(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, supplier := range s.suppliers {
+			if supplier.ID == id {
+				s.suppliers = append(s.suppliers[:i], s.suppliers[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("supplier with id %d not found", id)
+	}
+}
+
+func (s *JsonSupplierStore) SearchSuppliers(ctx context.Context, params map[string]string) ([]model.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.suppliers, nil
+		}
+
+		result := []model.Supplier{}
+		for _, supplier := range s.suppliers {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(supplier.Name, value) {
+						matches = false
+					}
+				case "email":
+					if !strings.EqualFold(supplier.Email, value) {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, supplier)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,195 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonTransferStore struct {
+	filename  string
+	mutex     sync.RWMutex
+	lastID    int
+	transfers []model.Transfer
+}
+
+type TransfersData struct {
+	Transfers []model.Transfer `json:"transfers"`
+}
+
+func NewJsonTransferStore() *JsonTransferStore {
+	store := &JsonTransferStore{
+		filename:  "../data/transfers.json",
+		transfers: make([]model.Transfer, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonTransferStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := TransfersData{Transfers: []model.Transfer{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var transfersData TransfersData
+	if err := json.Unmarshal(data, &transfersData); err != nil {
+		return err
+	}
+
+	s.transfers = transfersData.Transfers
+
+	for _, transfer := range s.transfers {
+		if transfer.ID > s.lastID {
+			s.lastID = transfer.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonTransferStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(TransfersData{Transfers: s.transfers}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonTransferStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonTransferStore) CreateTransfer(ctx context.Context, transfer model.Transfer) (model.Transfer, error) {
+	select {
+	case <-ctx.Done():
+		return model.Transfer{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		transfer.ID = s.getNextID()
+		s.transfers = append(s.transfers, transfer)
+		return transfer, nil
+	}
+}
+
+func (s *JsonTransferStore) GetTransfer(ctx context.Context, id int) (model.Transfer, error) {
+	select {
+	case <-ctx.Done():
+		return model.Transfer{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, transfer := range s.transfers {
+			if transfer.ID == id {
+				return transfer, nil
+			}
+		}
+		return model.Transfer{}, fmt.Errorf("transfer with id %d not found", id)
+	}
+}
+
+func (s *JsonTransferStore) UpdateTransfer(ctx context.Context, id int, updatedTransfer model.Transfer) (model.Transfer, error) {
+	select {
+	case <-ctx.Done():
+		return model.Transfer{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, transfer := range s.transfers {
+			if transfer.ID == id {
+				s.transfers[i] = updatedTransfer
+				return updatedTransfer, nil
+			}
+		}
+		return model.Transfer{}, fmt.Errorf("transfer with id %d not found", id)
+	}
+}
+
+func (s *JsonTransferStore) DeleteTransfer(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, transfer := range s.transfers {
+			if transfer.ID == id {
+				s.transfers = append(s.transfers[:i], s.transfers[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("transfer with id %d not found", id)
+	}
+}
+
+func (s *JsonTransferStore) SearchTransfers(ctx context.Context, params map[string]string) ([]model.Transfer, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.transfers, nil
+		}
+
+		result := []model.Transfer{}
+		for _, transfer := range s.transfers {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "book_id":
+					if strconv.Itoa(transfer.BookID) != value {
+						matches = false
+					}
+				case "from_warehouse_id":
+					if strconv.Itoa(transfer.FromWarehouseID) != value {
+						matches = false
+					}
+				case "to_warehouse_id":
+					if strconv.Itoa(transfer.ToWarehouseID) != value {
+						matches = false
+					}
+				case "status":
+					if transfer.Status != value {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, transfer)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,187 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+type JsonWarehouseStore struct {
+	filename   string
+	mutex      sync.RWMutex
+	lastID     int
+	warehouses []model.Warehouse
+}
+
+type WarehousesData struct {
+	Warehouses []model.Warehouse `json:"warehouses"`
+}
+
+func NewJsonWarehouseStore() *JsonWarehouseStore {
+	store := &JsonWarehouseStore{
+		filename:   "../data/warehouses.json",
+		warehouses: make([]model.Warehouse, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonWarehouseStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := WarehousesData{Warehouses: []model.Warehouse{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var warehousesData WarehousesData
+	if err := json.Unmarshal(data, &warehousesData); err != nil {
+		return err
+	}
+
+	s.warehouses = warehousesData.Warehouses
+
+	for _, warehouse := range s.warehouses {
+		if warehouse.ID > s.lastID {
+			s.lastID = warehouse.ID
+		}
+	}
+	return nil
+}
+
+func (s *JsonWarehouseStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(WarehousesData{Warehouses: s.warehouses}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonWarehouseStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonWarehouseStore) CreateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	select {
+	case <-ctx.Done():
+		return model.Warehouse{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		warehouse.ID = s.getNextID()
+		s.warehouses = append(s.warehouses, warehouse)
+		return warehouse, nil
+	}
+}
+
+func (s *JsonWarehouseStore) GetWarehouse(ctx context.Context, id int) (model.Warehouse, error) {
+	select {
+	case <-ctx.Done():
+		return model.Warehouse{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, warehouse := range s.warehouses {
+			if warehouse.ID == id {
+				return warehouse, nil
+			}
+		}
+		return model.Warehouse{}, fmt.Errorf("warehouse with id %d not found", id)
+	}
+}
+
+func (s *JsonWarehouseStore) UpdateWarehouse(ctx context.Context, id int, updatedWarehouse model.Warehouse) (model.Warehouse, error) {
+	select {
+	case <-ctx.Done():
+		return model.Warehouse{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, warehouse := range s.warehouses {
+			if warehouse.ID == id {
+				s.warehouses[i] = updatedWarehouse
+				return updatedWarehouse, nil
+			}
+		}
+		return model.Warehouse{}, fmt.Errorf("warehouse with id %d not found", id)
+	}
+}
+
+func (s *JsonWarehouseStore) DeleteWarehouse(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, warehouse := range s.warehouses {
+			if warehouse.ID == id {
+				s.warehouses = append(s.warehouses[:i], s.warehouses[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("warehouse with id %d not found", id)
+	}
+}
+
+func (s *JsonWarehouseStore) SearchWarehouses(ctx context.Context, params map[string]string) ([]model.Warehouse, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		if params == nil {
+			return s.warehouses, nil
+		}
+
+		result := []model.Warehouse{}
+		for _, warehouse := range s.warehouses {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "name":
+					if !strings.EqualFold(warehouse.Name, value) {
+						matches = false
+					}
+				case "type":
+					if !strings.EqualFold(warehouse.Type, value) {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, warehouse)
+			}
+		}
+		return result, nil
+	}
+}
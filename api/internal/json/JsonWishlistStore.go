@@ -0,0 +1,190 @@
+package json
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type JsonWishlistStore struct {
+	filename string
+	mutex    sync.RWMutex
+	lastID   int
+	items    []model.WishlistItem
+}
+
+type WishlistData struct {
+	WishlistItems []model.WishlistItem `json:"wishlist_items"`
+}
+
+func NewJsonWishlistStore() *JsonWishlistStore {
+	store := &JsonWishlistStore{
+		filename: "../data/wishlists.json",
+		items:    make([]model.WishlistItem, 0),
+	}
+
+	if err := store.loadFromFile(); err != nil {
+		panic(err)
+	}
+
+	return store
+}
+
+func (s *JsonWishlistStore) getNextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *JsonWishlistStore) loadFromFile() error {
+	if err := os.MkdirAll("../data", 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+		initialData := WishlistData{WishlistItems: []model.WishlistItem{}}
+		data, _ := json.MarshalIndent(initialData, "", "  ")
+		if err := os.WriteFile(s.filename, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var itemsData WishlistData
+	if err := json.Unmarshal(data, &itemsData); err != nil {
+		return err
+	}
+
+	s.items = itemsData.WishlistItems
+
+	for _, item := range s.items {
+		if item.ID > s.lastID {
+			s.lastID = item.ID
+		}
+	}
+
+	return nil
+}
+
+func (s *JsonWishlistStore) SaveToFile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(WishlistData{WishlistItems: s.items}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename, data, 0644)
+}
+
+func (s *JsonWishlistStore) CreateWishlistItem(ctx context.Context, item model.WishlistItem) (model.WishlistItem, error) {
+	select {
+	case <-ctx.Done():
+		return model.WishlistItem{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		item.ID = s.getNextID()
+		s.items = append(s.items, item)
+		return item, nil
+	}
+}
+
+func (s *JsonWishlistStore) GetWishlistItem(ctx context.Context, id int) (model.WishlistItem, error) {
+	select {
+	case <-ctx.Done():
+		return model.WishlistItem{}, ctx.Err()
+	default:
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		for _, item := range s.items {
+			if item.ID == id {
+				return item, nil
+			}
+		}
+		return model.WishlistItem{}, fmt.Errorf("item with id %d not found", id)
+	}
+}
+
+func (s *JsonWishlistStore) UpdateWishlistItem(ctx context.Context, id int, updatedWishlistItem model.WishlistItem) (model.WishlistItem, error) {
+	select {
+	case <-ctx.Done():
+		return model.WishlistItem{}, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, item := range s.items {
+			if item.ID == id {
+				s.items[i] = updatedWishlistItem
+				return updatedWishlistItem, nil
+			}
+		}
+		return model.WishlistItem{}, fmt.Errorf("item with id %d not found", id)
+	}
+}
+
+func (s *JsonWishlistStore) DeleteWishlistItem(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		for i, item := range s.items {
+			if item.ID == id {
+				s.items = append(s.items[:i], s.items[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("item with id %d not found", id)
+	}
+}
+
+func (s *JsonWishlistStore) SearchWishlistItems(ctx context.Context, params map[string]string) ([]model.WishlistItem, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if params == nil {
+			return s.items, nil
+		}
+
+		result := []model.WishlistItem{}
+		for _, item := range s.items {
+			matches := true
+			for key, value := range params {
+				switch key {
+				case "customer_id":
+					customerID, err := strconv.Atoi(value)
+					if err != nil || item.CustomerID != customerID {
+						matches = false
+					}
+				case "book_id":
+					bookID, err := strconv.Atoi(value)
+					if err != nil || item.BookID != bookID {
+						matches = false
+					}
+				}
+			}
+			if matches {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+	}
+}
@@ -2,25 +2,125 @@ package model
 
 import "time"
 
+// Book lists its authors, editors, translators and illustrators in
+// Contributors. AuthorID is the first author, kept for the clients from before
+// contributors. Publisher is the name of the publisher of PublisherID, or a
+// free-form name for books not linked to a publisher. NextInSeries is only
+// filled in when a single book is read. RatingAverage and RatingCount sum up
+// the approved reviews of the book.
 type Book struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	AuthorID    int       `json:"author_id"`
-	Genres      []string  `json:"genres"`
-	PublishedAt time.Time `json:"published_at"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
+	ID             int             `json:"id"`
+	Title          string          `json:"title"`
+	AuthorID       int             `json:"author_id,omitempty"`
+	Contributors   []Contributor   `json:"contributors"`
+	Genres         []string        `json:"genres"`
+	PublishedAt    time.Time       `json:"published_at"`
+	Price          Money           `json:"price"`
+	Prices         []Money         `json:"prices,omitempty"`
+	SalePrices     []SalePrice     `json:"sale_prices,omitempty"`
+	TaxCategory    string          `json:"tax_category,omitempty"`
+	Weight         int             `json:"weight,omitempty"`
+	ISBN           string          `json:"isbn,omitempty"`
+	PublisherID    int             `json:"publisher_id,omitempty"`
+	Publisher      string          `json:"publisher,omitempty"`
+	Language       string          `json:"language,omitempty"`
+	PageCount      int             `json:"page_count,omitempty"`
+	Format         string          `json:"format,omitempty"`
+	Edition        string          `json:"edition,omitempty"`
+	SeriesID       int             `json:"series_id,omitempty"`
+	SeriesPosition int             `json:"series_position,omitempty"`
+	NextInSeries   *BookReference  `json:"next_in_series,omitempty"`
+	Cover          *BookCover      `json:"cover,omitempty"`
+	RatingAverage  float64         `json:"rating_average"`
+	RatingCount    int             `json:"rating_count"`
+	Stock          int             `json:"stock"`
+	Locations      []LocationStock `json:"locations"`
 }
 
+const (
+	BookFormatHardcover = "hardcover"
+	BookFormatPaperback = "paperback"
+	BookFormatEbook     = "ebook"
+)
+
+const (
+	ContributorAuthor      = "author"
+	ContributorEditor      = "editor"
+	ContributorTranslator  = "translator"
+	ContributorIllustrator = "illustrator"
+)
+
+// Contributor is an author who worked on a book in a role. Contributors are
+// listed by Position, starting at 1.
+type Contributor struct {
+	AuthorID int    `json:"author_id"`
+	Role     string `json:"role"`
+	Position int    `json:"position"`
+}
+
+// BookCover describes the cover image of a book. URLs gives the address of the
+// original image and of every thumbnail by size.
+type BookCover struct {
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	URLs        map[string]string `json:"urls"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// BookReference points to another book.
+type BookReference struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// SalePrice replaces the price of a book in the currency of the sale price from
+// StartsAt until EndsAt, or for good when EndsAt is not set.
+type SalePrice struct {
+	Price    Money      `json:"price"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// LocationStock is the stock of a book held at one warehouse. Book.Stock is the
+// sum of all locations; InTransit counts copies on their way to the location.
+type LocationStock struct {
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`
+	InTransit   int `json:"in_transit"`
+}
+
+// BookInput creates or updates a book. A PublishedAt in the future lists an
+// upcoming title, which customers pre-order until its release; books are
+// published on creation when it is not set. ISBN takes an ISBN-10 or ISBN-13,
+// with or without hyphens, and is stored as an ISBN-13. A book sent with an
+// AuthorID and no Contributors has that author as its only contributor. A
+// book added to a series without a SeriesPosition comes last.
 type BookInput struct {
-	Title    string   `json:"title"`
-	AuthorID int      `json:"author_id"`
-	Genres   []string `json:"genres"`
-	Price    float64  `json:"price"`
-	Stock    int      `json:"stock"`
+	Title          string        `json:"title"`
+	AuthorID       int           `json:"author_id,omitempty"`
+	Contributors   []Contributor `json:"contributors,omitempty"`
+	Genres         []string      `json:"genres"`
+	Price          Money         `json:"price"`
+	Prices         []Money       `json:"prices,omitempty"`
+	SalePrices     []SalePrice   `json:"sale_prices,omitempty"`
+	TaxCategory    string        `json:"tax_category,omitempty"`
+	Weight         int           `json:"weight,omitempty"`
+	ISBN           string        `json:"isbn,omitempty"`
+	PublisherID    int           `json:"publisher_id,omitempty"`
+	Publisher      string        `json:"publisher,omitempty"`
+	Language       string        `json:"language,omitempty"`
+	PageCount      int           `json:"page_count,omitempty"`
+	Format         string        `json:"format,omitempty"`
+	Edition        string        `json:"edition,omitempty"`
+	SeriesID       int           `json:"series_id,omitempty"`
+	SeriesPosition int           `json:"series_position,omitempty"`
+	Stock          int           `json:"stock"`
+	PublishedAt    *time.Time    `json:"published_at,omitempty"`
 }
 
 type BookSale struct {
 	BookID   int
 	Quantity int
+	Revenue  Money
 }
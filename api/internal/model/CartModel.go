@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+const (
+	CartActive     = "Active"
+	CartCheckedOut = "Checked Out"
+	CartMerged     = "Merged"
+	CartExpired    = "Expired"
+)
+
+// Cart is a server-side shopping cart. Anonymous carts have no customer until
+// they are merged into the cart of a customer.
+type Cart struct {
+	ID         int        `json:"id"`
+	CustomerID int        `json:"customer_id"`
+	Items      []CartItem `json:"items"`
+	Status     string     `json:"status"`
+	OrderID    int        `json:"order_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+type CartItem struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+type CartInput struct {
+	CustomerID int        `json:"customer_id"`
+	Items      []CartItem `json:"items"`
+}
+
+type CartItemUpdate struct {
+	Quantity int `json:"quantity"`
+}
+
+type CartMergeInput struct {
+	CustomerID int `json:"customer_id"`
+}
+
+type CartCheckoutInput struct {
+	CouponCode       string `json:"coupon_code"`
+	ShippingMethodID int    `json:"shipping_method_id"`
+	RedeemPoints     int    `json:"redeem_points,omitempty"`
+	AllowBackorder   bool   `json:"allow_backorder,omitempty"`
+}
+
+// CartView is a cart priced with the current book prices and stock.
+type CartView struct {
+	ID         int        `json:"id"`
+	CustomerID int        `json:"customer_id"`
+	Status     string     `json:"status"`
+	OrderID    int        `json:"order_id,omitempty"`
+	Lines      []CartLine `json:"lines"`
+	Currency   string     `json:"currency"`
+	Total      Money      `json:"total"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+type CartLine struct {
+	BookID    int    `json:"book_id"`
+	Title     string `json:"title"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Discount  Money  `json:"discount"`
+	LineTotal Money  `json:"line_total"`
+	Available int    `json:"available"`
+	InStock   bool   `json:"in_stock"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	CreditAccountGiftCard    = "gift_card"
+	CreditAccountStoreCredit = "store_credit"
+)
+
+const (
+	CreditIssue   = "issue"
+	CreditRedeem  = "redeem"
+	CreditRelease = "release"
+	CreditExpire  = "expire"
+)
+
+// CreditTransaction is an entry of the ledger of gift card and store credit
+// balances. Amount is positive when it adds to the balance and negative when
+// it takes from it; Balance is the balance after the entry.
+type CreditTransaction struct {
+	ID         int       `json:"id"`
+	Account    string    `json:"account"`
+	GiftCardID int       `json:"gift_card_id,omitempty"`
+	CustomerID int       `json:"customer_id,omitempty"`
+	OrderID    int       `json:"order_id,omitempty"`
+	RefundID   int       `json:"refund_id,omitempty"`
+	Type       string    `json:"type"`
+	Amount     Money     `json:"amount"`
+	Balance    Money     `json:"balance"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StoreCreditInput issues store credit to a customer, e.g. as a goodwill
+// gesture.
+type StoreCreditInput struct {
+	Amount Money  `json:"amount"`
+	Note   string `json:"note"`
+}
+
+// StoreCredit is the store credit balance of a customer with its ledger.
+type StoreCredit struct {
+	CustomerID   int                 `json:"customer_id"`
+	Balance      Money               `json:"balance"`
+	Transactions []CreditTransaction `json:"transactions"`
+}
@@ -3,15 +3,19 @@ package model
 import "time"
 
 type Customer struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Address   Address   `json:"address"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Address       Address   `json:"address"`
+	Currency      string    `json:"currency,omitempty"`
+	StoreCredit   Money     `json:"store_credit"`
+	LoyaltyPoints int       `json:"loyalty_points"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type CustomerInput struct {
-	Name    string  `json:"name"`
-	Email   string  `json:"email"`
-	Address Address `json:"address"`
+	Name     string  `json:"name"`
+	Email    string  `json:"email"`
+	Address  Address `json:"address"`
+	Currency string  `json:"currency,omitempty"`
 }
@@ -0,0 +1,8 @@
+package model
+
+// ExchangeRateTable gives, for every currency the store sells in, how many
+// units of it one unit of the base currency buys.
+type ExchangeRateTable struct {
+	BaseCurrency string             `json:"base_currency"`
+	Rates        map[string]float64 `json:"rates"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// Genre is a node of the genre tree. Books name their genres by Name; the Slug
+// and Aliases are other spellings that lead to the same genre.
+type Genre struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Slug     string   `json:"slug"`
+	ParentID int      `json:"parent_id,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// GenreInput creates or updates a genre. The slug is made from the name when
+// it is not set.
+type GenreInput struct {
+	Name     string   `json:"name"`
+	Slug     string   `json:"slug,omitempty"`
+	ParentID int      `json:"parent_id,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	GiftCardActive  = "Active"
+	GiftCardExpired = "Expired"
+)
+
+// GiftCard is a prepaid balance, in the currency of the balance, that pays for
+// orders when its code is given at payment.
+type GiftCard struct {
+	ID             int        `json:"id"`
+	Code           string     `json:"code"`
+	CustomerID     int        `json:"customer_id,omitempty"`
+	InitialBalance Money      `json:"initial_balance"`
+	Balance        Money      `json:"balance"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// GiftCardInput issues a gift card; a code is generated when none is given.
+type GiftCardInput struct {
+	Code       string     `json:"code"`
+	CustomerID int        `json:"customer_id,omitempty"`
+	Balance    Money      `json:"balance"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
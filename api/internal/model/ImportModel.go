@@ -0,0 +1,49 @@
+package model
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatONIX = "onix"
+)
+
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportFailed    = "failed"
+)
+
+// ImportReport sums up a bulk import. In a dry run nothing is saved and the
+// report tells what the import would have done; books and authors it would
+// have created have no ID.
+type ImportReport struct {
+	Format         string      `json:"format"`
+	DryRun         bool        `json:"dry_run"`
+	Created        int         `json:"created"`
+	Updated        int         `json:"updated"`
+	Failed         int         `json:"failed"`
+	AuthorsCreated int         `json:"authors_created"`
+	AuthorsUpdated int         `json:"authors_updated"`
+	Rows           []ImportRow `json:"rows"`
+}
+
+// ImportRow reports what became of one book of the file: the line of a CSV
+// file where the record starts, or the position of the product in an ONIX
+// message. Status is created, updated or failed, with the reason in Error.
+type ImportRow struct {
+	Row     int            `json:"row"`
+	Status  string         `json:"status"`
+	BookID  int            `json:"book_id,omitempty"`
+	Title   string         `json:"title,omitempty"`
+	ISBN    string         `json:"isbn,omitempty"`
+	Authors []ImportAuthor `json:"authors,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// ImportAuthor reports an author of an imported book, matched on their name:
+// created, updated when the file brought a new biography, or unchanged.
+type ImportAuthor struct {
+	AuthorID int    `json:"author_id,omitempty"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Status   string `json:"status"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const (
+	InvoiceTypeInvoice    = "invoice"
+	InvoiceTypeCreditNote = "credit_note"
+)
+
+// Invoice is the accounting document issued when an order is paid, or the
+// credit note issued when part of it is refunded. Invoices are never changed
+// once issued: they copy the customer and the lines they bill, so that later
+// changes to the customer or the catalog don't alter them.
+type Invoice struct {
+	ID            int           `json:"id"`
+	Number        string        `json:"number"`
+	Type          string        `json:"type"`
+	OrderID       int           `json:"order_id"`
+	RefundID      int           `json:"refund_id,omitempty"`
+	InvoiceNumber string        `json:"invoice_number,omitempty"`
+	Customer      InvoiceParty  `json:"customer"`
+	Lines         []InvoiceLine `json:"lines"`
+	Currency      string        `json:"currency"`
+	Subtotal      Money         `json:"subtotal"`
+	DiscountTotal Money         `json:"discount_total"`
+	Shipping      Money         `json:"shipping"`
+	Tax           Money         `json:"tax"`
+	Total         Money         `json:"total"`
+	IssuedAt      time.Time     `json:"issued_at"`
+}
+
+// InvoiceParty is the customer billed by an invoice, as of its issue.
+type InvoiceParty struct {
+	CustomerID int     `json:"customer_id"`
+	Name       string  `json:"name"`
+	Email      string  `json:"email"`
+	Address    Address `json:"address"`
+}
+
+// InvoiceLine is a billed book, or the refunded amount of a credit note. The
+// line total is after discounts and before tax.
+type InvoiceLine struct {
+	BookID      int     `json:"book_id,omitempty"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   Money   `json:"unit_price"`
+	Discount    Money   `json:"discount"`
+	LineTotal   Money   `json:"line_total"`
+	TaxRate     float64 `json:"tax_rate"`
+	Tax         Money   `json:"tax"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const (
+	LoyaltyAwardOnPaid      = "paid"
+	LoyaltyAwardOnDelivered = "delivered"
+)
+
+const (
+	LoyaltyEarn     = "earn"
+	LoyaltyRedeem   = "redeem"
+	LoyaltyRelease  = "release"
+	LoyaltyClawback = "clawback"
+)
+
+// LoyaltyProgram is the loyalty program loaded from a file. Customers earn
+// PointsPerUnit points per unit of the base currency spent, times the
+// multiplier of their tier, and each point redeemed takes PointValue of the
+// base currency off an order.
+type LoyaltyProgram struct {
+	PointsPerUnit float64       `json:"points_per_unit"`
+	PointValue    float64       `json:"point_value"`
+	AwardOn       string        `json:"award_on"`
+	Tiers         []LoyaltyTier `json:"tiers"`
+}
+
+// LoyaltyTier is reached by customers who spent at least MinSpend of the base
+// currency over the last twelve months.
+type LoyaltyTier struct {
+	Name       string  `json:"name"`
+	MinSpend   float64 `json:"min_spend"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// LoyaltyTransaction is an entry of the ledger of loyalty points. Points are
+// positive when they add to the balance and negative when they take from it;
+// Balance is the balance after the entry.
+type LoyaltyTransaction struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	OrderID    int       `json:"order_id,omitempty"`
+	RefundID   int       `json:"refund_id,omitempty"`
+	Type       string    `json:"type"`
+	Points     int       `json:"points"`
+	Balance    int       `json:"balance"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LoyaltyAccount is the loyalty points balance of a customer, with the tier
+// reached by the spend of the last twelve months and the ledger.
+type LoyaltyAccount struct {
+	CustomerID   int                  `json:"customer_id"`
+	Points       int                  `json:"points"`
+	Tier         string               `json:"tier,omitempty"`
+	Multiplier   float64              `json:"multiplier"`
+	RollingSpend Money                `json:"rolling_spend"`
+	Transactions []LoyaltyTransaction `json:"transactions"`
+}
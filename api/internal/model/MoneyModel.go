@@ -0,0 +1,133 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Money is an amount in the minor units of its ISO 4217 currency, e.g. cents
+// of USD. An empty currency stands for the base currency of the store.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// zeroDecimalCurrencies have no minor units.
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"CLP": true,
+	"ISK": true,
+	"VND": true,
+}
+
+// baseCurrency stands in for amounts without a currency.
+var baseCurrency string
+
+// SetBaseCurrency sets the base currency of the store, which gives amounts
+// without a currency their minor units. It must be set before amounts are read.
+func SetBaseCurrency(currency string) {
+	baseCurrency = strings.ToUpper(currency)
+}
+
+// CurrencyDecimals returns the number of minor unit digits of a currency, those
+// of the base currency when none is given.
+func CurrencyDecimals(currency string) int {
+	if currency == "" {
+		currency = baseCurrency
+	}
+	if zeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return 0
+	}
+	return 2
+}
+
+// NewMoney converts an amount in major units, e.g. 12.99, into Money.
+func NewMoney(value float64, currency string) Money {
+	scale := math.Pow10(CurrencyDecimals(currency))
+	return Money{Amount: int64(math.Round(value * scale)), Currency: currency}
+}
+
+// Add and Sub refuse amounts of two different currencies; callers convert
+// first. An amount without a currency takes the currency of the other.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.currencyWith(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// Sum adds up amounts of the same currency.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// MulRate multiplies the amount by a rate, rounding half away from zero to the
+// nearest minor unit.
+func (m Money) MulRate(rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Float returns the amount in major units.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyDecimals(m.Currency))
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%.*f %s", CurrencyDecimals(m.Currency), m.Float(), m.Currency))
+}
+
+// UnmarshalJSON also reads a bare number, as written before amounts carried a
+// currency, as an amount in major units of the base currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value float64
+	if err := json.Unmarshal(data, &value); err == nil {
+		*m = NewMoney(value, "")
+		return nil
+	}
+
+	type money Money
+	var decoded money
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*m = Money(decoded)
+	m.Currency = strings.ToUpper(m.Currency)
+	return nil
+}
+
+func (m Money) currencyWith(other Money) (string, error) {
+	if m.Currency == "" {
+		return other.Currency, nil
+	}
+	if other.Currency != "" && other.Currency != m.Currency {
+		return "", fmt.Errorf("cannot combine amounts in %s and %s", m.Currency, other.Currency)
+	}
+	return m.Currency, nil
+}
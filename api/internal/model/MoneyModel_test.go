@@ -0,0 +1,114 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"mul", Money{1299, "USD"}.Mul(3), Money{3897, "USD"}},
+		{"mul rate rounds half away from zero", Money{5, "USD"}.MulRate(0.5), Money{3, "USD"}},
+		{"mul rate of a negative amount", Money{-5, "USD"}.MulRate(0.5), Money{-3, "USD"}},
+		{"new money", NewMoney(12.99, "USD"), Money{1299, "USD"}},
+		{"new money without minor units", NewMoney(1500, "JPY"), Money{1500, "JPY"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func() (Money, error)
+		want    Money
+		wantErr bool
+	}{
+		{"add", func() (Money, error) { return Money{1299, "USD"}.Add(Money{101, "USD"}) }, Money{1400, "USD"}, false},
+		{"add to an amount without currency", func() (Money, error) { return Money{}.Add(Money{500, "EUR"}) }, Money{500, "EUR"}, false},
+		{"add an amount without currency", func() (Money, error) { return Money{500, "EUR"}.Add(Money{250, ""}) }, Money{750, "EUR"}, false},
+		{"sub", func() (Money, error) { return Money{1000, "USD"}.Sub(Money{1299, "USD"}) }, Money{-299, "USD"}, false},
+		{"sum", func() (Money, error) { return Sum(Money{100, "USD"}, Money{}, Money{250, "USD"}) }, Money{350, "USD"}, false},
+		{"sum of nothing", func() (Money, error) { return Sum() }, Money{}, false},
+		{"add mixed currencies", func() (Money, error) { return Money{100, "USD"}.Add(Money{100, "EUR"}) }, Money{}, true},
+		{"sub mixed currencies", func() (Money, error) { return Money{100, "USD"}.Sub(Money{100, "EUR"}) }, Money{}, true},
+		{"sum mixed currencies", func() (Money, error) { return Sum(Money{100, "USD"}, Money{}, Money{100, "EUR"}) }, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{1299, "USD"}, "12.99 USD"},
+		{Money{-5, "EUR"}, "-0.05 EUR"},
+		{Money{1500, "JPY"}, "1500 JPY"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		data string
+		want Money
+	}{
+		{"object", "USD", `{"amount": 1299, "currency": "usd"}`, Money{1299, "USD"}},
+		{"bare number", "USD", `12.99`, Money{1299, ""}},
+		{"bare number rounds to the minor unit", "USD", `0.125`, Money{13, ""}},
+		{"bare number in a base currency without minor units", "JPY", `1500`, Money{1500, ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer SetBaseCurrency(baseCurrency)
+			SetBaseCurrency(tt.base)
+
+			var got Money
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSONRejectsInvalid(t *testing.T) {
+	var got Money
+	if err := json.Unmarshal([]byte(`"12.99"`), &got); err == nil {
+		t.Errorf("expected an error, got %+v", got)
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	NotificationBackorderAllocated = "backorder_allocated"
+	NotificationBackInStock        = "back_in_stock"
+	NotificationPriceDrop          = "price_drop"
+)
+
+// Notification is a message sent to a customer about one of their orders or
+// the books on their wishlist, kept in the customer's inbox.
+type Notification struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	OrderID    int       `json:"order_id,omitempty"`
+	BookID     int       `json:"book_id,omitempty"`
+	Type       string    `json:"type"`
+	Subject    string    `json:"subject"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
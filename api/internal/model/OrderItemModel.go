@@ -1,6 +1,20 @@
 package model
 
 type OrderItem struct {
-	BookID   int `json:"book_id"`
-	Quantity int `json:"quantity"`
+	BookID      int          `json:"book_id"`
+	Quantity    int          `json:"quantity"`
+	UnitPrice   Money        `json:"unit_price"`
+	Discounts   []Discount   `json:"discounts,omitempty"`
+	LineTotal   Money        `json:"line_total"`
+	TaxRate     float64      `json:"tax_rate,omitempty"`
+	Tax         Money        `json:"tax"`
+	Allocations []Allocation `json:"allocations,omitempty"`
+	Backordered int          `json:"backordered,omitempty"`
+}
+
+// Allocation is the part of an order item shipped from one warehouse. The
+// copies of an item that are not allocated yet are back-ordered.
+type Allocation struct {
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`
 }
@@ -2,16 +2,55 @@ package model
 
 import "time"
 
+const (
+	OrderPending = "Pending"
+	OrderPaid    = "Paid"
+	OrderExpired = "Expired"
+
+	OrderPartiallyShipped = "Partially Shipped"
+	OrderShipped          = "Shipped"
+	OrderDelivered        = "Delivered"
+)
+
 type Order struct {
-	ID         int         `json:"id"`
-	CustomerId int         `json:"customer"`
-	Items      []OrderItem `json:"items"`
-	TotalPrice float64     `json:"total_price"`
-	CreatedAt  time.Time   `json:"created_at"`
-	Status     string      `json:"status"`
+	ID               int             `json:"id"`
+	CustomerId       int             `json:"customer"`
+	Items            []OrderItem     `json:"items"`
+	Currency         string          `json:"currency,omitempty"`
+	CouponCode       string          `json:"coupon_code,omitempty"`
+	Subtotal         Money           `json:"subtotal"`
+	DiscountTotal    Money           `json:"discount_total"`
+	TotalPrice       Money           `json:"total_price"`
+	Tax              Money           `json:"tax"`
+	ShippingMethodID int             `json:"shipping_method_id,omitempty"`
+	ShippingCost     Money           `json:"shipping_cost"`
+	GrandTotal       Money           `json:"grand_total"`
+	Credits          []AppliedCredit `json:"credits,omitempty"`
+	CreditApplied    Money           `json:"credit_applied"`
+	PointsRedeemed   int             `json:"points_redeemed,omitempty"`
+	PointsEarned     int             `json:"points_earned,omitempty"`
+	Refunded         Money           `json:"refunded"`
+	CreatedAt        time.Time       `json:"created_at"`
+	PaidAt           *time.Time      `json:"paid_at,omitempty"`
+	Status           string          `json:"status"`
+}
+
+// AppliedCredit is the part of an order paid with a gift card or store credit.
+// Amount is in the currency of the order, Charged in the currency of the
+// balance it was taken from.
+type AppliedCredit struct {
+	Account    string `json:"account"`
+	GiftCardID int    `json:"gift_card_id,omitempty"`
+	Amount     Money  `json:"amount"`
+	Charged    Money  `json:"charged"`
 }
 
 type OrderInput struct {
-	CustomerId int         `json:"customer"`
-	Items      []OrderItem `json:"items"`
+	CustomerId       int         `json:"customer"`
+	Items            []OrderItem `json:"items"`
+	CouponCode       string      `json:"coupon_code,omitempty"`
+	ShippingMethodID int         `json:"shipping_method_id,omitempty"`
+	Currency         string      `json:"currency,omitempty"`
+	RedeemPoints     int         `json:"redeem_points,omitempty"`
+	AllowBackorder   bool        `json:"allow_backorder,omitempty"`
 }
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	PaymentPending    = "Pending"
+	PaymentAuthorized = "Authorized"
+	PaymentCaptured   = "Captured"
+	PaymentDeclined   = "Declined"
+	PaymentFailed     = "Failed"
+)
+
+// Payment records one attempt at paying an order through the payment gateway;
+// Reference is the identifier the gateway gave the payment.
+type Payment struct {
+	ID            int       `json:"id"`
+	OrderID       int       `json:"order_id"`
+	Amount        Money     `json:"amount"`
+	Method        string    `json:"method"`
+	Gateway       string    `json:"gateway"`
+	Reference     string    `json:"reference,omitempty"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PaymentInput pays an order through the gateway, after taking what it can
+// from the gift card and the store credit of the customer when asked to.
+type PaymentInput struct {
+	Method         string `json:"method"`
+	Token          string `json:"token"`
+	GiftCardCode   string `json:"gift_card_code,omitempty"`
+	UseStoreCredit bool   `json:"use_store_credit,omitempty"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+	DiscountBuyXGetY   = "buy_x_get_y"
+)
+
+const (
+	DiscountSourceSale    = "sale"
+	DiscountSourceRule    = "promotion"
+	DiscountSourceCoupon  = "coupon"
+	DiscountSourceLoyalty = "loyalty"
+)
+
+// Coupon is a discount code entered by the customer. Percentage coupons take a
+// share of every line, fixed coupons an amount of the base currency off the
+// order spread over its lines. UsageLimit 0 means unlimited.
+type Coupon struct {
+	ID         int        `json:"id"`
+	Code       string     `json:"code"`
+	Type       string     `json:"type"`
+	Value      float64    `json:"value"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	UsageLimit int        `json:"usage_limit"`
+	UsageCount int        `json:"usage_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CouponInput struct {
+	Code       string     `json:"code"`
+	Type       string     `json:"type"`
+	Value      float64    `json:"value"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	UsageLimit int        `json:"usage_limit"`
+}
+
+// PromotionRule is a discount applied automatically to the books it targets,
+// e.g. 20% off the Fantasy genre or buy 3 get 1 free. A rule without a genre,
+// book or author targets every book.
+type PromotionRule struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Percentage   float64    `json:"percentage,omitempty"`
+	BuyQuantity  int        `json:"buy_quantity,omitempty"`
+	FreeQuantity int        `json:"free_quantity,omitempty"`
+	Genre        string     `json:"genre,omitempty"`
+	BookID       int        `json:"book_id,omitempty"`
+	AuthorID     int        `json:"author_id,omitempty"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type PromotionRuleInput struct {
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Percentage   float64    `json:"percentage,omitempty"`
+	BuyQuantity  int        `json:"buy_quantity,omitempty"`
+	FreeQuantity int        `json:"free_quantity,omitempty"`
+	Genre        string     `json:"genre,omitempty"`
+	BookID       int        `json:"book_id,omitempty"`
+	AuthorID     int        `json:"author_id,omitempty"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+}
+
+// Discount is one reduction applied to an order line: a sale price, a promotion
+// rule, a coupon or redeemed loyalty points.
+type Discount struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+}
@@ -0,0 +1,14 @@
+package model
+
+type Publisher struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+	Website string `json:"website,omitempty"`
+}
+
+type PublisherInput struct {
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+	Website string `json:"website,omitempty"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	PurchaseOrderPending           = "Pending"
+	PurchaseOrderPartiallyReceived = "Partially Received"
+	PurchaseOrderReceived          = "Received"
+)
+
+type PurchaseOrder struct {
+	ID          int                 `json:"id"`
+	SupplierID  int                 `json:"supplier_id"`
+	WarehouseID int                 `json:"warehouse_id"`
+	Items       []PurchaseOrderItem `json:"items"`
+	TotalCost   Money               `json:"total_cost"`
+	Status      string              `json:"status"`
+	CreatedAt   time.Time           `json:"created_at"`
+	ReceivedAt  *time.Time          `json:"received_at,omitempty"`
+}
+
+type PurchaseOrderItem struct {
+	BookID           int   `json:"book_id"`
+	Quantity         int   `json:"quantity"`
+	ReceivedQuantity int   `json:"received_quantity"`
+	CostPrice        Money `json:"cost_price"`
+}
+
+type PurchaseOrderItemInput struct {
+	BookID    int   `json:"book_id"`
+	Quantity  int   `json:"quantity"`
+	CostPrice Money `json:"cost_price"`
+}
+
+type PurchaseOrderInput struct {
+	SupplierID  int                      `json:"supplier_id"`
+	WarehouseID int                      `json:"warehouse_id"`
+	Items       []PurchaseOrderItemInput `json:"items"`
+}
+
+type PurchaseOrderReceiptItem struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+type PurchaseOrderReceipt struct {
+	Items []PurchaseOrderReceiptItem `json:"items"`
+}
@@ -0,0 +1,16 @@
+package model
+
+const (
+	RecommendationBoughtTogether = "bought_together"
+	RecommendationGenre          = "genre"
+	RecommendationPopular        = "popular"
+)
+
+// Recommendation is a book suggested to a reader, best first by Score. Reason
+// tells whether it is often bought with the books at hand, shares their genres
+// or is simply popular.
+type Recommendation struct {
+	Book   Book    `json:"book"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Refund is money paid back to the customer on a captured payment, either for a
+// return or as a goodwill gesture.
+type Refund struct {
+	ID          int       `json:"id"`
+	OrderID     int       `json:"order_id"`
+	PaymentID   int       `json:"payment_id"`
+	ReturnID    int       `json:"return_id,omitempty"`
+	Amount      Money     `json:"amount"`
+	Tax         Money     `json:"tax"`
+	Reason      string    `json:"reason"`
+	Reference   string    `json:"reference"`
+	StoreCredit bool      `json:"store_credit,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RefundInput gives the amount to refund in major units of the order currency,
+// paid back to the customer's store credit rather than through the gateway
+// when ToStoreCredit is set.
+type RefundInput struct {
+	Amount        float64 `json:"amount"`
+	Reason        string  `json:"reason"`
+	ToStoreCredit bool    `json:"to_store_credit,omitempty"`
+}
@@ -2,10 +2,36 @@ package model
 
 import "time"
 
+// ReportModel amounts are in the base currency, orders in other currencies
+// being converted at the current exchange rates.
 type ReportModel struct {
-	TotalRevenue    float64   `json:"total_revenue"`
-	TotalOrders     int       `json:"total_orders"`
-	TotalBooksSold  int       `json:"total_books_sold"`
-	TopSellingBooks []Book    `json:"top_selling_books"`
-	GeneratedAt     time.Time `json:"generated_at"`
+	TotalRevenue    Money           `json:"total_revenue"`
+	NetRevenue      Money           `json:"net_revenue"`
+	TaxCollected    Money           `json:"tax_collected"`
+	TotalRefunds    Money           `json:"total_refunds"`
+	TotalDiscounts  Money           `json:"total_discounts"`
+	TotalOrders     int             `json:"total_orders"`
+	TotalBooksSold  int             `json:"total_books_sold"`
+	TopSellingBooks []Book          `json:"top_selling_books"`
+	PublisherSales  []PublisherSale `json:"publisher_sales"`
+	SeriesSales     []SeriesSale    `json:"series_sales"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+// PublisherSale is what the books of a publisher sold over the period, before
+// tax. Books not linked to a publisher are grouped by their publisher name,
+// with no PublisherID.
+type PublisherSale struct {
+	PublisherID int    `json:"publisher_id,omitempty"`
+	Publisher   string `json:"publisher"`
+	BooksSold   int    `json:"books_sold"`
+	Revenue     Money  `json:"revenue"`
+}
+
+// SeriesSale is what the books of a series sold over the period, before tax.
+type SeriesSale struct {
+	SeriesID  int    `json:"series_id"`
+	Series    string `json:"series"`
+	BooksSold int    `json:"books_sold"`
+	Revenue   Money  `json:"revenue"`
 }
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const (
+	ReservationActive    = "Active"
+	ReservationReleased  = "Released"
+	ReservationCommitted = "Committed"
+
+	ReservationBackordered = "Backordered"
+	ReservationAllocated   = "Allocated"
+)
+
+// Reservation holds copies of a book at a warehouse for a pending order until
+// it is paid (committed) or runs out of time (released).
+//
+// A back-order is a reservation of copies that are out of stock or not released
+// yet, with no warehouse. It expires with the other reservations of a pending
+// order, and waits for stock once the order is paid; it is allocated when the
+// copies come in.
+type Reservation struct {
+	ID          int        `json:"id"`
+	OrderID     int        `json:"order_id"`
+	BookID      int        `json:"book_id"`
+	WarehouseID int        `json:"warehouse_id"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+}
+
+type StockAvailability struct {
+	BookID      int                    `json:"book_id"`
+	Title       string                 `json:"title"`
+	OnHand      int                    `json:"on_hand"`
+	Reserved    int                    `json:"reserved"`
+	Available   int                    `json:"available"`
+	InTransit   int                    `json:"in_transit"`
+	Backordered int                    `json:"backordered"`
+	Wishlisted  int                    `json:"wishlisted"`
+	Locations   []LocationAvailability `json:"locations"`
+}
+
+type LocationAvailability struct {
+	WarehouseID int `json:"warehouse_id"`
+	OnHand      int `json:"on_hand"`
+	Reserved    int `json:"reserved"`
+	Available   int `json:"available"`
+	InTransit   int `json:"in_transit"`
+}
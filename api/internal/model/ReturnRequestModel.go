@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+const (
+	ReturnRequested = "Requested"
+	ReturnApproved  = "Approved"
+	ReturnRejected  = "Rejected"
+	ReturnReceived  = "Received"
+	ReturnRefunded  = "Refunded"
+)
+
+// ReturnRequest follows the return of order items by a customer (RMA): it is
+// requested, approved or rejected by staff, received back and finally refunded.
+type ReturnRequest struct {
+	ID              int          `json:"id"`
+	OrderID         int          `json:"order_id"`
+	CustomerID      int          `json:"customer_id"`
+	Items           []ReturnItem `json:"items"`
+	Reason          string       `json:"reason"`
+	Status          string       `json:"status"`
+	RejectionReason string       `json:"rejection_reason,omitempty"`
+	WarehouseID     int          `json:"warehouse_id,omitempty"`
+	RefundID        int          `json:"refund_id,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// ReturnItem is a returned order item. Of the received copies, only the
+// resellable ones are put back in stock.
+type ReturnItem struct {
+	BookID             int `json:"book_id"`
+	Quantity           int `json:"quantity"`
+	ReceivedQuantity   int `json:"received_quantity"`
+	ResellableQuantity int `json:"resellable_quantity"`
+}
+
+type ReturnItemInput struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+type ReturnRequestInput struct {
+	OrderID int               `json:"order_id"`
+	Items   []ReturnItemInput `json:"items"`
+	Reason  string            `json:"reason"`
+}
+
+type ReturnDecision struct {
+	Reason string `json:"reason"`
+}
+
+type ReturnReceiptItem struct {
+	BookID     int `json:"book_id"`
+	Quantity   int `json:"quantity"`
+	Resellable int `json:"resellable"`
+}
+
+type ReturnReceipt struct {
+	WarehouseID int                 `json:"warehouse_id"`
+	Items       []ReturnReceiptItem `json:"items"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Review is the rating of a book by a customer. Reviews wait in the pending
+// status until a moderator approves or rejects them; only approved reviews
+// count in the rating of the book. VerifiedPurchase marks customers who
+// received the book in a delivered order.
+type Review struct {
+	ID               int        `json:"id"`
+	CustomerID       int        `json:"customer_id"`
+	BookID           int        `json:"book_id"`
+	Rating           int        `json:"rating"`
+	Text             string     `json:"text,omitempty"`
+	Status           string     `json:"status"`
+	VerifiedPurchase bool       `json:"verified_purchase"`
+	ModerationNote   string     `json:"moderation_note,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+type ReviewInput struct {
+	CustomerID int    `json:"customer_id"`
+	Rating     int    `json:"rating"`
+	Text       string `json:"text,omitempty"`
+}
+
+type ReviewModerationInput struct {
+	Status string `json:"status"`
+	Note   string `json:"note,omitempty"`
+}
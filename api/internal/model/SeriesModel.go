@@ -0,0 +1,25 @@
+package model
+
+type Series struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type SeriesInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// SeriesDetail lists the books of a series in reading order.
+type SeriesDetail struct {
+	Series
+	Books []SeriesBook `json:"books"`
+}
+
+// SeriesBook is a book of a series with the copies available to order.
+type SeriesBook struct {
+	Position  int  `json:"position"`
+	Book      Book `json:"book"`
+	Available int  `json:"available"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	ShipmentShipped   = "Shipped"
+	ShipmentDelivered = "Delivered"
+)
+
+// Shipment is a parcel sent for an order. An order can be shipped in several
+// shipments, each holding some of its items.
+type Shipment struct {
+	ID             int            `json:"id"`
+	OrderID        int            `json:"order_id"`
+	Items          []ShipmentItem `json:"items"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	Status         string         `json:"status"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+}
+
+type ShipmentItem struct {
+	BookID   int `json:"book_id"`
+	Quantity int `json:"quantity"`
+}
+
+// ShipmentInput ships the listed items, or everything still to ship when no
+// item is listed. A tracking number is generated when none is given.
+type ShipmentInput struct {
+	Items          []ShipmentItem `json:"items"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ShippingMethod is a way of shipping orders, priced by the first of its rates
+// that matches the destination and the parcel.
+type ShippingMethod struct {
+	ID        int            `json:"id"`
+	Name      string         `json:"name"`
+	Carrier   string         `json:"carrier"`
+	Rates     []ShippingRate `json:"rates"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ShippingRate applies to the listed countries, or to every country when none
+// is listed, up to a total weight in grams and a number of items; a limit of 0
+// means no limit.
+type ShippingRate struct {
+	Countries   []string `json:"countries,omitempty"`
+	MaxWeight   int      `json:"max_weight,omitempty"`
+	MaxItems    int      `json:"max_items,omitempty"`
+	Cost        float64  `json:"cost"`
+	CostPerItem float64  `json:"cost_per_item,omitempty"`
+}
+
+type ShippingMethodInput struct {
+	Name    string         `json:"name"`
+	Carrier string         `json:"carrier"`
+	Rates   []ShippingRate `json:"rates"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	StockMovementSale        = "sale"
+	StockMovementReturn      = "return"
+	StockMovementReceipt     = "receipt"
+	StockMovementAdjustment  = "adjustment"
+	StockMovementDamage      = "damage"
+	StockMovementTransferOut = "transfer_out"
+	StockMovementTransferIn  = "transfer_in"
+)
+
+// StockMovement is one entry of the append-only stock ledger. Quantity is
+// signed: positive movements add stock, negative ones remove it.
+type StockMovement struct {
+	ID          int       `json:"id"`
+	BookID      int       `json:"book_id"`
+	WarehouseID int       `json:"warehouse_id"`
+	Type        string    `json:"type"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	Reference   string    `json:"reference,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type StockAdjustmentInput struct {
+	WarehouseID int    `json:"warehouse_id"`
+	Type        string `json:"type"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+	Actor       string `json:"actor"`
+}
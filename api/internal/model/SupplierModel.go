@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type Supplier struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	Phone     string          `json:"phone"`
+	Address   Address         `json:"address"`
+	Catalog   []SupplierPrice `json:"catalog"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type SupplierPrice struct {
+	BookID    int   `json:"book_id"`
+	CostPrice Money `json:"cost_price"`
+}
+
+type SupplierInput struct {
+	Name    string          `json:"name"`
+	Email   string          `json:"email"`
+	Phone   string          `json:"phone"`
+	Address Address         `json:"address"`
+	Catalog []SupplierPrice `json:"catalog"`
+}
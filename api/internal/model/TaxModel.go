@@ -0,0 +1,18 @@
+package model
+
+// TaxCategoryBooks is the tax category of books that do not set one.
+const TaxCategoryBooks = "books"
+
+// TaxRate is the rate charged on sales to a country, or to one state of it when
+// State is set. CategoryRates overrides the rate for some tax categories, a rate
+// of 0 making the category exempt.
+type TaxRate struct {
+	Country       string             `json:"country"`
+	State         string             `json:"state,omitempty"`
+	Rate          float64            `json:"rate"`
+	CategoryRates map[string]float64 `json:"category_rates,omitempty"`
+}
+
+type TaxTable struct {
+	TaxRates []TaxRate `json:"tax_rates"`
+}
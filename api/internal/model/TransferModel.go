@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+const (
+	TransferInTransit = "In Transit"
+	TransferReceived  = "Received"
+	TransferCancelled = "Cancelled"
+)
+
+type Transfer struct {
+	ID              int        `json:"id"`
+	BookID          int        `json:"book_id"`
+	FromWarehouseID int        `json:"from_warehouse_id"`
+	ToWarehouseID   int        `json:"to_warehouse_id"`
+	Quantity        int        `json:"quantity"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+type TransferInput struct {
+	BookID          int `json:"book_id"`
+	FromWarehouseID int `json:"from_warehouse_id"`
+	ToWarehouseID   int `json:"to_warehouse_id"`
+	Quantity        int `json:"quantity"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	WarehouseTypeWarehouse = "warehouse"
+	WarehouseTypeRetail    = "retail"
+)
+
+type Warehouse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Address   Address   `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WarehouseInput struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Address Address `json:"address"`
+}
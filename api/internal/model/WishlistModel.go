@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// WishlistItem is a book a customer wishes for. Price is the price of the book
+// when it was added, or when the customer was last told it dropped; a lower
+// price than that is a price drop. Book is only filled in when the wishlist is
+// read.
+type WishlistItem struct {
+	ID         int       `json:"id"`
+	CustomerID int       `json:"customer_id"`
+	BookID     int       `json:"book_id"`
+	Price      Money     `json:"price"`
+	AddedAt    time.Time `json:"added_at"`
+	Book       *Book     `json:"book,omitempty"`
+}
+
+type WishlistItemInput struct {
+	BookID int `json:"book_id"`
+}
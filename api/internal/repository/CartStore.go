@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type CartStore interface {
+	CreateCart(ctx context.Context, cart model.Cart) (model.Cart, error)
+	GetCart(ctx context.Context, id int) (model.Cart, error)
+	UpdateCart(ctx context.Context, id int, cart model.Cart) (model.Cart, error)
+	DeleteCart(ctx context.Context, id int) error
+	SearchCarts(ctx context.Context, params map[string]string) ([]model.Cart, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type CouponStore interface {
+	CreateCoupon(ctx context.Context, coupon model.Coupon) (model.Coupon, error)
+	GetCoupon(ctx context.Context, id int) (model.Coupon, error)
+	UpdateCoupon(ctx context.Context, id int, coupon model.Coupon) (model.Coupon, error)
+	DeleteCoupon(ctx context.Context, id int) error
+	SearchCoupons(ctx context.Context, params map[string]string) ([]model.Coupon, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// CreditTransactionStore has no update or delete: the ledger is append only.
+type CreditTransactionStore interface {
+	CreateCreditTransaction(ctx context.Context, transaction model.CreditTransaction) (model.CreditTransaction, error)
+	GetCreditTransaction(ctx context.Context, id int) (model.CreditTransaction, error)
+	SearchCreditTransactions(ctx context.Context, params map[string]string) ([]model.CreditTransaction, error)
+}
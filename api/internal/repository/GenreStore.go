@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type GenreStore interface {
+	CreateGenre(ctx context.Context, genre model.Genre) (model.Genre, error)
+	GetGenre(ctx context.Context, id int) (model.Genre, error)
+	UpdateGenre(ctx context.Context, id int, genre model.Genre) (model.Genre, error)
+	DeleteGenre(ctx context.Context, id int) error
+	SearchGenres(ctx context.Context, params map[string]string) ([]model.Genre, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// GiftCardStore has no delete, so that the credit ledger always refers to
+// existing gift cards.
+type GiftCardStore interface {
+	CreateGiftCard(ctx context.Context, giftCard model.GiftCard) (model.GiftCard, error)
+	GetGiftCard(ctx context.Context, id int) (model.GiftCard, error)
+	UpdateGiftCard(ctx context.Context, id int, giftCard model.GiftCard) (model.GiftCard, error)
+	SearchGiftCards(ctx context.Context, params map[string]string) ([]model.GiftCard, error)
+}
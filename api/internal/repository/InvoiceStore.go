@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// InvoiceStore has no update or delete: issued invoices are immutable.
+type InvoiceStore interface {
+	CreateInvoice(ctx context.Context, invoice model.Invoice) (model.Invoice, error)
+	GetInvoice(ctx context.Context, id int) (model.Invoice, error)
+	SearchInvoices(ctx context.Context, params map[string]string) ([]model.Invoice, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// LoyaltyTransactionStore has no update or delete: the ledger is append only.
+type LoyaltyTransactionStore interface {
+	CreateLoyaltyTransaction(ctx context.Context, transaction model.LoyaltyTransaction) (model.LoyaltyTransaction, error)
+	GetLoyaltyTransaction(ctx context.Context, id int) (model.LoyaltyTransaction, error)
+	SearchLoyaltyTransactions(ctx context.Context, params map[string]string) ([]model.LoyaltyTransaction, error)
+}
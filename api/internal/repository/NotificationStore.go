@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// NotificationStore has no update or delete: notifications are never changed
+// once sent.
+type NotificationStore interface {
+	CreateNotification(ctx context.Context, notification model.Notification) (model.Notification, error)
+	GetNotification(ctx context.Context, id int) (model.Notification, error)
+	SearchNotifications(ctx context.Context, params map[string]string) ([]model.Notification, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type PaymentStore interface {
+	CreatePayment(ctx context.Context, payment model.Payment) (model.Payment, error)
+	GetPayment(ctx context.Context, id int) (model.Payment, error)
+	UpdatePayment(ctx context.Context, id int, payment model.Payment) (model.Payment, error)
+	DeletePayment(ctx context.Context, id int) error
+	SearchPayments(ctx context.Context, params map[string]string) ([]model.Payment, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type PromotionRuleStore interface {
+	CreatePromotionRule(ctx context.Context, promotionRule model.PromotionRule) (model.PromotionRule, error)
+	GetPromotionRule(ctx context.Context, id int) (model.PromotionRule, error)
+	UpdatePromotionRule(ctx context.Context, id int, promotionRule model.PromotionRule) (model.PromotionRule, error)
+	DeletePromotionRule(ctx context.Context, id int) error
+	SearchPromotionRules(ctx context.Context, params map[string]string) ([]model.PromotionRule, error)
+}
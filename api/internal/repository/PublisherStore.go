@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type PublisherStore interface {
+	CreatePublisher(ctx context.Context, publisher model.Publisher) (model.Publisher, error)
+	GetPublisher(ctx context.Context, id int) (model.Publisher, error)
+	UpdatePublisher(ctx context.Context, id int, publisher model.Publisher) (model.Publisher, error)
+	DeletePublisher(ctx context.Context, id int) error
+	SearchPublishers(ctx context.Context, params map[string]string) ([]model.Publisher, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type PurchaseOrderStore interface {
+	CreatePurchaseOrder(ctx context.Context, purchaseOrder model.PurchaseOrder) (model.PurchaseOrder, error)
+	GetPurchaseOrder(ctx context.Context, id int) (model.PurchaseOrder, error)
+	UpdatePurchaseOrder(ctx context.Context, id int, purchaseOrder model.PurchaseOrder) (model.PurchaseOrder, error)
+	DeletePurchaseOrder(ctx context.Context, id int) error
+	SearchPurchaseOrders(ctx context.Context, params map[string]string) ([]model.PurchaseOrder, error)
+}
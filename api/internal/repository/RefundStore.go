@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type RefundStore interface {
+	CreateRefund(ctx context.Context, refund model.Refund) (model.Refund, error)
+	GetRefund(ctx context.Context, id int) (model.Refund, error)
+	UpdateRefund(ctx context.Context, id int, refund model.Refund) (model.Refund, error)
+	DeleteRefund(ctx context.Context, id int) error
+	SearchRefunds(ctx context.Context, params map[string]string) ([]model.Refund, error)
+}
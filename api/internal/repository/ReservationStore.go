@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type ReservationStore interface {
+	CreateReservation(ctx context.Context, reservation model.Reservation) (model.Reservation, error)
+	GetReservation(ctx context.Context, id int) (model.Reservation, error)
+	UpdateReservation(ctx context.Context, id int, reservation model.Reservation) (model.Reservation, error)
+	DeleteReservation(ctx context.Context, id int) error
+	SearchReservations(ctx context.Context, params map[string]string) ([]model.Reservation, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type ReturnRequestStore interface {
+	CreateReturnRequest(ctx context.Context, returnRequest model.ReturnRequest) (model.ReturnRequest, error)
+	GetReturnRequest(ctx context.Context, id int) (model.ReturnRequest, error)
+	UpdateReturnRequest(ctx context.Context, id int, returnRequest model.ReturnRequest) (model.ReturnRequest, error)
+	DeleteReturnRequest(ctx context.Context, id int) error
+	SearchReturnRequests(ctx context.Context, params map[string]string) ([]model.ReturnRequest, error)
+}
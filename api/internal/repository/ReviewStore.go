@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// ReviewStore has no delete: rejected reviews are kept for the moderation
+// history.
+type ReviewStore interface {
+	CreateReview(ctx context.Context, review model.Review) (model.Review, error)
+	GetReview(ctx context.Context, id int) (model.Review, error)
+	UpdateReview(ctx context.Context, id int, review model.Review) (model.Review, error)
+	SearchReviews(ctx context.Context, params map[string]string) ([]model.Review, error)
+}
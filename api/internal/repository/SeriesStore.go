@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type SeriesStore interface {
+	CreateSeries(ctx context.Context, series model.Series) (model.Series, error)
+	GetSeries(ctx context.Context, id int) (model.Series, error)
+	UpdateSeries(ctx context.Context, id int, series model.Series) (model.Series, error)
+	DeleteSeries(ctx context.Context, id int) error
+	SearchSeries(ctx context.Context, params map[string]string) ([]model.Series, error)
+}
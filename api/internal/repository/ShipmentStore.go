@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type ShipmentStore interface {
+	CreateShipment(ctx context.Context, shipment model.Shipment) (model.Shipment, error)
+	GetShipment(ctx context.Context, id int) (model.Shipment, error)
+	UpdateShipment(ctx context.Context, id int, shipment model.Shipment) (model.Shipment, error)
+	DeleteShipment(ctx context.Context, id int) error
+	SearchShipments(ctx context.Context, params map[string]string) ([]model.Shipment, error)
+}
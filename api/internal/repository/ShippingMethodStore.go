@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type ShippingMethodStore interface {
+	CreateShippingMethod(ctx context.Context, shippingMethod model.ShippingMethod) (model.ShippingMethod, error)
+	GetShippingMethod(ctx context.Context, id int) (model.ShippingMethod, error)
+	UpdateShippingMethod(ctx context.Context, id int, shippingMethod model.ShippingMethod) (model.ShippingMethod, error)
+	DeleteShippingMethod(ctx context.Context, id int) error
+	SearchShippingMethods(ctx context.Context, params map[string]string) ([]model.ShippingMethod, error)
+}
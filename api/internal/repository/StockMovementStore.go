@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+// StockMovementStore is append-only: movements are never updated or deleted.
+type StockMovementStore interface {
+	CreateStockMovement(ctx context.Context, movement model.StockMovement) (model.StockMovement, error)
+	GetStockMovement(ctx context.Context, id int) (model.StockMovement, error)
+	SearchStockMovements(ctx context.Context, params map[string]string) ([]model.StockMovement, error)
+}
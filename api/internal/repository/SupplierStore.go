@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type SupplierStore interface {
+	CreateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error)
+	GetSupplier(ctx context.Context, id int) (model.Supplier, error)
+	UpdateSupplier(ctx context.Context, id int, supplier model.Supplier) (model.Supplier, error)
+	DeleteSupplier(ctx context.Context, id int) error
+	SearchSuppliers(ctx context.Context, params map[string]string) ([]model.Supplier, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type TransferStore interface {
+	CreateTransfer(ctx context.Context, transfer model.Transfer) (model.Transfer, error)
+	GetTransfer(ctx context.Context, id int) (model.Transfer, error)
+	UpdateTransfer(ctx context.Context, id int, transfer model.Transfer) (model.Transfer, error)
+	DeleteTransfer(ctx context.Context, id int) error
+	SearchTransfers(ctx context.Context, params map[string]string) ([]model.Transfer, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type WarehouseStore interface {
+	CreateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	GetWarehouse(ctx context.Context, id int) (model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id int, warehouse model.Warehouse) (model.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, id int) error
+	SearchWarehouses(ctx context.Context, params map[string]string) ([]model.Warehouse, error)
+}
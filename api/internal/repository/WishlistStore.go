@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+)
+
+type WishlistStore interface {
+	CreateWishlistItem(ctx context.Context, item model.WishlistItem) (model.WishlistItem, error)
+	GetWishlistItem(ctx context.Context, id int) (model.WishlistItem, error)
+	UpdateWishlistItem(ctx context.Context, id int, item model.WishlistItem) (model.WishlistItem, error)
+	DeleteWishlistItem(ctx context.Context, id int) error
+	SearchWishlistItems(ctx context.Context, params map[string]string) ([]model.WishlistItem, error)
+}
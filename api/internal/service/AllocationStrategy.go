@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AllocationStrategy decides from which locations an order item is picked. Rank
+// returns the warehouses in order of preference; the order item is then filled
+// from the first warehouse with stock, spilling over to the next ones.
+type AllocationStrategy interface {
+	Rank(warehouses []model.Warehouse, available map[int]int, customer model.Customer) []model.Warehouse
+}
+
+func NewAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch name {
+	case "", "nearest":
+		return NearestAllocationStrategy{}, nil
+	case "most_stock":
+		return MostStockAllocationStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q", name)
+	}
+}
+
+// NearestAllocationStrategy prefers the warehouses closest to the customer's
+// address, matching on postal code, then city, state and country.
+type NearestAllocationStrategy struct{}
+
+func (NearestAllocationStrategy) Rank(warehouses []model.Warehouse, available map[int]int, customer model.Customer) []model.Warehouse {
+	ranked := append([]model.Warehouse{}, warehouses...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		di := addressDistance(ranked[i].Address, customer.Address)
+		dj := addressDistance(ranked[j].Address, customer.Address)
+		if di != dj {
+			return di < dj
+		}
+		return available[ranked[i].ID] > available[ranked[j].ID]
+	})
+	return ranked
+}
+
+// MostStockAllocationStrategy prefers the warehouses holding the most copies, so
+// that an order is split over as few locations as possible.
+type MostStockAllocationStrategy struct{}
+
+func (MostStockAllocationStrategy) Rank(warehouses []model.Warehouse, available map[int]int, customer model.Customer) []model.Warehouse {
+	ranked := append([]model.Warehouse{}, warehouses...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return available[ranked[i].ID] > available[ranked[j].ID]
+	})
+	return ranked
+}
+
+// addressDistance is a coarse distance between two addresses: 0 for the same
+// postal code up to 4 for a different country.
+func addressDistance(a model.Address, b model.Address) int {
+	sameCountry := strings.EqualFold(a.Country, b.Country)
+	switch {
+	case sameCountry && a.PostalCode != "" && strings.EqualFold(a.PostalCode, b.PostalCode):
+		return 0
+	case sameCountry && a.City != "" && strings.EqualFold(a.City, b.City):
+		return 1
+	case sameCountry && a.State != "" && strings.EqualFold(a.State, b.State):
+		return 2
+	case sameCountry && a.Country != "":
+		return 3
+	default:
+		return 4
+	}
+}
+
+func allocate(strategy AllocationStrategy, warehouses []model.Warehouse, available map[int]int, customer model.Customer, quantity int) ([]model.Allocation, error) {
+	allocations := []model.Allocation{}
+	remaining := quantity
+	for _, warehouse := range strategy.Rank(warehouses, available, customer) {
+		if remaining == 0 {
+			break
+		}
+		picked := min(available[warehouse.ID], remaining)
+		if picked <= 0 {
+			continue
+		}
+		allocations = append(allocations, model.Allocation{WarehouseID: warehouse.ID, Quantity: picked})
+		remaining -= picked
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("insufficient stock, %d copies missing", remaining)
+	}
+	return allocations, nil
+}
@@ -5,20 +5,37 @@ import (
 	"bookstore/api/api/internal/repository"
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type BookService struct {
-	repo       repository.BookStore
-	repoAuthor repository.AuthorStore
-	currentID  int
+	repo          repository.BookStore
+	repoAuthor    repository.AuthorStore
+	repoPublisher repository.PublisherStore
+	repoSeries    repository.SeriesStore
+	genres        *GenreService
+	stock         *StockService
+	wishlists     *WishlistService
+	currencies    *CurrencyService
+	currentID     int
 }
 
-func NewBookService(repo repository.BookStore, repoAuthor repository.AuthorStore) *BookService {
+func NewBookService(repo repository.BookStore, repoAuthor repository.AuthorStore, repoPublisher repository.PublisherStore, repoSeries repository.SeriesStore, genres *GenreService, stock *StockService, wishlists *WishlistService, currencies *CurrencyService) *BookService {
 	return &BookService{
-		repo:       repo,
-		repoAuthor: repoAuthor,
-		currentID:  1,
+		repo:          repo,
+		repoAuthor:    repoAuthor,
+		repoPublisher: repoPublisher,
+		repoSeries:    repoSeries,
+		genres:        genres,
+		stock:         stock,
+		wishlists:     wishlists,
+		currencies:    currencies,
+		currentID:     1,
 	}
 }
 
@@ -28,44 +45,113 @@ func (s *BookService) CreateBook(ctx context.Context, bookInput model.BookInput)
 		return model.Book{}, err
 	}
 
-	book := model.Book{
-		ID:          s.currentID,
-		Title:       bookInput.Title,
-		AuthorID:    bookInput.AuthorID,
-		Genres:      bookInput.Genres,
-		PublishedAt: time.Now(),
-		Price:       bookInput.Price,
-		Stock:       bookInput.Stock,
+	book, err := s.buildBook(ctx, 0, bookInput, nil)
+	if err != nil {
+		return model.Book{}, err
 	}
+	book.ID = s.currentID
 	s.currentID++
+	if bookInput.PublishedAt == nil {
+		book.PublishedAt = time.Now()
+	}
 
-	if book.Stock < 0 || book.Price < 0 {
-		return model.Book{}, errors.New("book details are invalid")
+	// the initial stock goes through the ledger like any other stock change
+	book.Stock = 0
+	created, err := s.repo.CreateBook(ctx, book)
+	if err != nil {
+		return model.Book{}, err
 	}
-	if book.Title == "" {
-		return model.Book{}, errors.New("book title is mandatory")
+	if bookInput.Stock == 0 {
+		return created, nil
 	}
 
-	_, err := s.repoAuthor.GetAuthor(ctx, bookInput.AuthorID)
+	_, err = s.stock.RecordMovement(ctx, model.StockMovement{
+		BookID:   created.ID,
+		Type:     model.StockMovementReceipt,
+		Quantity: bookInput.Stock,
+		Reason:   "initial stock",
+	})
 	if err != nil {
-		return model.Book{}, errors.New("author not found")
+		return model.Book{}, err
 	}
-
-	return s.repo.CreateBook(ctx, book)
+	return s.repo.GetBook(ctx, created.ID)
 }
 
 func (s *BookService) GetBook(ctx context.Context, id int) (model.Book, error) {
 	if err := ctx.Err(); err != nil {
 		return model.Book{}, err
 	}
-	return s.repo.GetBook(ctx, id)
+
+	book, err := s.repo.GetBook(ctx, id)
+	if err != nil {
+		return model.Book{}, err
+	}
+	if book.SeriesID != 0 {
+		books, err := seriesBooks(ctx, s.repo, book.SeriesID)
+		if err != nil {
+			return model.Book{}, err
+		}
+		for _, next := range books {
+			if next.SeriesPosition > book.SeriesPosition {
+				book.NextInSeries = &model.BookReference{ID: next.ID, Title: next.Title}
+				break
+			}
+		}
+	}
+	return s.withCurrencies(book), nil
+}
+
+// GetBookByISBN looks a book up by its ISBN-10 or ISBN-13.
+func (s *BookService) GetBookByISBN(ctx context.Context, isbn string) (model.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Book{}, err
+	}
+
+	normalized, err := normalizeISBN(isbn)
+	if err != nil {
+		return model.Book{}, err
+	}
+	books, err := s.repo.SearchBooks(ctx, map[string]string{"isbn": normalized})
+	if err != nil {
+		return model.Book{}, err
+	}
+	if len(books) == 0 {
+		return model.Book{}, fmt.Errorf("book with isbn %s not found", normalized)
+	}
+	return s.withCurrencies(books[0]), nil
+}
+
+// GetAuthorBooks lists the books the author contributed to in any role.
+func (s *BookService) GetAuthorBooks(ctx context.Context, authorID int) ([]model.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoAuthor.GetAuthor(ctx, authorID); err != nil {
+		return nil, err
+	}
+	return s.SearchBooks(ctx, map[string]string{"contributor": strconv.Itoa(authorID)})
+}
+
+// GetPublisherBooks lists the books of the publisher.
+func (s *BookService) GetPublisherBooks(ctx context.Context, publisherID int) ([]model.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoPublisher.GetPublisher(ctx, publisherID); err != nil {
+		return nil, err
+	}
+	return s.SearchBooks(ctx, map[string]string{"publisher_id": strconv.Itoa(publisherID)})
 }
 
+// DeleteBook refuses to delete a book that still has stock, reservations or
+// back-orders.
 func (s *BookService) DeleteBook(ctx context.Context, id int) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return s.repo.DeleteBook(ctx, id)
+	return s.stock.DeleteBook(ctx, id)
 }
 
 func (s *BookService) UpdateBook(ctx context.Context, id int, bookInput model.BookInput) (model.Book, error) {
@@ -75,42 +161,448 @@ func (s *BookService) UpdateBook(ctx context.Context, id int, bookInput model.Bo
 	}
 
 	existingBook, err := s.repo.GetBook(ctx, id)
+	if err != nil {
+		return model.Book{}, err
+	}
+	updatedBook, err := s.buildBook(ctx, id, bookInput, nil)
+	if err != nil {
+		return model.Book{}, err
+	}
+	updatedBook.ID = existingBook.ID
+	if bookInput.PublishedAt == nil {
+		updatedBook.PublishedAt = existingBook.PublishedAt
+	}
+
+	if err := ctx.Err(); err != nil {
+		return model.Book{}, err
+	}
 
-	updatedBook := model.Book{
-		ID:          existingBook.ID,
-		Title:       bookInput.Title,
-		AuthorID:    bookInput.AuthorID,
-		Genres:      bookInput.Genres,
-		PublishedAt: existingBook.PublishedAt,
-		Price:       bookInput.Price,
-		Stock:       bookInput.Stock,
+	// stock changes are booked as an adjustment of the default warehouse
+	// instead of being overwritten
+	updatedBook.Stock = existingBook.Stock
+	updatedBook.Locations = existingBook.Locations
+	updatedBook.Cover = existingBook.Cover
+	updatedBook.RatingAverage = existingBook.RatingAverage
+	updatedBook.RatingCount = existingBook.RatingCount
+	if _, err := s.repo.UpdateBook(ctx, id, updatedBook); err != nil {
+		return model.Book{}, err
+	}
+	if delta := bookInput.Stock - existingBook.Stock; delta != 0 {
+		recorded, err := s.stock.RecordMovement(ctx, model.StockMovement{
+			BookID:   id,
+			Type:     model.StockMovementAdjustment,
+			Quantity: delta,
+			Reason:   "stock set through a book update",
+		})
+		if recorded.ID == 0 {
+			// a refused adjustment leaves the book as it was
+			if _, restoreErr := s.repo.UpdateBook(ctx, id, existingBook); restoreErr != nil {
+				return model.Book{}, fmt.Errorf("stock could not be adjusted (%v) and the book could not be restored: %w", err, restoreErr)
+			}
+			return model.Book{}, err
+		}
+		if err != nil {
+			// the stock was recorded, only what follows it failed
+			book, _ := s.repo.GetBook(ctx, id)
+			return book, err
+		}
 	}
 
+	book, err := s.repo.GetBook(ctx, id)
 	if err != nil {
 		return model.Book{}, err
 	}
-	if updatedBook.Stock < 0 || bookInput.Price < 0 {
-		return model.Book{}, errors.New("book details are invalid")
+	if _, err := s.wishlists.CheckPrice(ctx, id); err != nil {
+		return book, fmt.Errorf("book updated but wishlists could not be notified: %w", err)
 	}
-	if updatedBook.Title == "" {
-		return model.Book{}, errors.New("book title is mandatory")
+	return book, nil
+}
+
+// validateBook checks a book the way CreateBook, for id 0, or UpdateBook would
+// without saving it, the ISBN being unique included. newAuthors are the
+// stand-in IDs of contributors whose authors are created with the book.
+func (s *BookService) validateBook(ctx context.Context, id int, bookInput model.BookInput, newAuthors map[int]bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if id != 0 {
+		if _, err := s.repo.GetBook(ctx, id); err != nil {
+			return err
+		}
+	}
+	book, err := s.buildBook(ctx, id, bookInput, newAuthors)
+	if err != nil {
+		return err
+	}
+	if book.ISBN == "" {
+		return nil
 	}
+	books, err := s.repo.SearchBooks(ctx, map[string]string{"isbn": book.ISBN})
+	if err != nil {
+		return err
+	}
+	for _, other := range books {
+		if other.ID != id {
+			return fmt.Errorf("book with isbn %s already exists", book.ISBN)
+		}
+	}
+	return nil
+}
 
-	_, err1 := s.repoAuthor.GetAuthor(ctx, bookInput.AuthorID)
-	if err1 != nil {
-		return model.Book{}, errors.New("author not found")
+// buildBook validates the input of the book with the id, 0 for a new book, and
+// turns it into a book. The stock is the one asked for; callers book it
+// through the ledger. Contributors with an ID in newAuthors need no author.
+func (s *BookService) buildBook(ctx context.Context, id int, bookInput model.BookInput, newAuthors map[int]bool) (model.Book, error) {
+	book := model.Book{
+		Title:          bookInput.Title,
+		Genres:         bookInput.Genres,
+		Price:          bookInput.Price,
+		Prices:         bookInput.Prices,
+		SalePrices:     bookInput.SalePrices,
+		TaxCategory:    bookInput.TaxCategory,
+		Weight:         bookInput.Weight,
+		PublisherID:    bookInput.PublisherID,
+		Publisher:      bookInput.Publisher,
+		Language:       strings.ToLower(strings.TrimSpace(bookInput.Language)),
+		PageCount:      bookInput.PageCount,
+		Format:         strings.ToLower(bookInput.Format),
+		Edition:        bookInput.Edition,
+		SeriesID:       bookInput.SeriesID,
+		SeriesPosition: bookInput.SeriesPosition,
+		Stock:          bookInput.Stock,
+	}
+	if bookInput.PublishedAt != nil {
+		book.PublishedAt = *bookInput.PublishedAt
 	}
 
-	if err := ctx.Err(); err != nil {
+	if book.Stock < 0 || book.Price.Amount < 0 || book.Weight < 0 || book.PageCount < 0 {
+		return model.Book{}, errors.New("book details are invalid")
+	}
+	if err := normalizeBookMetadata(&book, bookInput.ISBN); err != nil {
 		return model.Book{}, err
 	}
+	if err := s.normalizePrices(&book); err != nil {
+		return model.Book{}, err
+	}
+	if book.Title == "" {
+		return model.Book{}, errors.New("book title is mandatory")
+	}
 
-	return s.repo.UpdateBook(ctx, id, updatedBook)
+	contributors, err := s.bookContributors(ctx, bookInput, newAuthors)
+	if err != nil {
+		return model.Book{}, err
+	}
+	book.Contributors = contributors
+	book.AuthorID = firstAuthorID(contributors)
+	if err := s.linkPublisher(ctx, &book); err != nil {
+		return model.Book{}, err
+	}
+	if err := s.placeInSeries(ctx, id, &book); err != nil {
+		return model.Book{}, err
+	}
+	if book.Genres, err = s.genres.ResolveGenres(ctx, book.Genres); err != nil {
+		return model.Book{}, err
+	}
+	return book, nil
 }
 
 func (s *BookService) SearchBooks(ctx context.Context, params map[string]string) ([]model.Book, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	return s.repo.SearchBooks(ctx, params)
+
+	// a genre finds the books of its subgenres too
+	if genre, ok := params["genre"]; ok {
+		names, found, err := s.genres.GenreWithSubgenres(ctx, genre)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			params["genre"] = strings.Join(names, ",")
+		}
+	}
+	// ISBNs are searched the way they are stored
+	if isbn, ok := params["isbn"]; ok {
+		if normalized, err := normalizeISBN(isbn); err == nil {
+			params["isbn"] = normalized
+		}
+	}
+
+	// sorting is not a filter of the store
+	sortBy := params["sort"]
+	delete(params, "sort")
+	if sortBy != "" && sortBy != "rating" {
+		return nil, fmt.Errorf("books cannot be sorted by %s", sortBy)
+	}
+
+	books, err := s.repo.SearchBooks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if sortBy == "rating" {
+		books = slices.Clone(books)
+		sort.SliceStable(books, func(i, j int) bool {
+			if books[i].RatingAverage != books[j].RatingAverage {
+				return books[i].RatingAverage > books[j].RatingAverage
+			}
+			return books[i].RatingCount > books[j].RatingCount
+		})
+	}
+	result := make([]model.Book, 0, len(books))
+	for _, book := range books {
+		result = append(result, s.withCurrencies(book))
+	}
+	return result, nil
+}
+
+// withCurrencies shows the prices of books saved before prices had a currency
+// in the base currency.
+func (s *BookService) withCurrencies(book model.Book) model.Book {
+	book.Price = s.currencies.Normalize(book.Price)
+	if len(book.SalePrices) > 0 {
+		salePrices := make([]model.SalePrice, len(book.SalePrices))
+		for i, sale := range book.SalePrices {
+			sale.Price = s.currencies.Normalize(sale.Price)
+			salePrices[i] = sale
+		}
+		book.SalePrices = salePrices
+	}
+	return book
+}
+
+// normalizePrices gives the prices of the book without a currency the base
+// currency, and checks that the book has at most one list price per currency
+// and that every sale price is below the list price of its currency.
+func (s *BookService) normalizePrices(book *model.Book) error {
+	currency, err := s.currencies.Currency(book.Price.Currency)
+	if err != nil {
+		return err
+	}
+	book.Price.Currency = currency
+
+	listed := map[string]bool{currency: true}
+	for i, price := range book.Prices {
+		if price.Currency == "" {
+			return errors.New("price currency is mandatory")
+		}
+		currency, err := s.currencies.Currency(price.Currency)
+		if err != nil {
+			return err
+		}
+		if listed[currency] {
+			return fmt.Errorf("book has more than one price in %s", currency)
+		}
+		if price.Amount < 0 {
+			return errors.New("book details are invalid")
+		}
+		listed[currency] = true
+		book.Prices[i].Currency = currency
+	}
+
+	for i, sale := range book.SalePrices {
+		sale.Price = s.currencies.Normalize(sale.Price)
+		listPrice, ok := bookListPrice(*book, sale.Price.Currency)
+		if !ok {
+			return fmt.Errorf("book has no price in %s to put on sale", sale.Price.Currency)
+		}
+		if sale.Price.Amount < 0 || sale.Price.Amount > listPrice.Amount {
+			return errors.New("sale price must be between 0 and the book price")
+		}
+		if sale.EndsAt != nil && !sale.EndsAt.After(sale.StartsAt) {
+			return errors.New("sale must end after it starts")
+		}
+		book.SalePrices[i] = sale
+	}
+	return nil
+}
+
+// bookListPrice returns the price of the book listed in the currency, if any.
+func bookListPrice(book model.Book, currency string) (model.Money, bool) {
+	if book.Price.Currency == currency || (book.Price.Currency == "" && currency == "") {
+		return book.Price, true
+	}
+	for _, price := range book.Prices {
+		if price.Currency == currency {
+			return price, true
+		}
+	}
+	return model.Money{}, false
+}
+
+// bookContributors checks the contributors of the book input against the
+// authors and numbers them in order. An input with only an author ID has that
+// author as its only contributor.
+func (s *BookService) bookContributors(ctx context.Context, bookInput model.BookInput, newAuthors map[int]bool) ([]model.Contributor, error) {
+	input := bookInput.Contributors
+	if len(input) == 0 && bookInput.AuthorID != 0 {
+		input = []model.Contributor{{AuthorID: bookInput.AuthorID, Role: model.ContributorAuthor}}
+	}
+	if len(input) == 0 {
+		return nil, errors.New("book needs at least one contributor")
+	}
+
+	contributors := make([]model.Contributor, len(input))
+	seen := make(map[model.Contributor]bool)
+	for i, contributor := range input {
+		contributor.Role = strings.ToLower(contributor.Role)
+		if contributor.Role == "" {
+			contributor.Role = model.ContributorAuthor
+		}
+		switch contributor.Role {
+		case model.ContributorAuthor, model.ContributorEditor, model.ContributorTranslator, model.ContributorIllustrator:
+		default:
+			return nil, fmt.Errorf("contributor role must be %s, %s, %s or %s", model.ContributorAuthor, model.ContributorEditor, model.ContributorTranslator, model.ContributorIllustrator)
+		}
+		if _, err := s.repoAuthor.GetAuthor(ctx, contributor.AuthorID); err != nil && !newAuthors[contributor.AuthorID] {
+			return nil, fmt.Errorf("author %d not found", contributor.AuthorID)
+		}
+		key := model.Contributor{AuthorID: contributor.AuthorID, Role: contributor.Role}
+		if seen[key] {
+			return nil, fmt.Errorf("author %d is listed twice as %s", contributor.AuthorID, contributor.Role)
+		}
+		seen[key] = true
+		if contributor.Position == 0 {
+			contributor.Position = i + 1
+		}
+		contributors[i] = contributor
+	}
+
+	sort.SliceStable(contributors, func(i, j int) bool { return contributors[i].Position < contributors[j].Position })
+	for i := range contributors {
+		contributors[i].Position = i + 1
+	}
+	return contributors, nil
+}
+
+// linkPublisher names the book after its publisher, when it is linked to one.
+func (s *BookService) linkPublisher(ctx context.Context, book *model.Book) error {
+	if book.PublisherID == 0 {
+		return nil
+	}
+	publisher, err := s.repoPublisher.GetPublisher(ctx, book.PublisherID)
+	if err != nil {
+		return errors.New("publisher not found")
+	}
+	book.Publisher = publisher.Name
+	return nil
+}
+
+// placeInSeries checks the series of the book with the id, 0 for a new book,
+// and that no other book of the series holds its position. A book without a
+// position comes last.
+func (s *BookService) placeInSeries(ctx context.Context, id int, book *model.Book) error {
+	if book.SeriesID == 0 {
+		book.SeriesPosition = 0
+		return nil
+	}
+	if _, err := s.repoSeries.GetSeries(ctx, book.SeriesID); err != nil {
+		return errors.New("series not found")
+	}
+	if book.SeriesPosition < 0 {
+		return errors.New("series position must be positive")
+	}
+
+	books, err := seriesBooks(ctx, s.repo, book.SeriesID)
+	if err != nil {
+		return err
+	}
+	last := 0
+	for _, other := range books {
+		if other.ID == id {
+			continue
+		}
+		if other.SeriesPosition == book.SeriesPosition {
+			return fmt.Errorf("%s is already at position %d of the series", other.Title, other.SeriesPosition)
+		}
+		last = max(last, other.SeriesPosition)
+	}
+	if book.SeriesPosition == 0 {
+		book.SeriesPosition = last + 1
+	}
+	return nil
+}
+
+// firstAuthorID returns the first contributor of the book in the author role,
+// or 0 when it only has editors, translators or illustrators.
+func firstAuthorID(contributors []model.Contributor) int {
+	for _, contributor := range contributors {
+		if contributor.Role == model.ContributorAuthor {
+			return contributor.AuthorID
+		}
+	}
+	return 0
+}
+
+// normalizeBookMetadata stores the ISBN of the book as an ISBN-13 and checks
+// its format.
+func normalizeBookMetadata(book *model.Book, isbn string) error {
+	if isbn != "" {
+		normalized, err := normalizeISBN(isbn)
+		if err != nil {
+			return err
+		}
+		book.ISBN = normalized
+	}
+	switch book.Format {
+	case "", model.BookFormatHardcover, model.BookFormatPaperback, model.BookFormatEbook:
+		return nil
+	default:
+		return fmt.Errorf("book format must be %s, %s or %s", model.BookFormatHardcover, model.BookFormatPaperback, model.BookFormatEbook)
+	}
+}
+
+// normalizeISBN strips the hyphens and spaces of an ISBN-10 or ISBN-13, checks
+// its check digit and returns it as an ISBN-13. ISBN-10s become 978 ISBN-13s.
+func normalizeISBN(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+	invalid := fmt.Errorf("%s is not a valid ISBN", isbn)
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, c := range digits {
+			value := int(c - '0')
+			if c == 'X' && i == 9 {
+				value = 10
+			} else if c < '0' || c > '9' {
+				return "", invalid
+			}
+			sum += (10 - i) * value
+		}
+		if sum%11 != 0 {
+			return "", invalid
+		}
+		isbn13 := "978" + digits[:9]
+		return isbn13 + isbn13CheckDigit(isbn13), nil
+	case 13:
+		for _, c := range digits {
+			if c < '0' || c > '9' {
+				return "", invalid
+			}
+		}
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", invalid
+		}
+		if isbn13CheckDigit(digits[:12]) != digits[12:] {
+			return "", invalid
+		}
+		return digits, nil
+	default:
+		return "", invalid
+	}
+}
+
+// isbn13CheckDigit computes the check digit of the first twelve digits of an
+// ISBN-13.
+func isbn13CheckDigit(digits string) string {
+	sum := 0
+	for i, c := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(c-'0')
+	}
+	return strconv.Itoa((10 - sum%10) % 10)
 }
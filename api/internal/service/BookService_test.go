@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		name    string
+		isbn    string
+		want    string
+		wantErr bool
+	}{
+		{name: "isbn-13 with hyphens", isbn: "978-0-306-40615-7", want: "9780306406157"},
+		{name: "isbn-13 with spaces", isbn: "978 0 306 40615 7", want: "9780306406157"},
+		{name: "979 isbn-13", isbn: "979-10-90636-07-1", want: "9791090636071"},
+		{name: "isbn-10 becomes isbn-13", isbn: "0-306-40615-2", want: "9780306406157"},
+		{name: "isbn-10 with x check digit", isbn: "0-8044-2957-X", want: "9780804429573"},
+		{name: "isbn-10 with lower case x", isbn: "080442957x", want: "9780804429573"},
+		{name: "isbn-10 with wrong check digit", isbn: "0-306-40615-3", wantErr: true},
+		{name: "isbn-13 with wrong check digit", isbn: "978-0-306-40615-8", wantErr: true},
+		{name: "isbn-13 without book prefix", isbn: "1234567890128", wantErr: true},
+		{name: "x before the check digit", isbn: "0-306-4061X-2", wantErr: true},
+		{name: "letters", isbn: "978-0-306-4061A-7", wantErr: true},
+		{name: "too short", isbn: "12345", wantErr: true},
+		{name: "empty", isbn: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeISBN(tt.isbn)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestISBN13CheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   string
+	}{
+		{"978030640615", "7"},
+		{"978080442957", "3"},
+		{"979109063607", "1"},
+		// a sum that is a multiple of ten has 0 as its check digit
+		{"978000000004", "0"},
+	}
+	for _, tt := range tests {
+		if got := isbn13CheckDigit(tt.digits); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteBook(t *testing.T) {
+	tests := []struct {
+		name        string
+		book        model.Book
+		reservation *model.Reservation
+		wantErr     bool
+	}{
+		{
+			name: "book without stock or orders",
+			book: model.Book{ID: 1},
+		},
+		{
+			name:    "book in stock",
+			book:    model.Book{ID: 1, Stock: 2, Locations: []model.LocationStock{{WarehouseID: 1, Quantity: 2}}},
+			wantErr: true,
+		},
+		{
+			name:    "copies on their way to a warehouse",
+			book:    model.Book{ID: 1, Locations: []model.LocationStock{{WarehouseID: 1, InTransit: 1}}},
+			wantErr: true,
+		},
+		{
+			name:        "copies reserved for a pending order",
+			book:        model.Book{ID: 1},
+			reservation: &model.Reservation{OrderID: 1, BookID: 1, WarehouseID: 1, Quantity: 1, Status: model.ReservationActive},
+			wantErr:     true,
+		},
+		{
+			name:        "back-ordered book",
+			book:        model.Book{ID: 1},
+			reservation: &model.Reservation{OrderID: 1, BookID: 1, Quantity: 2, Status: model.ReservationBackordered},
+			wantErr:     true,
+		},
+		{
+			name:        "back-order that was released",
+			book:        model.Book{ID: 1},
+			reservation: &model.Reservation{OrderID: 1, BookID: 1, Quantity: 2, Status: model.ReservationReleased},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books := &fakeBookStore{books: map[int]model.Book{1: tt.book}}
+			reservations := &fakeReservationStore{}
+			if tt.reservation != nil {
+				reservations.CreateReservation(context.Background(), *tt.reservation)
+			}
+			stock := NewStockService(nil, books, nil, reservations, nil, nil, nil, nil, time.Hour)
+			service := NewBookService(books, nil, nil, nil, nil, stock, nil, nil)
+
+			err := service.DeleteBook(context.Background(), 1)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				if _, ok := books.books[1]; !ok {
+					t.Error("the book was deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := books.books[1]; ok {
+				t.Error("the book was not deleted")
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type CartService struct {
+	repo         repository.CartStore
+	repoBook     repository.BookStore
+	repoCustomer repository.CustomerStore
+	orderService *OrderService
+	stock        *StockService
+	promotions   *PromotionService
+	currencies   *CurrencyService
+	ttl          time.Duration
+	mutex        sync.Mutex
+}
+
+func NewCartService(repo repository.CartStore, repoBook repository.BookStore, repoCustomer repository.CustomerStore, orderService *OrderService, stock *StockService, promotions *PromotionService, currencies *CurrencyService, ttl time.Duration) *CartService {
+	return &CartService{
+		repo:         repo,
+		repoBook:     repoBook,
+		repoCustomer: repoCustomer,
+		orderService: orderService,
+		stock:        stock,
+		promotions:   promotions,
+		currencies:   currencies,
+		ttl:          ttl,
+	}
+}
+
+func (s *CartService) CreateCart(ctx context.Context, cartInput model.CartInput) (model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CartView{}, err
+	}
+
+	if cartInput.CustomerID != 0 {
+		if _, err := s.repoCustomer.GetCustomer(ctx, cartInput.CustomerID); err != nil {
+			return model.CartView{}, errors.New("customer non existant")
+		}
+	}
+
+	items := []model.CartItem{}
+	for _, item := range cartInput.Items {
+		if err := s.validateItem(ctx, item); err != nil {
+			return model.CartView{}, err
+		}
+		items = addCartItem(items, item)
+	}
+
+	now := time.Now()
+	cart, err := s.repo.CreateCart(ctx, model.Cart{
+		CustomerID: cartInput.CustomerID,
+		Items:      items,
+		Status:     model.CartActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ExpiresAt:  now.Add(s.ttl),
+	})
+	if err != nil {
+		return model.CartView{}, err
+	}
+
+	return s.priceCart(ctx, cart), nil
+}
+
+func (s *CartService) GetCart(ctx context.Context, id int) (model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CartView{}, err
+	}
+
+	cart, err := s.repo.GetCart(ctx, id)
+	if err != nil {
+		return model.CartView{}, err
+	}
+
+	return s.priceCart(ctx, cart), nil
+}
+
+func (s *CartService) DeleteCart(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repo.DeleteCart(ctx, id)
+}
+
+func (s *CartService) SearchCarts(ctx context.Context, params map[string]string) ([]model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	carts, err := s.repo.SearchCarts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]model.CartView, 0, len(carts))
+	for _, cart := range carts {
+		views = append(views, s.priceCart(ctx, cart))
+	}
+	return views, nil
+}
+
+func (s *CartService) AddItem(ctx context.Context, id int, item model.CartItem) (model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CartView{}, err
+	}
+
+	if err := s.validateItem(ctx, item); err != nil {
+		return model.CartView{}, err
+	}
+
+	return s.modifyCart(ctx, id, func(cart *model.Cart) error {
+		cart.Items = addCartItem(cart.Items, item)
+		return nil
+	})
+}
+
+// UpdateItem sets the quantity of a book in the cart, a quantity of 0 removing it.
+func (s *CartService) UpdateItem(ctx context.Context, id int, bookID int, quantity int) (model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CartView{}, err
+	}
+
+	if quantity < 0 {
+		return model.CartView{}, errors.New("item quantity cannot be negative")
+	}
+
+	return s.modifyCart(ctx, id, func(cart *model.Cart) error {
+		for i, item := range cart.Items {
+			if item.BookID == bookID {
+				if quantity == 0 {
+					cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+				} else {
+					cart.Items[i].Quantity = quantity
+				}
+				return nil
+			}
+		}
+		return errors.New("book is not in the cart")
+	})
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, id int, bookID int) (model.CartView, error) {
+	return s.UpdateItem(ctx, id, bookID, 0)
+}
+
+// MergeCart hands an anonymous cart over to a customer, typically when they log
+// in: its items are added to the customer's active cart, or the cart becomes
+// the customer's cart if they have none.
+func (s *CartService) MergeCart(ctx context.Context, id int, customerID int) (model.CartView, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CartView{}, err
+	}
+
+	if _, err := s.repoCustomer.GetCustomer(ctx, customerID); err != nil {
+		return model.CartView{}, errors.New("customer non existant")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	anonymousCart, err := s.activeCart(ctx, id)
+	if err != nil {
+		return model.CartView{}, err
+	}
+	if anonymousCart.CustomerID != 0 {
+		return model.CartView{}, errors.New("only anonymous carts can be merged")
+	}
+
+	customerCarts, err := s.repo.SearchCarts(ctx, map[string]string{
+		"customer_id": strconv.Itoa(customerID),
+		"status":      model.CartActive,
+	})
+	if err != nil {
+		return model.CartView{}, err
+	}
+
+	now := time.Now()
+	var target model.Cart
+	for _, cart := range customerCarts {
+		if !isCartExpired(cart, now) {
+			target = cart
+			break
+		}
+	}
+
+	if target.ID == 0 {
+		anonymousCart.CustomerID = customerID
+		anonymousCart.UpdatedAt = now
+		anonymousCart.ExpiresAt = now.Add(s.ttl)
+		updated, err := s.repo.UpdateCart(ctx, anonymousCart.ID, anonymousCart)
+		if err != nil {
+			return model.CartView{}, err
+		}
+		return s.priceCart(ctx, updated), nil
+	}
+
+	for _, item := range anonymousCart.Items {
+		target.Items = addCartItem(target.Items, item)
+	}
+	target.UpdatedAt = now
+	target.ExpiresAt = now.Add(s.ttl)
+	merged, err := s.repo.UpdateCart(ctx, target.ID, target)
+	if err != nil {
+		return model.CartView{}, err
+	}
+
+	anonymousCart.Status = model.CartMerged
+	anonymousCart.UpdatedAt = now
+	if _, err := s.repo.UpdateCart(ctx, anonymousCart.ID, anonymousCart); err != nil {
+		return model.CartView{}, err
+	}
+
+	return s.priceCart(ctx, merged), nil
+}
+
+// Checkout turns the cart into an order through the order service, which
+// reserves the stock of every item until the order is paid.
+func (s *CartService) Checkout(ctx context.Context, id int, checkoutInput model.CartCheckoutInput) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cart, err := s.activeCart(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if cart.CustomerID == 0 {
+		return model.Order{}, errors.New("anonymous carts must be merged into a customer cart before checkout")
+	}
+	if len(cart.Items) == 0 {
+		return model.Order{}, errors.New("cart is empty")
+	}
+
+	items := make([]model.OrderItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, model.OrderItem{BookID: item.BookID, Quantity: item.Quantity})
+	}
+
+	order, err := s.orderService.CreateOrder(ctx, model.OrderInput{
+		CustomerId:       cart.CustomerID,
+		Items:            items,
+		CouponCode:       checkoutInput.CouponCode,
+		ShippingMethodID: checkoutInput.ShippingMethodID,
+		RedeemPoints:     checkoutInput.RedeemPoints,
+		AllowBackorder:   checkoutInput.AllowBackorder,
+	})
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	cart.Status = model.CartCheckedOut
+	cart.OrderID = order.ID
+	cart.UpdatedAt = time.Now()
+	if _, err := s.repo.UpdateCart(ctx, cart.ID, cart); err != nil {
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
+
+// ExpireCarts marks every active cart past its expiry date as expired.
+func (s *CartService) ExpireCarts(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	carts, err := s.repo.SearchCarts(ctx, map[string]string{"status": model.CartActive})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	expired := 0
+	for _, cart := range carts {
+		if !isCartExpired(cart, now) {
+			continue
+		}
+		cart.Status = model.CartExpired
+		if _, err := s.repo.UpdateCart(ctx, cart.ID, cart); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *CartService) StartCartSweeper(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireCarts(ctx)
+			if err != nil {
+				logger.Printf("Error expiring carts: %v\n", err)
+			} else if expired > 0 {
+				logger.Printf("Expired %d carts\n", expired)
+			}
+		}
+	}
+}
+
+func (s *CartService) modifyCart(ctx context.Context, id int, modify func(cart *model.Cart) error) (model.CartView, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cart, err := s.activeCart(ctx, id)
+	if err != nil {
+		return model.CartView{}, err
+	}
+
+	if err := modify(&cart); err != nil {
+		return model.CartView{}, err
+	}
+
+	now := time.Now()
+	cart.UpdatedAt = now
+	cart.ExpiresAt = now.Add(s.ttl)
+
+	updated, err := s.repo.UpdateCart(ctx, id, cart)
+	if err != nil {
+		return model.CartView{}, err
+	}
+	return s.priceCart(ctx, updated), nil
+}
+
+func (s *CartService) activeCart(ctx context.Context, id int) (model.Cart, error) {
+	cart, err := s.repo.GetCart(ctx, id)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	if cart.Status != model.CartActive || isCartExpired(cart, time.Now()) {
+		return model.Cart{}, errors.New("cart is no longer active")
+	}
+	return cart, nil
+}
+
+func (s *CartService) validateItem(ctx context.Context, item model.CartItem) error {
+	if item.Quantity <= 0 {
+		return errors.New("item quantity must be positive")
+	}
+	if _, err := s.repoBook.GetBook(ctx, item.BookID); err != nil {
+		return errors.New("book non existant")
+	}
+	return nil
+}
+
+// priceCart prices every line of the cart like an order would be, in the
+// currency of the customer with the sale prices and promotion rules running now,
+// and shows the available stock of its book; books deleted since they were
+// added are priced at zero and shown as unavailable.
+func (s *CartService) priceCart(ctx context.Context, cart model.Cart) model.CartView {
+	view := model.CartView{
+		ID:         cart.ID,
+		CustomerID: cart.CustomerID,
+		Status:     cart.Status,
+		OrderID:    cart.OrderID,
+		Lines:      []model.CartLine{},
+		CreatedAt:  cart.CreatedAt,
+		UpdatedAt:  cart.UpdatedAt,
+		ExpiresAt:  cart.ExpiresAt,
+	}
+	if cart.Status == model.CartActive && isCartExpired(cart, time.Now()) {
+		view.Status = model.CartExpired
+	}
+
+	view.Currency = s.currencies.BaseCurrency()
+	if customer, err := s.repoCustomer.GetCustomer(ctx, cart.CustomerID); err == nil {
+		if currency, err := s.currencies.Currency(customer.Currency); err == nil {
+			view.Currency = currency
+		}
+	}
+	view.Total = model.Money{Currency: view.Currency}
+
+	for _, item := range cart.Items {
+		zero := model.Money{Currency: view.Currency}
+		line := model.CartLine{BookID: item.BookID, Quantity: item.Quantity, UnitPrice: zero, Discount: zero, LineTotal: zero}
+		if book, err := s.repoBook.GetBook(ctx, item.BookID); err == nil {
+			line.Title = book.Title
+			orderItem := model.OrderItem{BookID: item.BookID, Quantity: item.Quantity}
+			if priced, err := s.promotions.PriceItems(ctx, []model.OrderItem{orderItem}, nil, view.Currency); err == nil {
+				line.UnitPrice = priced[0].UnitPrice
+				line.LineTotal = priced[0].LineTotal
+				if discount, err := line.UnitPrice.Mul(line.Quantity).Sub(line.LineTotal); err == nil {
+					line.Discount = discount
+				}
+			}
+		}
+		if availability, err := s.stock.GetAvailability(ctx, item.BookID); err == nil {
+			line.Available = availability.Available
+			line.InStock = availability.Available >= item.Quantity
+		}
+		view.Lines = append(view.Lines, line)
+		if total, err := view.Total.Add(line.LineTotal); err == nil {
+			view.Total = total
+		}
+	}
+	return view
+}
+
+func addCartItem(items []model.CartItem, item model.CartItem) []model.CartItem {
+	for i, existing := range items {
+		if existing.BookID == item.BookID {
+			items[i].Quantity += item.Quantity
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+func isCartExpired(cart model.Cart, now time.Time) bool {
+	return now.After(cart.ExpiresAt)
+}
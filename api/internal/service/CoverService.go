@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxCoverSize is the largest cover image accepted, in bytes, and
+// MaxCoverPixels the most pixels it may have. A small compressed file can
+// declare a huge image, so the dimensions are checked before it is decoded.
+const (
+	MaxCoverSize   = 5 << 20
+	MaxCoverPixels = 40_000_000
+)
+
+const (
+	CoverSizeOriginal = "original"
+	CoverSizeLarge    = "large"
+	CoverSizeMedium   = "medium"
+	CoverSizeSmall    = "small"
+)
+
+// coverWidths are the widths in pixels of the thumbnails made of every cover.
+// Covers narrower than a thumbnail are kept at their size.
+var coverWidths = map[string]int{
+	CoverSizeLarge:  600,
+	CoverSizeMedium: 300,
+	CoverSizeSmall:  100,
+}
+
+type CoverService struct {
+	repoBook repository.BookStore
+	storage  BlobStorage
+	mutex    sync.Mutex
+}
+
+func NewCoverService(repoBook repository.BookStore, storage BlobStorage) *CoverService {
+	return &CoverService{
+		repoBook: repoBook,
+		storage:  storage,
+	}
+}
+
+// UploadCover checks that the image is a JPEG or PNG of at most MaxCoverSize,
+// stores it with its thumbnails and links them to the book.
+func (s *CoverService) UploadCover(ctx context.Context, bookID int, data []byte) (model.Book, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Book{}, err
+	}
+
+	if len(data) == 0 {
+		return model.Book{}, errors.New("cover image is empty")
+	}
+	if len(data) > MaxCoverSize {
+		return model.Book{}, fmt.Errorf("cover image is larger than %d MB", MaxCoverSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return model.Book{}, errors.New("cover must be a JPEG or PNG image")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return model.Book{}, errors.New("cover image cannot be read")
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxCoverPixels {
+		return model.Book{}, fmt.Errorf("cover image is larger than %d megapixels", MaxCoverPixels/1_000_000)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return model.Book{}, errors.New("cover image cannot be read")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	cover := &model.BookCover{
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		URLs:        make(map[string]string),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.storage.Put(ctx, coverKey(bookID, CoverSizeOriginal, contentType), data); err != nil {
+		return model.Book{}, err
+	}
+	for size, width := range coverWidths {
+		resized, err := resizeCover(ctx, img, width)
+		if err != nil {
+			return model.Book{}, err
+		}
+		thumbnail, err := encodeCover(resized, contentType)
+		if err != nil {
+			return model.Book{}, err
+		}
+		if err := s.storage.Put(ctx, coverKey(bookID, size, contentType), thumbnail); err != nil {
+			return model.Book{}, err
+		}
+	}
+	for _, size := range []string{CoverSizeOriginal, CoverSizeLarge, CoverSizeMedium, CoverSizeSmall} {
+		// the version makes clients caching the previous cover fetch the new one
+		cover.URLs[size] = fmt.Sprintf("/books/%d/cover?size=%s&v=%d", bookID, size, cover.UpdatedAt.Unix())
+	}
+
+	previous := book.Cover
+	book.Cover = cover
+	updated, err := s.repoBook.UpdateBook(ctx, bookID, book)
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	// a cover of another type was saved under other keys
+	if previous != nil && previous.ContentType != contentType {
+		for _, size := range []string{CoverSizeOriginal, CoverSizeLarge, CoverSizeMedium, CoverSizeSmall} {
+			if err := s.storage.Delete(ctx, coverKey(bookID, size, previous.ContentType)); err != nil {
+				return updated, fmt.Errorf("cover uploaded but the previous cover could not be deleted: %w", err)
+			}
+		}
+	}
+	return updated, nil
+}
+
+// GetCover returns the cover of the book in the size, the original image when
+// no size is given.
+func (s *CoverService) GetCover(ctx context.Context, bookID int, size string) ([]byte, model.BookCover, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, model.BookCover{}, err
+	}
+
+	if size == "" {
+		size = CoverSizeOriginal
+	}
+	if _, ok := coverWidths[size]; !ok && size != CoverSizeOriginal {
+		return nil, model.BookCover{}, fmt.Errorf("unknown cover size %s", size)
+	}
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return nil, model.BookCover{}, err
+	}
+	if book.Cover == nil {
+		return nil, model.BookCover{}, errors.New("book has no cover")
+	}
+
+	data, err := s.storage.Get(ctx, coverKey(bookID, size, book.Cover.ContentType))
+	if err != nil {
+		return nil, model.BookCover{}, err
+	}
+	return data, *book.Cover, nil
+}
+
+func coverKey(bookID int, size string, contentType string) string {
+	extension := "jpg"
+	if contentType == "image/png" {
+		extension = "png"
+	}
+	return fmt.Sprintf("covers/%d/%s.%s", bookID, size, extension)
+}
+
+// encodeCover encodes a thumbnail in the format of the uploaded cover.
+func encodeCover(img image.Image, contentType string) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buffer, img)
+	} else {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85})
+	}
+	return buffer.Bytes(), err
+}
+
+// resizeCover scales the image down to the width, keeping its aspect ratio.
+// Every pixel of the thumbnail is the average of the pixels it covers. It
+// stops when the context is done.
+func resizeCover(ctx context.Context, img image.Image, width int) (image.Image, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img, nil
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+
+	thumbnail := image.NewRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			thumbnail.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+	return thumbnail, nil
+}
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CreditService manages gift cards and the store credit of customers, the
+// balances that can pay for orders, and records every change to them in the
+// credit ledger.
+type CreditService struct {
+	repo            repository.GiftCardStore
+	repoTransaction repository.CreditTransactionStore
+	repoCustomer    repository.CustomerStore
+	currencies      *CurrencyService
+	mutex           sync.Mutex
+}
+
+func NewCreditService(repo repository.GiftCardStore, repoTransaction repository.CreditTransactionStore, repoCustomer repository.CustomerStore, currencies *CurrencyService) *CreditService {
+	return &CreditService{
+		repo:            repo,
+		repoTransaction: repoTransaction,
+		repoCustomer:    repoCustomer,
+		currencies:      currencies,
+	}
+}
+
+func (s *CreditService) IssueGiftCard(ctx context.Context, giftCardInput model.GiftCardInput) (model.GiftCard, error) {
+	if err := ctx.Err(); err != nil {
+		return model.GiftCard{}, err
+	}
+
+	balance, err := s.creditAmount(giftCardInput.Balance)
+	if err != nil {
+		return model.GiftCard{}, err
+	}
+	if giftCardInput.ExpiresAt != nil && !giftCardInput.ExpiresAt.After(time.Now()) {
+		return model.GiftCard{}, errors.New("gift card expiry must be in the future")
+	}
+	if giftCardInput.CustomerID != 0 {
+		if _, err := s.repoCustomer.GetCustomer(ctx, giftCardInput.CustomerID); err != nil {
+			return model.GiftCard{}, errors.New("customer non existant")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	code := strings.ToUpper(strings.TrimSpace(giftCardInput.Code))
+	if code == "" {
+		code = nextGiftCardCode()
+	}
+	existing, err := s.repo.SearchGiftCards(ctx, map[string]string{"code": code})
+	if err != nil {
+		return model.GiftCard{}, err
+	}
+	if len(existing) > 0 {
+		return model.GiftCard{}, fmt.Errorf("gift card %s already exists", code)
+	}
+
+	giftCard, err := s.repo.CreateGiftCard(ctx, model.GiftCard{
+		Code:           code,
+		CustomerID:     giftCardInput.CustomerID,
+		InitialBalance: balance,
+		Balance:        balance,
+		ExpiresAt:      giftCardInput.ExpiresAt,
+		Status:         model.GiftCardActive,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return model.GiftCard{}, err
+	}
+
+	if _, err := s.record(ctx, model.CreditTransaction{
+		Account:    model.CreditAccountGiftCard,
+		GiftCardID: giftCard.ID,
+		CustomerID: giftCard.CustomerID,
+		Type:       model.CreditIssue,
+		Amount:     balance,
+		Balance:    giftCard.Balance,
+	}); err != nil {
+		return model.GiftCard{}, err
+	}
+	return giftCard, nil
+}
+
+func (s *CreditService) GetGiftCard(ctx context.Context, id int) (model.GiftCard, error) {
+	if err := ctx.Err(); err != nil {
+		return model.GiftCard{}, err
+	}
+	return s.repo.GetGiftCard(ctx, id)
+}
+
+func (s *CreditService) SearchGiftCards(ctx context.Context, params map[string]string) ([]model.GiftCard, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchGiftCards(ctx, params)
+}
+
+func (s *CreditService) GetGiftCardTransactions(ctx context.Context, id int) ([]model.CreditTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetGiftCard(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repoTransaction.SearchCreditTransactions(ctx, map[string]string{
+		"account":      model.CreditAccountGiftCard,
+		"gift_card_id": strconv.Itoa(id),
+	})
+}
+
+// IssueStoreCredit adds to the store credit of a customer, converting the
+// amount into the currency of the balance.
+func (s *CreditService) IssueStoreCredit(ctx context.Context, customerID int, creditInput model.StoreCreditInput) (model.CreditTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CreditTransaction{}, err
+	}
+
+	amount, err := s.creditAmount(creditInput.Amount)
+	if err != nil {
+		return model.CreditTransaction{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.addStoreCredit(ctx, model.CreditTransaction{
+		CustomerID: customerID,
+		Type:       model.CreditIssue,
+		Amount:     amount,
+		Note:       creditInput.Note,
+	})
+}
+
+// RefundToStoreCredit pays a refund of an order back as store credit of the
+// customer who placed it.
+func (s *CreditService) RefundToStoreCredit(ctx context.Context, order model.Order, refund model.Refund) (model.CreditTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return model.CreditTransaction{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.addStoreCredit(ctx, model.CreditTransaction{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		RefundID:   refund.ID,
+		Type:       model.CreditIssue,
+		Amount:     refund.Amount,
+		Note:       "refund: " + refund.Reason,
+	})
+}
+
+func (s *CreditService) GetStoreCredit(ctx context.Context, customerID int) (model.StoreCredit, error) {
+	if err := ctx.Err(); err != nil {
+		return model.StoreCredit{}, err
+	}
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, customerID)
+	if err != nil {
+		return model.StoreCredit{}, err
+	}
+	balance, err := s.storeCreditBalance(customer)
+	if err != nil {
+		return model.StoreCredit{}, err
+	}
+
+	transactions, err := s.repoTransaction.SearchCreditTransactions(ctx, map[string]string{
+		"account":     model.CreditAccountStoreCredit,
+		"customer_id": strconv.Itoa(customerID),
+	})
+	if err != nil {
+		return model.StoreCredit{}, err
+	}
+
+	return model.StoreCredit{
+		CustomerID:   customerID,
+		Balance:      balance,
+		Transactions: transactions,
+	}, nil
+}
+
+// RedeemCredits takes up to the amount due for an order from the gift card,
+// then from the store credit of the customer. Nothing is taken when the gift
+// card can't be redeemed; a gift card already applied to the order, or an
+// empty store credit, is skipped so that a failed payment can be retried.
+func (s *CreditService) RedeemCredits(ctx context.Context, order model.Order, giftCardCode string, useStoreCredit bool, due model.Money) ([]model.AppliedCredit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	applied := []model.AppliedCredit{}
+	if giftCardCode != "" {
+		credit, err := s.redeemGiftCard(ctx, order, giftCardCode, due)
+		if err != nil {
+			return nil, err
+		}
+		if credit.Amount.Amount > 0 {
+			applied = append(applied, credit)
+			due, err = due.Sub(credit.Amount)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if useStoreCredit && due.Amount > 0 {
+		credit, err := s.redeemStoreCredit(ctx, order, due)
+		if err != nil {
+			if releaseErr := s.release(ctx, order, applied); releaseErr != nil {
+				return nil, releaseErr
+			}
+			return nil, err
+		}
+		if credit.Amount.Amount > 0 {
+			applied = append(applied, credit)
+		}
+	}
+	return applied, nil
+}
+
+// ReleaseCredits gives the credits applied to an order back to the balances
+// they were taken from, when the order is dropped before it is paid.
+func (s *CreditService) ReleaseCredits(ctx context.Context, order model.Order) error {
+	if len(order.Credits) == 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.release(ctx, order, order.Credits)
+}
+
+// ExpireGiftCards clears the balance of the gift cards past their expiry.
+func (s *CreditService) ExpireGiftCards(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	giftCards, err := s.repo.SearchGiftCards(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	expired := 0
+	for _, giftCard := range giftCards {
+		if giftCard.ExpiresAt == nil || now.Before(*giftCard.ExpiresAt) {
+			continue
+		}
+		if giftCard.Status == model.GiftCardExpired && giftCard.Balance.Amount == 0 {
+			continue
+		}
+
+		forfeited := giftCard.Balance
+		giftCard.Balance = model.Money{Currency: forfeited.Currency}
+		giftCard.Status = model.GiftCardExpired
+		if _, err := s.repo.UpdateGiftCard(ctx, giftCard.ID, giftCard); err != nil {
+			return expired, err
+		}
+		if _, err := s.record(ctx, model.CreditTransaction{
+			Account:    model.CreditAccountGiftCard,
+			GiftCardID: giftCard.ID,
+			CustomerID: giftCard.CustomerID,
+			Type:       model.CreditExpire,
+			Amount:     negate(forfeited),
+			Balance:    giftCard.Balance,
+		}); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *CreditService) StartGiftCardSweeper(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireGiftCards(ctx)
+			if err != nil {
+				logger.Printf("Error expiring gift cards: %v\n", err)
+			} else if expired > 0 {
+				logger.Printf("Expired %d gift cards\n", expired)
+			}
+		}
+	}
+}
+
+// redeemGiftCard takes up to the amount due from the gift card with the code,
+// nothing when the card is already applied to the order. Callers hold the
+// mutex.
+func (s *CreditService) redeemGiftCard(ctx context.Context, order model.Order, code string, due model.Money) (model.AppliedCredit, error) {
+	giftCards, err := s.repo.SearchGiftCards(ctx, map[string]string{"code": strings.TrimSpace(code)})
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if len(giftCards) == 0 {
+		return model.AppliedCredit{}, errors.New("gift card non existant")
+	}
+	giftCard := giftCards[0]
+	for _, credit := range order.Credits {
+		if credit.GiftCardID == giftCard.ID {
+			return model.AppliedCredit{}, nil
+		}
+	}
+
+	if giftCard.Status == model.GiftCardExpired || (giftCard.ExpiresAt != nil && time.Now().After(*giftCard.ExpiresAt)) {
+		return model.AppliedCredit{}, fmt.Errorf("gift card %s has expired", giftCard.Code)
+	}
+	if giftCard.Balance.Amount <= 0 {
+		return model.AppliedCredit{}, fmt.Errorf("gift card %s has no balance left", giftCard.Code)
+	}
+
+	amount, charged, err := s.takeCredit(giftCard.Balance, due)
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	giftCard.Balance, err = giftCard.Balance.Sub(charged)
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if _, err := s.repo.UpdateGiftCard(ctx, giftCard.ID, giftCard); err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if _, err := s.record(ctx, model.CreditTransaction{
+		Account:    model.CreditAccountGiftCard,
+		GiftCardID: giftCard.ID,
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		Type:       model.CreditRedeem,
+		Amount:     negate(charged),
+		Balance:    giftCard.Balance,
+	}); err != nil {
+		return model.AppliedCredit{}, err
+	}
+
+	return model.AppliedCredit{
+		Account:    model.CreditAccountGiftCard,
+		GiftCardID: giftCard.ID,
+		Amount:     amount,
+		Charged:    charged,
+	}, nil
+}
+
+// redeemStoreCredit takes up to the amount due from the store credit of the
+// customer of the order, if any. Callers hold the mutex.
+func (s *CreditService) redeemStoreCredit(ctx context.Context, order model.Order, due model.Money) (model.AppliedCredit, error) {
+	customer, err := s.repoCustomer.GetCustomer(ctx, order.CustomerId)
+	if err != nil {
+		return model.AppliedCredit{}, errors.New("customer non existant")
+	}
+	balance, err := s.storeCreditBalance(customer)
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if balance.Amount <= 0 {
+		return model.AppliedCredit{}, nil
+	}
+
+	amount, charged, err := s.takeCredit(balance, due)
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	customer.StoreCredit, err = balance.Sub(charged)
+	if err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if _, err := s.repoCustomer.UpdateCustomer(ctx, customer.ID, customer); err != nil {
+		return model.AppliedCredit{}, err
+	}
+	if _, err := s.record(ctx, model.CreditTransaction{
+		Account:    model.CreditAccountStoreCredit,
+		CustomerID: customer.ID,
+		OrderID:    order.ID,
+		Type:       model.CreditRedeem,
+		Amount:     negate(charged),
+		Balance:    customer.StoreCredit,
+	}); err != nil {
+		return model.AppliedCredit{}, err
+	}
+
+	return model.AppliedCredit{
+		Account: model.CreditAccountStoreCredit,
+		Amount:  amount,
+		Charged: charged,
+	}, nil
+}
+
+// release gives the credits back to their balances. Callers hold the mutex.
+func (s *CreditService) release(ctx context.Context, order model.Order, credits []model.AppliedCredit) error {
+	for _, credit := range credits {
+		if credit.Account == model.CreditAccountStoreCredit {
+			if _, err := s.addStoreCredit(ctx, model.CreditTransaction{
+				CustomerID: order.CustomerId,
+				OrderID:    order.ID,
+				Type:       model.CreditRelease,
+				Amount:     credit.Charged,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		giftCard, err := s.repo.GetGiftCard(ctx, credit.GiftCardID)
+		if err != nil {
+			return err
+		}
+		giftCard.Balance, err = giftCard.Balance.Add(credit.Charged)
+		if err != nil {
+			return err
+		}
+		if _, err := s.repo.UpdateGiftCard(ctx, giftCard.ID, giftCard); err != nil {
+			return err
+		}
+		if _, err := s.record(ctx, model.CreditTransaction{
+			Account:    model.CreditAccountGiftCard,
+			GiftCardID: giftCard.ID,
+			CustomerID: order.CustomerId,
+			OrderID:    order.ID,
+			Type:       model.CreditRelease,
+			Amount:     credit.Charged,
+			Balance:    giftCard.Balance,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addStoreCredit adds the amount of the transaction to the store credit of its
+// customer and records it. Callers hold the mutex.
+func (s *CreditService) addStoreCredit(ctx context.Context, transaction model.CreditTransaction) (model.CreditTransaction, error) {
+	customer, err := s.repoCustomer.GetCustomer(ctx, transaction.CustomerID)
+	if err != nil {
+		return model.CreditTransaction{}, errors.New("customer non existant")
+	}
+	balance, err := s.storeCreditBalance(customer)
+	if err != nil {
+		return model.CreditTransaction{}, err
+	}
+
+	amount, err := s.currencies.Convert(transaction.Amount, balance.Currency)
+	if err != nil {
+		return model.CreditTransaction{}, err
+	}
+	customer.StoreCredit, err = balance.Add(amount)
+	if err != nil {
+		return model.CreditTransaction{}, err
+	}
+	if _, err := s.repoCustomer.UpdateCustomer(ctx, customer.ID, customer); err != nil {
+		return model.CreditTransaction{}, err
+	}
+
+	transaction.Account = model.CreditAccountStoreCredit
+	transaction.Amount = amount
+	transaction.Balance = customer.StoreCredit
+	return s.record(ctx, transaction)
+}
+
+func (s *CreditService) record(ctx context.Context, transaction model.CreditTransaction) (model.CreditTransaction, error) {
+	transaction.CreatedAt = time.Now()
+	return s.repoTransaction.CreateCreditTransaction(ctx, transaction)
+}
+
+// takeCredit returns how much of the amount due a balance pays, in the
+// currency of the amount due, and what that takes from the balance.
+func (s *CreditService) takeCredit(balance model.Money, due model.Money) (model.Money, model.Money, error) {
+	dueInBalance, err := s.currencies.Convert(due, balance.Currency)
+	if err != nil {
+		return model.Money{}, model.Money{}, err
+	}
+	if balance.Amount >= dueInBalance.Amount {
+		return due, dueInBalance, nil
+	}
+
+	amount, err := s.currencies.Convert(balance, due.Currency)
+	if err != nil {
+		return model.Money{}, model.Money{}, err
+	}
+	if amount.Amount > due.Amount {
+		amount = due
+	}
+	return amount, balance, nil
+}
+
+// storeCreditBalance returns the store credit of the customer, in the currency
+// of the customer while it is empty.
+func (s *CreditService) storeCreditBalance(customer model.Customer) (model.Money, error) {
+	if customer.StoreCredit.Currency != "" {
+		return customer.StoreCredit, nil
+	}
+	currency, err := s.currencies.Currency(customer.Currency)
+	if err != nil {
+		return model.Money{}, err
+	}
+	return model.Money{Amount: customer.StoreCredit.Amount, Currency: currency}, nil
+}
+
+// creditAmount validates an amount credited to a balance, giving it the base
+// currency when it has none.
+func (s *CreditService) creditAmount(amount model.Money) (model.Money, error) {
+	currency, err := s.currencies.Currency(amount.Currency)
+	if err != nil {
+		return model.Money{}, err
+	}
+	if amount.Amount <= 0 {
+		return model.Money{}, errors.New("credit amount must be positive")
+	}
+	amount.Currency = currency
+	return amount, nil
+}
+
+func negate(amount model.Money) model.Money {
+	return model.Money{Amount: -amount.Amount, Currency: amount.Currency}
+}
+
+// nextGiftCardCode returns a random code for gift cards issued without one.
+func nextGiftCardCode() string {
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	code := strings.ToUpper(hex.EncodeToString(buf))
+	return "GC-" + code[:4] + "-" + code[4:8] + "-" + code[8:]
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CurrencyService converts amounts between the currencies of the exchange rate
+// table loaded from a file.
+type CurrencyService struct {
+	base  string
+	rates map[string]float64
+}
+
+func NewCurrencyService(filename string) (*CurrencyService, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %v", err)
+	}
+
+	var table model.ExchangeRateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates: %v", err)
+	}
+	if table.BaseCurrency == "" {
+		return nil, errors.New("base currency is mandatory")
+	}
+
+	base := strings.ToUpper(table.BaseCurrency)
+	rates := map[string]float64{base: 1}
+	for currency, rate := range table.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("exchange rate of %s must be positive", currency)
+		}
+		if strings.ToUpper(currency) != base {
+			rates[strings.ToUpper(currency)] = rate
+		}
+	}
+
+	return &CurrencyService{base: base, rates: rates}, nil
+}
+
+func (s *CurrencyService) BaseCurrency() string {
+	return s.base
+}
+
+// Currency returns the ISO code of a supported currency in upper case, the base
+// currency when none is given.
+func (s *CurrencyService) Currency(currency string) (string, error) {
+	if currency == "" {
+		return s.base, nil
+	}
+	currency = strings.ToUpper(currency)
+	if _, ok := s.rates[currency]; !ok {
+		return "", fmt.Errorf("currency %s is not supported", currency)
+	}
+	return currency, nil
+}
+
+// Normalize gives amounts without a currency the base currency.
+func (s *CurrencyService) Normalize(amount model.Money) model.Money {
+	if amount.Currency == "" {
+		amount.Currency = s.base
+	}
+	return amount
+}
+
+// Convert converts the amount into the currency, rounding to its minor unit.
+func (s *CurrencyService) Convert(amount model.Money, currency string) (model.Money, error) {
+	amount = s.Normalize(amount)
+	to, err := s.Currency(currency)
+	if err != nil {
+		return model.Money{}, err
+	}
+	if amount.Currency == to {
+		return amount, nil
+	}
+
+	fromRate, ok := s.rates[amount.Currency]
+	if !ok {
+		return model.Money{}, fmt.Errorf("currency %s is not supported", amount.Currency)
+	}
+	return model.NewMoney(amount.Float()*s.rates[to]/fromRate, to), nil
+}
@@ -9,14 +9,16 @@ import (
 )
 
 type CustomerService struct {
-	repo      repository.CustomerStore
-	currentID int
+	repo       repository.CustomerStore
+	currencies *CurrencyService
+	currentID  int
 }
 
-func NewCustomerService(repo repository.CustomerStore) *CustomerService {
+func NewCustomerService(repo repository.CustomerStore, currencies *CurrencyService) *CustomerService {
 	return &CustomerService{
-		repo:      repo,
-		currentID: 1,
+		repo:       repo,
+		currencies: currencies,
+		currentID:  1,
 	}
 }
 
@@ -30,6 +32,7 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, customerInput mode
 		Name:      customerInput.Name,
 		Email:     customerInput.Email,
 		Address:   customerInput.Address,
+		Currency:  customerInput.Currency,
 		CreatedAt: time.Now(),
 	}
 	s.currentID++
@@ -40,6 +43,14 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, customerInput mode
 	if customer.Email == "" {
 		return model.Customer{}, errors.New("customer email is mandatory")
 	}
+	if customer.Currency != "" {
+		currency, err := s.currencies.Currency(customer.Currency)
+		if err != nil {
+			return model.Customer{}, err
+		}
+		customer.Currency = currency
+	}
+	customer.StoreCredit = s.currencies.Normalize(model.Money{Currency: customer.Currency})
 
 	return s.repo.CreateCustomer(ctx, customer)
 }
@@ -62,11 +73,14 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id int, customerIn
 	}
 
 	updatedCustomer := model.Customer{
-		ID:        existingCustomer.ID,
-		Name:      customerInput.Name,
-		Email:     customerInput.Email,
-		Address:   customerInput.Address,
-		CreatedAt: existingCustomer.CreatedAt,
+		ID:            existingCustomer.ID,
+		Name:          customerInput.Name,
+		Email:         customerInput.Email,
+		Address:       customerInput.Address,
+		Currency:      customerInput.Currency,
+		StoreCredit:   existingCustomer.StoreCredit,
+		LoyaltyPoints: existingCustomer.LoyaltyPoints,
+		CreatedAt:     existingCustomer.CreatedAt,
 	}
 
 	if updatedCustomer.Name == "" {
@@ -75,6 +89,13 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id int, customerIn
 	if updatedCustomer.Email == "" {
 		return model.Customer{}, errors.New("customer email is mandatory")
 	}
+	if updatedCustomer.Currency != "" {
+		currency, err := s.currencies.Currency(updatedCustomer.Currency)
+		if err != nil {
+			return model.Customer{}, err
+		}
+		updatedCustomer.Currency = currency
+	}
 
 	return s.repo.UpdateCustomer(ctx, id, updatedCustomer)
 }
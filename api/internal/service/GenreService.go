@@ -0,0 +1,346 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type GenreService struct {
+	repo      repository.GenreStore
+	repoBook  repository.BookStore
+	mutex     sync.Mutex
+	currentID int
+}
+
+func NewGenreService(repo repository.GenreStore, repoBook repository.BookStore) *GenreService {
+	return &GenreService{
+		repo:      repo,
+		repoBook:  repoBook,
+		currentID: 1,
+	}
+}
+
+func (s *GenreService) CreateGenre(ctx context.Context, genreInput model.GenreInput) (model.Genre, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Genre{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	genre := model.Genre{ID: s.currentID}
+	s.currentID++
+	if err := s.fillGenre(ctx, 0, &genre, genreInput); err != nil {
+		return model.Genre{}, err
+	}
+	return s.repo.CreateGenre(ctx, genre)
+}
+
+func (s *GenreService) GetGenre(ctx context.Context, id int) (model.Genre, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Genre{}, err
+	}
+	return s.repo.GetGenre(ctx, id)
+}
+
+// UpdateGenre also renames the genre on its books.
+func (s *GenreService) UpdateGenre(ctx context.Context, id int, genreInput model.GenreInput) (model.Genre, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Genre{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existingGenre, err := s.repo.GetGenre(ctx, id)
+	if err != nil {
+		return model.Genre{}, err
+	}
+
+	updatedGenre := model.Genre{ID: existingGenre.ID}
+	if err := s.fillGenre(ctx, id, &updatedGenre, genreInput); err != nil {
+		return model.Genre{}, err
+	}
+
+	updated, err := s.repo.UpdateGenre(ctx, id, updatedGenre)
+	if err != nil || updated.Name == existingGenre.Name {
+		return updated, err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, map[string]string{"genre": existingGenre.Name})
+	if err != nil {
+		return updated, err
+	}
+	for _, book := range books {
+		book.Genres = renameGenre(book.Genres, existingGenre.Name, updated.Name)
+		if _, err := s.repoBook.UpdateBook(ctx, book.ID, book); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// DeleteGenre refuses to delete a genre that still has subgenres or books.
+func (s *GenreService) DeleteGenre(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	genre, err := s.repo.GetGenre(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	genres, err := s.repo.SearchGenres(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, other := range genres {
+		if other.ParentID == id {
+			return errors.New("genre still has subgenres")
+		}
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, map[string]string{"genre": genre.Name})
+	if err != nil {
+		return err
+	}
+	if len(books) > 0 {
+		return errors.New("genre still has books")
+	}
+
+	return s.repo.DeleteGenre(ctx, id)
+}
+
+func (s *GenreService) SearchGenres(ctx context.Context, params map[string]string) ([]model.Genre, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchGenres(ctx, params)
+}
+
+// ResolveGenres replaces the genres of a book by the names of the genres they
+// spell, whether by name, slug or alias, and drops the duplicates.
+func (s *GenreService) ResolveGenres(ctx context.Context, names []string) ([]string, error) {
+	genres, err := s.repo.SearchGenres(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]string, 0, len(names))
+	seen := make(map[int]bool)
+	for _, name := range names {
+		genre, ok := findGenre(genres, name)
+		if !ok {
+			return nil, fmt.Errorf("genre %s not found", name)
+		}
+		if !seen[genre.ID] {
+			seen[genre.ID] = true
+			resolved = append(resolved, genre.Name)
+		}
+	}
+	return resolved, nil
+}
+
+// GenreWithSubgenres returns the name of the genre spelled by name followed by
+// the names of all the genres below it, or false when there is no such genre.
+func (s *GenreService) GenreWithSubgenres(ctx context.Context, name string) ([]string, bool, error) {
+	genres, err := s.repo.SearchGenres(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	genre, ok := findGenre(genres, name)
+	if !ok {
+		return nil, false, nil
+	}
+
+	names := []string{genre.Name}
+	parents := []int{genre.ID}
+	for len(parents) > 0 {
+		parentID := parents[0]
+		parents = parents[1:]
+		for _, child := range genres {
+			if child.ParentID == parentID {
+				names = append(names, child.Name)
+				parents = append(parents, child.ID)
+			}
+		}
+	}
+	return names, true, nil
+}
+
+// MigrateBookGenres maps the free-form genres of the books saved before the
+// genre tree to their genres. Genres that match none are added at the top of
+// the tree, so no book loses a genre.
+func (s *GenreService) MigrateBookGenres(ctx context.Context, logger *log.Logger) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	genres, err := s.repo.SearchGenres(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		migrated := make([]string, 0, len(book.Genres))
+		seen := make(map[int]bool)
+		changed := false
+		for _, name := range book.Genres {
+			genre, ok := findGenre(genres, name)
+			if !ok {
+				logger.Printf("Adding genre %s of book %d to the genre tree\n", name, book.ID)
+				genre = model.Genre{ID: s.currentID, Name: strings.TrimSpace(name), Slug: genreSlug(name)}
+				s.currentID++
+				if genre, err = s.repo.CreateGenre(ctx, genre); err != nil {
+					return err
+				}
+				genres = append(genres, genre)
+			}
+			if genre.Name != name || seen[genre.ID] {
+				changed = true
+			}
+			if !seen[genre.ID] {
+				seen[genre.ID] = true
+				migrated = append(migrated, genre.Name)
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		logger.Printf("Mapping the genres %v of book %d to %v\n", book.Genres, book.ID, migrated)
+		book.Genres = migrated
+		if _, err := s.repoBook.UpdateBook(ctx, book.ID, book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillGenre sets the genre from the input, checking that its parent exists and
+// is not below the genre with the id, 0 for a new genre, and that its name,
+// slug and aliases spell no other genre. Callers hold the mutex.
+func (s *GenreService) fillGenre(ctx context.Context, id int, genre *model.Genre, genreInput model.GenreInput) error {
+	genre.Name = strings.TrimSpace(genreInput.Name)
+	genre.Slug = genreSlug(genreInput.Slug)
+	genre.ParentID = genreInput.ParentID
+	genre.Aliases = nil
+	if genre.Name == "" {
+		return errors.New("genre name is mandatory")
+	}
+	if genre.Slug == "" {
+		genre.Slug = genreSlug(genre.Name)
+	}
+	if genre.Slug == "" {
+		return errors.New("genre slug is invalid")
+	}
+	for _, alias := range genreInput.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			genre.Aliases = append(genre.Aliases, alias)
+		}
+	}
+
+	genres, err := s.repo.SearchGenres(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for parentID := genre.ParentID; parentID != 0; {
+		if parentID == id {
+			return errors.New("genre cannot be below itself")
+		}
+		parent, ok := genreByID(genres, parentID)
+		if !ok {
+			return errors.New("parent genre not found")
+		}
+		parentID = parent.ParentID
+	}
+
+	spellings := append([]string{genre.Name, genre.Slug}, genre.Aliases...)
+	for _, other := range genres {
+		if other.ID == id {
+			continue
+		}
+		for _, spelling := range spellings {
+			if genreSpells(other, spelling) {
+				return fmt.Errorf("%s already names the genre %s", spelling, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// findGenre returns the genre spelled by name.
+func findGenre(genres []model.Genre, name string) (model.Genre, bool) {
+	for _, genre := range genres {
+		if genreSpells(genre, name) {
+			return genre, true
+		}
+	}
+	return model.Genre{}, false
+}
+
+func genreByID(genres []model.Genre, id int) (model.Genre, bool) {
+	for _, genre := range genres {
+		if genre.ID == id {
+			return genre, true
+		}
+	}
+	return model.Genre{}, false
+}
+
+// genreSpells tells whether name spells the genre, ignoring case, spaces and
+// punctuation, so that "Sci-Fi" and "scifi" are the same alias.
+func genreSpells(genre model.Genre, name string) bool {
+	key := genreKey(name)
+	if key == "" {
+		return false
+	}
+	if genreKey(genre.Name) == key || genreKey(genre.Slug) == key {
+		return true
+	}
+	for _, alias := range genre.Aliases {
+		if genreKey(alias) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func genreKey(name string) string {
+	return strings.ReplaceAll(genreSlug(name), "-", "")
+}
+
+// genreSlug lowercases the name and joins its words with hyphens.
+func genreSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// renameGenre replaces a genre name in the genres of a book.
+func renameGenre(genres []string, oldName, newName string) []string {
+	renamed := make([]string, len(genres))
+	for i, genre := range genres {
+		if strings.EqualFold(genre, oldName) {
+			genre = newName
+		}
+		renamed[i] = genre
+	}
+	return renamed
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importRecord is a book read from an import file. Values missing from the file
+// are left empty, or nil for price and stock, and keep the value of the book
+// when it is updated.
+type importRecord struct {
+	row          int
+	err          error
+	book         model.BookInput
+	price        *model.Money
+	stock        *int
+	contributors []importContributor
+	// subjects are genres only kept when the store knows them, as publishers
+	// describe their books with their own headings
+	subjects []string
+}
+
+type importContributor struct {
+	author model.AuthorInput
+	role   string
+}
+
+// csvColumns are the columns a CSV import may have; only title is mandatory.
+// Lists are separated by semicolons and prices are in major units, e.g. 12.99.
+var csvColumns = map[string]bool{
+	"title":        true,
+	"isbn":         true,
+	"authors":      true,
+	"editors":      true,
+	"translators":  true,
+	"illustrators": true,
+	"genres":       true,
+	"price":        true,
+	"currency":     true,
+	"publisher":    true,
+	"language":     true,
+	"page_count":   true,
+	"format":       true,
+	"edition":      true,
+	"published_at": true,
+	"stock":        true,
+	"weight":       true,
+	"tax_category": true,
+}
+
+var csvContributorColumns = []struct {
+	column string
+	role   string
+}{
+	{"authors", model.ContributorAuthor},
+	{"editors", model.ContributorEditor},
+	{"translators", model.ContributorTranslator},
+	{"illustrators", model.ContributorIllustrator},
+}
+
+// readCSV reads a CSV file with a header row. A record that cannot be read
+// fails on its own; a bad header fails the whole file.
+func readCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header could not be read: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !csvColumns[name] {
+			return nil, fmt.Errorf("unknown csv column %s", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("csv column title is mandatory")
+	}
+
+	records := []importRecord{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			records = append(records, importRecord{row: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, csvRecord(line, columns, fields))
+	}
+	return records, nil
+}
+
+func csvRecord(line int, columns map[string]int, fields []string) importRecord {
+	record := importRecord{row: line}
+	value := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	record.book = model.BookInput{
+		Title:       value("title"),
+		ISBN:        value("isbn"),
+		Genres:      splitList(value("genres")),
+		Publisher:   value("publisher"),
+		Language:    value("language"),
+		Format:      value("format"),
+		Edition:     value("edition"),
+		TaxCategory: value("tax_category"),
+	}
+	for _, column := range csvContributorColumns {
+		for _, name := range splitList(value(column.column)) {
+			author, err := parseAuthorName(name)
+			if err != nil {
+				record.err = err
+				return record
+			}
+			record.contributors = append(record.contributors, importContributor{author: author, role: column.role})
+		}
+	}
+
+	if price := value("price"); price != "" {
+		amount, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			record.err = fmt.Errorf("price %s is not a number", price)
+			return record
+		}
+		money := model.NewMoney(amount, strings.ToUpper(value("currency")))
+		record.price = &money
+	}
+	if stock := value("stock"); stock != "" {
+		quantity, err := strconv.Atoi(stock)
+		if err != nil {
+			record.err = fmt.Errorf("stock %s is not a number", stock)
+			return record
+		}
+		record.stock = &quantity
+	}
+	for _, number := range []struct {
+		column string
+		target *int
+	}{{"page_count", &record.book.PageCount}, {"weight", &record.book.Weight}} {
+		if text := value(number.column); text != "" {
+			parsed, err := strconv.Atoi(text)
+			if err != nil {
+				record.err = fmt.Errorf("%s %s is not a number", number.column, text)
+				return record
+			}
+			*number.target = parsed
+		}
+	}
+	if publishedAt := value("published_at"); publishedAt != "" {
+		date, err := parseImportDate(publishedAt)
+		if err != nil {
+			record.err = err
+			return record
+		}
+		record.book.PublishedAt = &date
+	}
+	return record
+}
+
+// onixMessage is the part of an ONIX for Books 3.0 message, with reference
+// tags, that the import reads.
+type onixMessage struct {
+	XMLName  xml.Name      `xml:"ONIXMessage"`
+	Release  string        `xml:"release,attr"`
+	Products []onixProduct `xml:"Product"`
+}
+
+type onixProduct struct {
+	Identifiers []struct {
+		Type  string `xml:"ProductIDType"`
+		Value string `xml:"IDValue"`
+	} `xml:"ProductIdentifier"`
+	Form   string `xml:"DescriptiveDetail>ProductForm"`
+	Titles []struct {
+		Type     string `xml:"TitleType"`
+		Elements []struct {
+			Level         string `xml:"TitleElementLevel"`
+			Text          string `xml:"TitleText"`
+			Prefix        string `xml:"TitlePrefix"`
+			WithoutPrefix string `xml:"TitleWithoutPrefix"`
+		} `xml:"TitleElement"`
+	} `xml:"DescriptiveDetail>TitleDetail"`
+	Contributors []struct {
+		SequenceNumber     int      `xml:"SequenceNumber"`
+		Roles              []string `xml:"ContributorRole"`
+		PersonName         string   `xml:"PersonName"`
+		PersonNameInverted string   `xml:"PersonNameInverted"`
+		NamesBeforeKey     string   `xml:"NamesBeforeKey"`
+		KeyNames           string   `xml:"KeyNames"`
+		BiographicalNote   string   `xml:"BiographicalNote"`
+	} `xml:"DescriptiveDetail>Contributor"`
+	EditionNumber string `xml:"DescriptiveDetail>EditionNumber"`
+	Languages     []struct {
+		Role string `xml:"LanguageRole"`
+		Code string `xml:"LanguageCode"`
+	} `xml:"DescriptiveDetail>Language"`
+	Extents []struct {
+		Type  string `xml:"ExtentType"`
+		Value string `xml:"ExtentValue"`
+	} `xml:"DescriptiveDetail>Extent"`
+	Subjects []struct {
+		Heading string `xml:"SubjectHeadingText"`
+	} `xml:"DescriptiveDetail>Subject"`
+	Publishers []struct {
+		Role string `xml:"PublishingRole"`
+		Name string `xml:"PublisherName"`
+	} `xml:"PublishingDetail>Publisher"`
+	PublishingDates []struct {
+		Role string `xml:"PublishingDateRole"`
+		Date string `xml:"Date"`
+	} `xml:"PublishingDetail>PublishingDate"`
+	SupplyDetails []struct {
+		OnHand []string `xml:"Stock>OnHand"`
+		Prices []struct {
+			Type     string `xml:"PriceType"`
+			Amount   string `xml:"PriceAmount"`
+			Currency string `xml:"CurrencyCode"`
+		} `xml:"Price"`
+	} `xml:"ProductSupply>SupplyDetail"`
+}
+
+// onixRoles maps the ONIX contributor role codes to the roles of the store;
+// contributors in other roles are left out.
+var onixRoles = map[string]string{
+	"A01": model.ContributorAuthor,
+	"B01": model.ContributorEditor,
+	"B06": model.ContributorTranslator,
+	"A12": model.ContributorIllustrator,
+}
+
+// readONIX reads the products of an ONIX 3.0 message. Short tags and older
+// releases are refused.
+func readONIX(r io.Reader) ([]importRecord, error) {
+	var message onixMessage
+	if err := xml.NewDecoder(r).Decode(&message); err != nil {
+		return nil, fmt.Errorf("onix message could not be read: %w", err)
+	}
+	if !strings.HasPrefix(message.Release, "3.") {
+		return nil, errors.New("only onix 3.0 messages are supported")
+	}
+
+	records := make([]importRecord, 0, len(message.Products))
+	for i, product := range message.Products {
+		records = append(records, onixRecord(i+1, product))
+	}
+	return records, nil
+}
+
+func onixRecord(position int, product onixProduct) importRecord {
+	record := importRecord{row: position}
+
+	for _, identifier := range product.Identifiers {
+		// 15 is an ISBN-13, 02 an ISBN-10 and 03 a GTIN-13, which is an ISBN
+		// for books
+		switch identifier.Type {
+		case "15", "02", "03":
+			if record.book.ISBN == "" || identifier.Type == "15" {
+				record.book.ISBN = identifier.Value
+			}
+		}
+	}
+
+	for _, title := range product.Titles {
+		if title.Type != "01" {
+			continue
+		}
+		for _, element := range title.Elements {
+			if element.Level != "01" {
+				continue
+			}
+			record.book.Title = strings.TrimSpace(element.Text)
+			if record.book.Title == "" {
+				record.book.Title = strings.TrimSpace(element.Prefix + " " + element.WithoutPrefix)
+			}
+		}
+	}
+
+	switch {
+	case product.Form == "BB":
+		record.book.Format = model.BookFormatHardcover
+	case product.Form == "BC":
+		record.book.Format = model.BookFormatPaperback
+	case strings.HasPrefix(product.Form, "E"):
+		record.book.Format = model.BookFormatEbook
+	}
+	record.book.Edition = product.EditionNumber
+	for _, language := range product.Languages {
+		if language.Role == "01" {
+			record.book.Language = language.Code
+			break
+		}
+	}
+	for _, extent := range product.Extents {
+		// 00 is the main content page count, 07 the total numbered pages
+		if extent.Type == "00" || (extent.Type == "07" && record.book.PageCount == 0) {
+			pages, err := strconv.Atoi(strings.TrimSpace(extent.Value))
+			if err != nil {
+				record.err = fmt.Errorf("page count %s is not a number", extent.Value)
+				return record
+			}
+			record.book.PageCount = pages
+		}
+	}
+	for _, subject := range product.Subjects {
+		if heading := strings.TrimSpace(subject.Heading); heading != "" {
+			record.subjects = append(record.subjects, heading)
+		}
+	}
+	for _, publisher := range product.Publishers {
+		if publisher.Role == "01" || record.book.Publisher == "" {
+			record.book.Publisher = strings.TrimSpace(publisher.Name)
+		}
+	}
+	for _, date := range product.PublishingDates {
+		if date.Role != "01" {
+			continue
+		}
+		publishedAt, err := parseImportDate(date.Date)
+		if err != nil {
+			record.err = err
+			return record
+		}
+		record.book.PublishedAt = &publishedAt
+	}
+
+	contributors := product.Contributors
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return contributors[i].SequenceNumber < contributors[j].SequenceNumber
+	})
+	for _, contributor := range contributors {
+		role := ""
+		for _, code := range contributor.Roles {
+			if role = onixRoles[code]; role != "" {
+				break
+			}
+		}
+		if role == "" {
+			continue
+		}
+		var author model.AuthorInput
+		var err error
+		switch {
+		case contributor.KeyNames != "":
+			author = model.AuthorInput{FirstName: strings.TrimSpace(contributor.NamesBeforeKey), LastName: strings.TrimSpace(contributor.KeyNames)}
+			if author.FirstName == "" {
+				err = fmt.Errorf("author name %s must have a first and a last name", contributor.KeyNames)
+			}
+		case contributor.PersonNameInverted != "":
+			author, err = parseAuthorName(contributor.PersonNameInverted)
+		default:
+			author, err = parseAuthorName(contributor.PersonName)
+		}
+		if err != nil {
+			record.err = err
+			return record
+		}
+		author.Bio = strings.TrimSpace(contributor.BiographicalNote)
+		record.contributors = append(record.contributors, importContributor{author: author, role: role})
+	}
+
+	for _, supply := range product.SupplyDetails {
+		for _, price := range supply.Prices {
+			// 01 and 02 are the recommended retail prices without and with tax
+			if price.Type != "" && price.Type != "01" && price.Type != "02" {
+				continue
+			}
+			amount, err := strconv.ParseFloat(strings.TrimSpace(price.Amount), 64)
+			if err != nil {
+				record.err = fmt.Errorf("price %s is not a number", price.Amount)
+				return record
+			}
+			money := model.NewMoney(amount, strings.ToUpper(price.Currency))
+			if record.price == nil {
+				record.price = &money
+			} else if money.Currency != record.price.Currency && !hasPriceIn(record.book.Prices, money.Currency) {
+				record.book.Prices = append(record.book.Prices, money)
+			}
+		}
+		for _, onHand := range supply.OnHand {
+			quantity, err := strconv.Atoi(strings.TrimSpace(onHand))
+			if err != nil {
+				record.err = fmt.Errorf("stock %s is not a number", onHand)
+				return record
+			}
+			if record.stock == nil {
+				record.stock = new(int)
+			}
+			*record.stock += quantity
+		}
+	}
+	return record
+}
+
+// parseAuthorName splits "First Last" or "Last, First" into the names of an
+// author, who needs both.
+func parseAuthorName(name string) (model.AuthorInput, error) {
+	name = strings.TrimSpace(name)
+	if last, first, ok := strings.Cut(name, ","); ok {
+		author := model.AuthorInput{FirstName: strings.TrimSpace(first), LastName: strings.TrimSpace(last)}
+		if author.FirstName != "" && author.LastName != "" {
+			return author, nil
+		}
+	} else if i := strings.LastIndex(name, " "); i > 0 {
+		return model.AuthorInput{FirstName: strings.TrimSpace(name[:i]), LastName: name[i+1:]}, nil
+	}
+	return model.AuthorInput{}, fmt.Errorf("author name %s must have a first and a last name", name)
+}
+
+// parseImportDate reads the dates of import files: 2006-01-02, the 20060102 of
+// ONIX, or a full RFC 3339 time.
+func parseImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "20060102", time.RFC3339} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %s is not valid", value)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func hasPriceIn(prices []model.Money, currency string) bool {
+	for _, price := range prices {
+		if price.Currency == currency {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MaxImportSize is the largest file a bulk import accepts.
+const MaxImportSize = 20 << 20
+
+// ImportService loads books and their authors in bulk from CSV files and ONIX
+// 3.0 messages. A book is matched on its ISBN, then on its title and first
+// author, and updated, or created when nothing matches; authors are matched on
+// their name. Every book is checked with the rules of BookService before any
+// author or book is saved, and the authors a row created or updated are put
+// back when its book still fails, so a failed row changes nothing.
+type ImportService struct {
+	books         *BookService
+	authors       *AuthorService
+	genres        *GenreService
+	repoBook      repository.BookStore
+	repoAuthor    repository.AuthorStore
+	repoPublisher repository.PublisherStore
+	mutex         sync.Mutex
+}
+
+func NewImportService(books *BookService, authors *AuthorService, genres *GenreService, repoBook repository.BookStore, repoAuthor repository.AuthorStore, repoPublisher repository.PublisherStore) *ImportService {
+	return &ImportService{
+		books:         books,
+		authors:       authors,
+		genres:        genres,
+		repoBook:      repoBook,
+		repoAuthor:    repoAuthor,
+		repoPublisher: repoPublisher,
+	}
+}
+
+// importPlan remembers the books and authors a dry run would have created, so
+// the rows after them are reported as the real import would.
+type importPlan struct {
+	books   map[string]bool
+	authors map[string]bool
+}
+
+// ImportBooks imports the books of a file in the format, csv or onix, and
+// reports on every row. Rows fail on their own; an error is only returned when
+// the file cannot be read.
+func (s *ImportService) ImportBooks(ctx context.Context, format string, r io.Reader, dryRun bool) (model.ImportReport, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ImportReport{}, err
+	}
+
+	var records []importRecord
+	var err error
+	switch strings.ToLower(format) {
+	case model.ImportFormatCSV:
+		records, err = readCSV(r)
+	case model.ImportFormatONIX:
+		records, err = readONIX(r)
+	default:
+		return model.ImportReport{}, fmt.Errorf("import format must be %s or %s", model.ImportFormatCSV, model.ImportFormatONIX)
+	}
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	report := model.ImportReport{
+		Format: strings.ToLower(format),
+		DryRun: dryRun,
+		Rows:   make([]model.ImportRow, 0, len(records)),
+	}
+	plan := importPlan{books: make(map[string]bool), authors: make(map[string]bool)}
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		row := s.importRecord(ctx, record, dryRun, plan)
+		switch row.Status {
+		case model.ImportCreated:
+			report.Created++
+		case model.ImportUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		for _, author := range row.Authors {
+			switch author.Status {
+			case model.ImportCreated:
+				report.AuthorsCreated++
+			case model.ImportUpdated:
+				report.AuthorsUpdated++
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// importRecord creates or updates the book of a record with its authors, or
+// only checks it in a dry run. Callers hold the mutex.
+func (s *ImportService) importRecord(ctx context.Context, record importRecord, dryRun bool, plan importPlan) model.ImportRow {
+	row := model.ImportRow{Row: record.row, Title: record.book.Title}
+	fail := func(err error) model.ImportRow {
+		row.Status = model.ImportFailed
+		row.Error = err.Error()
+		return row
+	}
+	if record.err != nil {
+		return fail(record.err)
+	}
+	if record.book.ISBN != "" {
+		isbn, err := normalizeISBN(record.book.ISBN)
+		if err != nil {
+			return fail(err)
+		}
+		record.book.ISBN = isbn
+		row.ISBN = isbn
+	}
+
+	// authors not found yet are created once the book is known to be valid;
+	// until then they stand in with negative IDs
+	authors := make([]model.ImportAuthor, len(record.contributors))
+	ids := make([]int, len(record.contributors))
+	standIns := make(map[string]int)
+	newAuthors := make(map[int]bool)
+	bios := make(map[int]string)
+	for i, contributor := range record.contributors {
+		key := authorKey(contributor.author)
+		authors[i] = model.ImportAuthor{
+			Name:   contributor.author.FirstName + " " + contributor.author.LastName,
+			Role:   contributor.role,
+			Status: model.ImportCreated,
+		}
+		author, found, err := s.findAuthor(ctx, contributor.author)
+		if err != nil {
+			return fail(err)
+		}
+		switch {
+		case found:
+			authors[i].AuthorID = author.ID
+			authors[i].Status = model.ImportUnchanged
+			if bio := contributor.author.Bio; bio != "" && bio != author.Bio && bios[author.ID] == "" {
+				authors[i].Status = model.ImportUpdated
+				bios[author.ID] = bio
+			}
+		case plan.authors[key] || repeatsAuthor(record.contributors[:i], key):
+			authors[i].Status = model.ImportUnchanged
+		}
+
+		ids[i] = authors[i].AuthorID
+		if ids[i] == 0 {
+			if _, ok := standIns[key]; !ok {
+				standIns[key] = -len(standIns) - 1
+			}
+			ids[i] = standIns[key]
+			newAuthors[ids[i]] = true
+		}
+	}
+
+	existing, found, err := s.matchBook(ctx, record, authors)
+	if err != nil {
+		return fail(err)
+	}
+	input := model.BookInput{}
+	if found {
+		input = bookInputFrom(existing)
+		row.BookID = existing.ID
+	}
+	if err := s.applyRecord(ctx, &input, record); err != nil {
+		return fail(err)
+	}
+	if len(record.contributors) > 0 {
+		input.AuthorID = 0
+		input.Contributors = importContributors(authors, ids)
+	}
+	if err := s.books.validateBook(ctx, row.BookID, input, newAuthors); err != nil {
+		return fail(err)
+	}
+
+	key := bookKey(record, authors)
+	status := model.ImportCreated
+	if found || (key != "" && plan.books[key]) || (row.ISBN != "" && plan.books[row.ISBN]) {
+		status = model.ImportUpdated
+	}
+	if dryRun {
+		for _, author := range authors {
+			if author.Status == model.ImportCreated {
+				plan.authors[strings.ToLower(author.Name)] = true
+			}
+		}
+		if key != "" {
+			plan.books[key] = true
+		}
+		if row.ISBN != "" {
+			plan.books[row.ISBN] = true
+		}
+		row.Status = status
+		row.Authors = authors
+		return row
+	}
+
+	var changes authorChanges
+	undo := func(err error) model.ImportRow {
+		if undoErr := s.undoAuthors(ctx, changes); undoErr != nil {
+			err = fmt.Errorf("%v; the authors of the row could not be put back: %v", err, undoErr)
+		}
+		return fail(err)
+	}
+	for i, contributor := range record.contributors {
+		switch authors[i].Status {
+		case model.ImportCreated:
+			created, err := s.authors.CreateAuthor(ctx, contributor.author)
+			if err != nil {
+				return undo(err)
+			}
+			changes.created = append(changes.created, created.ID)
+			authors[i].AuthorID = created.ID
+			ids[i] = created.ID
+		case model.ImportUnchanged:
+			if authors[i].AuthorID == 0 {
+				author, _, err := s.findAuthor(ctx, contributor.author)
+				if err != nil {
+					return undo(err)
+				}
+				authors[i].AuthorID = author.ID
+				ids[i] = author.ID
+			}
+		case model.ImportUpdated:
+			author, err := s.authors.GetAuthor(ctx, authors[i].AuthorID)
+			if err != nil {
+				return undo(err)
+			}
+			_, err = s.authors.UpdateAuthor(ctx, author.ID, model.AuthorInput{
+				FirstName: author.FirstName,
+				LastName:  author.LastName,
+				Bio:       bios[author.ID],
+			})
+			if err != nil {
+				return undo(err)
+			}
+			changes.updated = append(changes.updated, author)
+		}
+	}
+	if len(record.contributors) > 0 {
+		input.Contributors = importContributors(authors, ids)
+	}
+
+	var book model.Book
+	if found {
+		book, err = s.books.UpdateBook(ctx, existing.ID, input)
+	} else {
+		book, err = s.books.CreateBook(ctx, input)
+	}
+	// a book returned with an error was saved, only what follows failed
+	if book.ID == 0 {
+		return undo(err)
+	}
+	row.Authors = authors
+	row.BookID = book.ID
+	if err != nil {
+		return fail(err)
+	}
+	row.Status = status
+	return row
+}
+
+// authorChanges are the authors a row created, and those it updated as they
+// were before.
+type authorChanges struct {
+	created []int
+	updated []model.Author
+}
+
+// undoAuthors deletes the authors a failed row created and gives those it
+// updated their biography back. Callers hold the mutex.
+func (s *ImportService) undoAuthors(ctx context.Context, changes authorChanges) error {
+	for _, id := range changes.created {
+		if err := s.repoAuthor.DeleteAuthor(ctx, id); err != nil {
+			return err
+		}
+	}
+	for _, author := range changes.updated {
+		if _, err := s.repoAuthor.UpdateAuthor(ctx, author.ID, author); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchBook finds the book of a record by its ISBN, or by its title and first
+// author.
+func (s *ImportService) matchBook(ctx context.Context, record importRecord, authors []model.ImportAuthor) (model.Book, bool, error) {
+	if record.book.ISBN != "" {
+		books, err := s.repoBook.SearchBooks(ctx, map[string]string{"isbn": record.book.ISBN})
+		if err != nil || len(books) > 0 {
+			return firstBook(books), len(books) > 0, err
+		}
+	}
+
+	authorID := 0
+	for _, author := range authors {
+		if author.Role == model.ContributorAuthor {
+			authorID = author.AuthorID
+			break
+		}
+	}
+	if record.book.Title == "" || authorID == 0 {
+		return model.Book{}, false, nil
+	}
+	books, err := s.repoBook.SearchBooks(ctx, map[string]string{
+		"title":  record.book.Title,
+		"author": strconv.Itoa(authorID),
+	})
+	return firstBook(books), len(books) > 0, err
+}
+
+// applyRecord sets the values of the record on the input of a new or matched
+// book. Subjects the genres of the store do not know are left out, and a
+// publisher name is linked to the publisher of that name when there is one.
+func (s *ImportService) applyRecord(ctx context.Context, input *model.BookInput, record importRecord) error {
+	book := record.book
+	if book.Title != "" {
+		input.Title = book.Title
+	}
+	if book.ISBN != "" {
+		input.ISBN = book.ISBN
+	}
+	if record.price != nil {
+		input.Price = *record.price
+	}
+	if len(book.Prices) > 0 {
+		input.Prices = book.Prices
+	}
+	if record.stock != nil {
+		input.Stock = *record.stock
+	}
+	if book.PublishedAt != nil {
+		input.PublishedAt = book.PublishedAt
+	}
+	if book.Language != "" {
+		input.Language = book.Language
+	}
+	if book.PageCount != 0 {
+		input.PageCount = book.PageCount
+	}
+	if book.Format != "" {
+		input.Format = book.Format
+	}
+	if book.Edition != "" {
+		input.Edition = book.Edition
+	}
+	if book.Weight != 0 {
+		input.Weight = book.Weight
+	}
+	if book.TaxCategory != "" {
+		input.TaxCategory = book.TaxCategory
+	}
+
+	genres := book.Genres
+	for _, subject := range record.subjects {
+		if resolved, err := s.genres.ResolveGenres(ctx, []string{subject}); err == nil {
+			genres = append(genres, resolved...)
+		}
+	}
+	if len(genres) > 0 {
+		input.Genres = genres
+	}
+
+	if book.Publisher != "" {
+		input.Publisher = book.Publisher
+		input.PublisherID = 0
+		publishers, err := s.repoPublisher.SearchPublishers(ctx, map[string]string{"name": book.Publisher})
+		if err != nil {
+			return err
+		}
+		if len(publishers) > 0 {
+			input.PublisherID = publishers[0].ID
+		}
+	}
+	return nil
+}
+
+func (s *ImportService) findAuthor(ctx context.Context, name model.AuthorInput) (model.Author, bool, error) {
+	authors, err := s.repoAuthor.SearchAuthors(ctx, map[string]string{
+		"firstName": name.FirstName,
+		"lastName":  name.LastName,
+	})
+	if err != nil || len(authors) == 0 {
+		return model.Author{}, false, err
+	}
+	return authors[0], true, nil
+}
+
+// importContributors lists the authors of a record, with their IDs, as
+// contributors in the order of the file.
+func importContributors(authors []model.ImportAuthor, ids []int) []model.Contributor {
+	contributors := make([]model.Contributor, len(authors))
+	for i, author := range authors {
+		contributors[i] = model.Contributor{AuthorID: ids[i], Role: author.Role, Position: i + 1}
+	}
+	return contributors
+}
+
+func bookInputFrom(book model.Book) model.BookInput {
+	return model.BookInput{
+		Title:          book.Title,
+		Contributors:   book.Contributors,
+		Genres:         book.Genres,
+		Price:          book.Price,
+		Prices:         book.Prices,
+		SalePrices:     book.SalePrices,
+		TaxCategory:    book.TaxCategory,
+		Weight:         book.Weight,
+		ISBN:           book.ISBN,
+		PublisherID:    book.PublisherID,
+		Publisher:      book.Publisher,
+		Language:       book.Language,
+		PageCount:      book.PageCount,
+		Format:         book.Format,
+		Edition:        book.Edition,
+		SeriesID:       book.SeriesID,
+		SeriesPosition: book.SeriesPosition,
+		Stock:          book.Stock,
+		PublishedAt:    &book.PublishedAt,
+	}
+}
+
+// bookKey identifies a book of a dry run by its title and first author, the
+// way matchBook does; books without an author have none.
+func bookKey(record importRecord, authors []model.ImportAuthor) string {
+	for _, author := range authors {
+		if author.Role == model.ContributorAuthor {
+			return strings.ToLower(record.book.Title + "|" + author.Name)
+		}
+	}
+	return ""
+}
+
+func authorKey(author model.AuthorInput) string {
+	return strings.ToLower(author.FirstName + " " + author.LastName)
+}
+
+func repeatsAuthor(contributors []importContributor, key string) bool {
+	for _, contributor := range contributors {
+		if authorKey(contributor.author) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func firstBook(books []model.Book) model.Book {
+	if len(books) == 0 {
+		return model.Book{}
+	}
+	return books[0]
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	InvoiceFormatHTML = "html"
+	InvoiceFormatPDF  = "pdf"
+)
+
+var invoiceHTMLTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"title":   invoiceTitle,
+	"date":    func(invoice model.Invoice) string { return invoice.IssuedAt.Format("2006-01-02") },
+	"address": addressLines,
+	"rate":    formatTaxRate,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{title .}} {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 14px; margin: 40px; }
+table { border-collapse: collapse; width: 100%; margin-top: 24px; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.amount { text-align: right; white-space: nowrap; }
+.totals td { border: none; }
+</style>
+</head>
+<body>
+<h1>{{title .}}</h1>
+<p>
+Number: {{.Number}}<br>
+Date: {{date .}}<br>
+Order: #{{.OrderID}}{{if .InvoiceNumber}}<br>
+Credits invoice: {{.InvoiceNumber}}{{end}}
+</p>
+<h2>Bill to</h2>
+<p>
+{{.Customer.Name}}<br>
+{{.Customer.Email}}{{range address .Customer.Address}}<br>
+{{.}}{{end}}
+</p>
+<table>
+<thead>
+<tr><th>Description</th><th class="amount">Qty</th><th class="amount">Unit price</th><th class="amount">Discount</th><th class="amount">Net</th><th class="amount">Tax rate</th><th class="amount">Tax</th></tr>
+</thead>
+<tbody>
+{{range .Lines}}<tr><td>{{.Description}}</td><td class="amount">{{.Quantity}}</td><td class="amount">{{.UnitPrice}}</td><td class="amount">{{.Discount}}</td><td class="amount">{{.LineTotal}}</td><td class="amount">{{rate .TaxRate}}</td><td class="amount">{{.Tax}}</td></tr>
+{{end}}</tbody>
+</table>
+<table class="totals">
+<tr><td>Subtotal</td><td class="amount">{{.Subtotal}}</td></tr>
+<tr><td>Discounts</td><td class="amount">{{.DiscountTotal}}</td></tr>
+<tr><td>Shipping</td><td class="amount">{{.Shipping}}</td></tr>
+<tr><td>Tax</td><td class="amount">{{.Tax}}</td></tr>
+<tr><th>Total</th><th class="amount">{{.Total}}</th></tr>
+</table>
+</body>
+</html>
+`))
+
+func renderInvoiceHTML(invoice model.Invoice) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := invoiceHTMLTemplate.Execute(&buf, invoice); err != nil {
+		return nil, fmt.Errorf("failed to render invoice %s: %v", invoice.Number, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfLine is a line of text of a PDF document, in one of the fonts of
+// pdfFonts.
+type pdfLine struct {
+	font string
+	size float64
+	text string
+}
+
+var pdfFonts = []struct {
+	key  string
+	name string
+}{
+	{"F1", "Helvetica"},
+	{"F2", "Helvetica-Bold"},
+	{"F3", "Courier"},
+	{"F4", "Courier-Bold"},
+}
+
+// renderInvoicePDF lays the invoice out as lines of text on A4 pages, using the
+// standard PDF fonts so that the document needs no embedded font. The lines
+// are set in a monospaced font to align the columns.
+func renderInvoicePDF(invoice model.Invoice) []byte {
+	lines := []pdfLine{
+		{"F2", 18, strings.ToUpper(invoiceTitle(invoice))},
+		{"F1", 10, ""},
+		{"F1", 10, "Number: " + invoice.Number},
+		{"F1", 10, "Date: " + invoice.IssuedAt.Format("2006-01-02")},
+		{"F1", 10, fmt.Sprintf("Order: #%d", invoice.OrderID)},
+	}
+	if invoice.InvoiceNumber != "" {
+		lines = append(lines, pdfLine{"F1", 10, "Credits invoice: " + invoice.InvoiceNumber})
+	}
+	lines = append(lines,
+		pdfLine{"F1", 10, ""},
+		pdfLine{"F2", 11, "Bill to"},
+		pdfLine{"F1", 10, invoice.Customer.Name},
+		pdfLine{"F1", 10, invoice.Customer.Email},
+	)
+	for _, line := range addressLines(invoice.Customer.Address) {
+		lines = append(lines, pdfLine{"F1", 10, line})
+	}
+
+	row := "%-30.30s %4s %14s %12s %14s %6s %12s"
+	lines = append(lines,
+		pdfLine{"F1", 10, ""},
+		pdfLine{"F4", 8, fmt.Sprintf(row, "Description", "Qty", "Unit price", "Discount", "Net", "Rate", "Tax")},
+	)
+	for _, line := range invoice.Lines {
+		lines = append(lines, pdfLine{"F3", 8, fmt.Sprintf(row,
+			line.Description, strconv.Itoa(line.Quantity), line.UnitPrice, line.Discount, line.LineTotal, formatTaxRate(line.TaxRate), line.Tax)})
+	}
+
+	total := "%83s %14s"
+	lines = append(lines,
+		pdfLine{"F3", 8, ""},
+		pdfLine{"F3", 8, fmt.Sprintf(total, "Subtotal", invoice.Subtotal)},
+		pdfLine{"F3", 8, fmt.Sprintf(total, "Discounts", invoice.DiscountTotal)},
+		pdfLine{"F3", 8, fmt.Sprintf(total, "Shipping", invoice.Shipping)},
+		pdfLine{"F3", 8, fmt.Sprintf(total, "Tax", invoice.Tax)},
+		pdfLine{"F4", 8, fmt.Sprintf(total, "Total", invoice.Total)},
+	)
+
+	return renderPDF(lines)
+}
+
+// renderPDF writes the lines on as many A4 pages as they need.
+func renderPDF(lines []pdfLine) []byte {
+	const (
+		pageWidth  = 595
+		pageHeight = 842
+		margin     = 50
+	)
+
+	var pages []string
+	var content strings.Builder
+	y := float64(pageHeight - margin)
+	for _, line := range lines {
+		leading := line.size * 1.4
+		if y-leading < margin {
+			pages = append(pages, content.String())
+			content.Reset()
+			y = pageHeight - margin
+		}
+		y -= leading
+		if line.text != "" {
+			fmt.Fprintf(&content, "BT /%s %g Tf %d %.2f Td (%s) Tj ET\n", line.font, line.size, margin, y, pdfString(line.text))
+		}
+	}
+	pages = append(pages, content.String())
+
+	// objects 1 and 2 are the catalog and the page tree, followed by the
+	// fonts, then a page and its content stream for every page
+	firstPage := 3 + len(pdfFonts)
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+	)
+	fonts := make([]string, len(pdfFonts))
+	for i, font := range pdfFonts {
+		objects = append(objects, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.name))
+		fonts[i] = fmt.Sprintf("/%s %d 0 R", font.key, 3+i)
+	}
+	for i, page := range pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, strings.Join(fonts, " "), firstPage+2*i+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page), page),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes text for a PDF string in WinAnsiEncoding. Characters the
+// encoding lacks are replaced by a question mark.
+func pdfString(text string) string {
+	var buf strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '€':
+			buf.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7f:
+			buf.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&buf, `\%03o`, r)
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	return buf.String()
+}
+
+func invoiceTitle(invoice model.Invoice) string {
+	if invoice.Type == model.InvoiceTypeCreditNote {
+		return "Credit note"
+	}
+	return "Invoice"
+}
+
+func addressLines(address model.Address) []string {
+	lines := []string{}
+	if address.Street != "" {
+		lines = append(lines, address.Street)
+	}
+	city := strings.Join(strings.Fields(address.City+" "+address.State+" "+address.PostalCode), " ")
+	if city != "" {
+		lines = append(lines, city)
+	}
+	if address.Country != "" {
+		lines = append(lines, address.Country)
+	}
+	return lines
+}
+
+// formatTaxRate formats a rate such as 0.055 as 5.5%. Untaxed lines, and credit
+// notes for orders taxed at mixed rates, show no rate.
+func formatTaxRate(rate float64) string {
+	if rate == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(math.Round(rate*10000)/100, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// InvoiceService issues the invoices of paid orders and the credit notes of
+// their refunds. Invoices and credit notes each have their own sequence of
+// numbers, without gaps, and their HTML and PDF documents are written once to
+// the invoices directory.
+type InvoiceService struct {
+	repo         repository.InvoiceStore
+	repoOrder    repository.OrderStore
+	repoCustomer repository.CustomerStore
+	repoBook     repository.BookStore
+	repoRefund   repository.RefundStore
+	currencies   *CurrencyService
+	dir          string
+	mutex        sync.Mutex
+}
+
+func NewInvoiceService(repo repository.InvoiceStore, repoOrder repository.OrderStore, repoCustomer repository.CustomerStore, repoBook repository.BookStore, repoRefund repository.RefundStore, currencies *CurrencyService, dir string) *InvoiceService {
+	return &InvoiceService{
+		repo:         repo,
+		repoOrder:    repoOrder,
+		repoCustomer: repoCustomer,
+		repoBook:     repoBook,
+		repoRefund:   repoRefund,
+		currencies:   currencies,
+		dir:          dir,
+	}
+}
+
+// IssueInvoice issues the invoice of a paid order, or returns it if the order
+// is already invoiced.
+func (s *InvoiceService) IssueInvoice(ctx context.Context, orderID int) (model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Invoice{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.issueInvoice(ctx, orderID)
+}
+
+// IssueCreditNote issues the credit note of a refund, against the invoice of
+// the refunded order, or returns it if the refund is already credited.
+func (s *InvoiceService) IssueCreditNote(ctx context.Context, refund model.Refund) (model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Invoice{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.issueCreditNote(ctx, refund)
+}
+
+// GetOrderInvoice returns the invoice of an order. Orders paid before invoices
+// were issued are invoiced on their first request.
+func (s *InvoiceService) GetOrderInvoice(ctx context.Context, orderID int) (model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Invoice{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.issueInvoice(ctx, orderID)
+}
+
+// GetOrderCreditNotes returns the credit notes of an order, issuing those of
+// refunds that are missing one.
+func (s *InvoiceService) GetOrderCreditNotes(ctx context.Context, orderID int) ([]model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoOrder.GetOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	refunds, err := s.repoRefund.SearchRefunds(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+	if err != nil {
+		return nil, err
+	}
+	creditNotes := []model.Invoice{}
+	for _, refund := range refunds {
+		creditNote, err := s.issueCreditNote(ctx, refund)
+		if err != nil {
+			return nil, err
+		}
+		creditNotes = append(creditNotes, creditNote)
+	}
+	return creditNotes, nil
+}
+
+func (s *InvoiceService) GetInvoice(ctx context.Context, id int) (model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Invoice{}, err
+	}
+	return s.repo.GetInvoice(ctx, id)
+}
+
+func (s *InvoiceService) SearchInvoices(ctx context.Context, params map[string]string) ([]model.Invoice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchInvoices(ctx, params)
+}
+
+// Document returns the HTML or PDF document of an invoice. Documents are
+// rendered from the invoice, which never changes, and stored on first use, so
+// that the same bytes are served every time.
+func (s *InvoiceService) Document(ctx context.Context, invoice model.Invoice, format string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if format != InvoiceFormatHTML && format != InvoiceFormatPDF {
+		return nil, fmt.Errorf("invoice format %s is not supported", format)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := os.ReadFile(s.documentPath(invoice, format))
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read invoice %s: %v", invoice.Number, err)
+	}
+
+	if err := s.storeDocuments(invoice); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(s.documentPath(invoice, format))
+}
+
+// issueCreditNote returns the credit note of the refund, issuing it if the
+// refund has none yet. Callers hold the mutex.
+func (s *InvoiceService) issueCreditNote(ctx context.Context, refund model.Refund) (model.Invoice, error) {
+	creditNotes, err := s.repo.SearchInvoices(ctx, map[string]string{
+		"type":      model.InvoiceTypeCreditNote,
+		"refund_id": strconv.Itoa(refund.ID),
+	})
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	if len(creditNotes) > 0 {
+		return creditNotes[0], nil
+	}
+
+	invoice, err := s.issueInvoice(ctx, refund.OrderID)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	amount := s.currencies.Normalize(refund.Amount)
+	tax := s.currencies.Normalize(refund.Tax)
+	net, err := amount.Sub(tax)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	description := "Refund: " + refund.Reason
+	if refund.ReturnID != 0 {
+		description = fmt.Sprintf("Refund of return #%d: %s", refund.ReturnID, refund.Reason)
+	}
+	if refund.StoreCredit {
+		description += " (store credit)"
+	}
+
+	zero := model.Money{Currency: amount.Currency}
+	return s.create(ctx, model.Invoice{
+		Type:          model.InvoiceTypeCreditNote,
+		OrderID:       refund.OrderID,
+		RefundID:      refund.ID,
+		InvoiceNumber: invoice.Number,
+		Customer:      invoice.Customer,
+		Lines: []model.InvoiceLine{{
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   net,
+			Discount:    zero,
+			LineTotal:   net,
+			TaxRate:     singleTaxRate(invoice),
+			Tax:         tax,
+		}},
+		Currency:      amount.Currency,
+		Subtotal:      net,
+		DiscountTotal: zero,
+		Shipping:      zero,
+		Tax:           tax,
+		Total:         amount,
+	})
+}
+
+// issueInvoice returns the invoice of the order, issuing it if the order is
+// paid and not invoiced yet. Callers hold the mutex.
+func (s *InvoiceService) issueInvoice(ctx context.Context, orderID int) (model.Invoice, error) {
+	invoices, err := s.repo.SearchInvoices(ctx, map[string]string{
+		"type":     model.InvoiceTypeInvoice,
+		"order_id": strconv.Itoa(orderID),
+	})
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	if len(invoices) > 0 {
+		return invoices[0], nil
+	}
+
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return model.Invoice{}, errors.New("order non existant")
+	}
+	if !orderIsPaid(order) {
+		return model.Invoice{}, fmt.Errorf("order is %s, only paid orders are invoiced", order.Status)
+	}
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, order.CustomerId)
+	if err != nil {
+		return model.Invoice{}, errors.New("customer non existant")
+	}
+
+	currency, err := s.currencies.Currency(order.Currency)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	zero := model.Money{Currency: currency}
+	invoice := model.Invoice{
+		Type:    model.InvoiceTypeInvoice,
+		OrderID: order.ID,
+		Customer: model.InvoiceParty{
+			CustomerID: customer.ID,
+			Name:       customer.Name,
+			Email:      customer.Email,
+			Address:    customer.Address,
+		},
+		Lines:         []model.InvoiceLine{},
+		Currency:      currency,
+		Subtotal:      zero,
+		DiscountTotal: zero,
+		Shipping:      s.currencies.Normalize(order.ShippingCost),
+		Tax:           zero,
+	}
+	for _, item := range order.Items {
+		description := fmt.Sprintf("Book #%d", item.BookID)
+		if book, err := s.repoBook.GetBook(ctx, item.BookID); err == nil {
+			description = book.Title
+		}
+
+		gross := s.currencies.Normalize(item.UnitPrice).Mul(item.Quantity)
+		net := s.currencies.Normalize(item.LineTotal)
+		discount, err := gross.Sub(net)
+		if err != nil {
+			return model.Invoice{}, err
+		}
+		line := model.InvoiceLine{
+			BookID:      item.BookID,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   s.currencies.Normalize(item.UnitPrice),
+			Discount:    discount,
+			LineTotal:   net,
+			TaxRate:     item.TaxRate,
+			Tax:         s.currencies.Normalize(item.Tax),
+		}
+		invoice.Lines = append(invoice.Lines, line)
+		if invoice.Subtotal, err = invoice.Subtotal.Add(gross); err != nil {
+			return model.Invoice{}, err
+		}
+		if invoice.DiscountTotal, err = invoice.DiscountTotal.Add(line.Discount); err != nil {
+			return model.Invoice{}, err
+		}
+		if invoice.Tax, err = invoice.Tax.Add(line.Tax); err != nil {
+			return model.Invoice{}, err
+		}
+	}
+	net, err := invoice.Subtotal.Sub(invoice.DiscountTotal)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	if invoice.Total, err = model.Sum(net, invoice.Tax, invoice.Shipping); err != nil {
+		return model.Invoice{}, err
+	}
+
+	return s.create(ctx, invoice)
+}
+
+// create numbers and stores an invoice, then writes its documents. Callers
+// hold the mutex, so that numbers are given out in order; as invoices are never
+// deleted, the next number follows the count of invoices of the same type.
+func (s *InvoiceService) create(ctx context.Context, invoice model.Invoice) (model.Invoice, error) {
+	issued, err := s.repo.SearchInvoices(ctx, map[string]string{"type": invoice.Type})
+	if err != nil {
+		return model.Invoice{}, err
+	}
+
+	prefix := "INV"
+	if invoice.Type == model.InvoiceTypeCreditNote {
+		prefix = "CN"
+	}
+	invoice.Number = fmt.Sprintf("%s-%06d", prefix, len(issued)+1)
+	invoice.IssuedAt = time.Now()
+
+	created, err := s.repo.CreateInvoice(ctx, invoice)
+	if err != nil {
+		return model.Invoice{}, err
+	}
+	if err := s.storeDocuments(created); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+// storeDocuments writes the documents of an invoice that are not written yet.
+// Documents are read-only and never overwritten.
+func (s *InvoiceService) storeDocuments(invoice model.Invoice) error {
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create invoices directory: %v", err)
+	}
+
+	html, err := renderInvoiceHTML(invoice)
+	if err != nil {
+		return err
+	}
+	documents := map[string][]byte{
+		InvoiceFormatHTML: html,
+		InvoiceFormatPDF:  renderInvoicePDF(invoice),
+	}
+	for format, data := range documents {
+		file, err := os.OpenFile(s.documentPath(invoice, format), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0444)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create invoice file: %v", err)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(s.documentPath(invoice, format))
+			return fmt.Errorf("failed to write invoice file: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *InvoiceService) documentPath(invoice model.Invoice, format string) string {
+	return filepath.Join(s.dir, invoice.Number+"."+format)
+}
+
+// singleTaxRate returns the tax rate of the invoice when all its lines share
+// it, and zero otherwise.
+func singleTaxRate(invoice model.Invoice) float64 {
+	if len(invoice.Lines) == 0 {
+		return 0
+	}
+	rate := invoice.Lines[0].TaxRate
+	for _, line := range invoice.Lines[1:] {
+		if line.TaxRate != rate {
+			return 0
+		}
+	}
+	return rate
+}
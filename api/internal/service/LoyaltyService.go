@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LoyaltyService runs the loyalty program loaded from a file. Customers earn
+// points on the orders they pay for, at the multiplier of the tier reached by
+// what they spent over the last twelve months, and redeem points for discounts
+// on new orders. Every change to a balance is recorded in the loyalty ledger.
+type LoyaltyService struct {
+	repo         repository.LoyaltyTransactionStore
+	repoCustomer repository.CustomerStore
+	repoOrder    repository.OrderStore
+	currencies   *CurrencyService
+	program      model.LoyaltyProgram
+	mutex        sync.Mutex
+}
+
+func NewLoyaltyService(repo repository.LoyaltyTransactionStore, repoCustomer repository.CustomerStore, repoOrder repository.OrderStore, currencies *CurrencyService, filename string) (*LoyaltyService, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loyalty program: %v", err)
+	}
+
+	var program model.LoyaltyProgram
+	if err := json.Unmarshal(data, &program); err != nil {
+		return nil, fmt.Errorf("failed to parse loyalty program: %v", err)
+	}
+	if program.PointsPerUnit < 0 {
+		return nil, errors.New("loyalty points per unit must not be negative")
+	}
+	if program.PointValue < 0 {
+		return nil, errors.New("loyalty point value must not be negative")
+	}
+	switch program.AwardOn {
+	case "":
+		program.AwardOn = model.LoyaltyAwardOnPaid
+	case model.LoyaltyAwardOnPaid, model.LoyaltyAwardOnDelivered:
+	default:
+		return nil, fmt.Errorf("loyalty points cannot be awarded on %s", program.AwardOn)
+	}
+	for i, tier := range program.Tiers {
+		if tier.Name == "" {
+			return nil, errors.New("loyalty tier name is mandatory")
+		}
+		if tier.MinSpend < 0 {
+			return nil, fmt.Errorf("minimum spend of loyalty tier %s must not be negative", tier.Name)
+		}
+		if tier.Multiplier < 0 {
+			return nil, fmt.Errorf("multiplier of loyalty tier %s must not be negative", tier.Name)
+		}
+		if tier.Multiplier == 0 {
+			program.Tiers[i].Multiplier = 1
+		}
+	}
+	sort.Slice(program.Tiers, func(i, j int) bool {
+		return program.Tiers[i].MinSpend < program.Tiers[j].MinSpend
+	})
+
+	return &LoyaltyService{
+		repo:         repo,
+		repoCustomer: repoCustomer,
+		repoOrder:    repoOrder,
+		currencies:   currencies,
+		program:      program,
+	}, nil
+}
+
+// GetLoyalty returns the points balance of a customer, the tier reached and
+// the ledger of points.
+func (s *LoyaltyService) GetLoyalty(ctx context.Context, customerID int) (model.LoyaltyAccount, error) {
+	if err := ctx.Err(); err != nil {
+		return model.LoyaltyAccount{}, err
+	}
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, customerID)
+	if err != nil {
+		return model.LoyaltyAccount{}, err
+	}
+	spend, err := s.rollingSpend(ctx, customerID, time.Now())
+	if err != nil {
+		return model.LoyaltyAccount{}, err
+	}
+	tier := s.tier(spend)
+
+	transactions, err := s.repo.SearchLoyaltyTransactions(ctx, map[string]string{"customer_id": strconv.Itoa(customerID)})
+	if err != nil {
+		return model.LoyaltyAccount{}, err
+	}
+
+	return model.LoyaltyAccount{
+		CustomerID:   customerID,
+		Points:       customer.LoyaltyPoints,
+		Tier:         tier.Name,
+		Multiplier:   tier.Multiplier,
+		RollingSpend: spend,
+		Transactions: transactions,
+	}, nil
+}
+
+// DiscountItems takes the value of the points redeemed off the priced items of
+// an order, spread over the lines like a fixed coupon, and returns the items
+// with the number of points used: never more than the items are worth.
+// available is the balance the points are taken from.
+func (s *LoyaltyService) DiscountItems(items []model.OrderItem, points int, available int, currency string) ([]model.OrderItem, int, error) {
+	if points < 0 {
+		return nil, 0, errors.New("points to redeem must not be negative")
+	}
+	if points == 0 {
+		return items, 0, nil
+	}
+	if s.program.PointValue == 0 {
+		return nil, 0, errors.New("loyalty points cannot be redeemed")
+	}
+	if points > available {
+		return nil, 0, fmt.Errorf("customer has only %d loyalty points", available)
+	}
+
+	remaining := make([]model.Money, len(items))
+	total := model.Money{Currency: currency}
+	for i, item := range items {
+		remaining[i] = item.LineTotal
+		var err error
+		if total, err = total.Add(item.LineTotal); err != nil {
+			return nil, 0, err
+		}
+	}
+	totalBase, err := s.currencies.Convert(total, s.currencies.BaseCurrency())
+	if err != nil {
+		return nil, 0, err
+	}
+	if needed := int(math.Ceil(totalBase.Float() / s.program.PointValue)); points > needed {
+		points = needed
+	}
+	if points == 0 {
+		return items, 0, nil
+	}
+
+	value, err := s.currencies.Convert(model.NewMoney(float64(points)*s.program.PointValue, s.currencies.BaseCurrency()), currency)
+	if err != nil {
+		return nil, 0, err
+	}
+	discounted := make([]model.OrderItem, len(items))
+	for i, amount := range spreadDiscount(value, remaining) {
+		discounted[i] = items[i]
+		if amount.Amount > 0 {
+			discounted[i].Discounts = append(append([]model.Discount{}, items[i].Discounts...), model.Discount{
+				Source: model.DiscountSourceLoyalty,
+				Name:   fmt.Sprintf("%d points", points),
+				Amount: amount,
+			})
+			if discounted[i].LineTotal, err = items[i].LineTotal.Sub(amount); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return discounted, points, nil
+}
+
+// RedeemPoints takes the points redeemed on an order from the balance of its
+// customer.
+func (s *LoyaltyService) RedeemPoints(ctx context.Context, order model.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if order.PointsRedeemed == 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, order.CustomerId)
+	if err != nil {
+		return errors.New("customer non existant")
+	}
+	if customer.LoyaltyPoints < order.PointsRedeemed {
+		return fmt.Errorf("customer has only %d loyalty points", customer.LoyaltyPoints)
+	}
+
+	_, err = s.addPoints(ctx, model.LoyaltyTransaction{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		Type:       model.LoyaltyRedeem,
+		Points:     -order.PointsRedeemed,
+	})
+	return err
+}
+
+// ReleasePoints gives the points redeemed on an order that will not be paid
+// back to its customer.
+func (s *LoyaltyService) ReleasePoints(ctx context.Context, order model.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if order.PointsRedeemed == 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.addPoints(ctx, model.LoyaltyTransaction{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		Type:       model.LoyaltyRelease,
+		Points:     order.PointsRedeemed,
+	})
+	return err
+}
+
+// AwardPoints awards the points of an order when it reaches the event the
+// program awards points on, paid or delivered. The points are earned on what
+// the order cost before tax and shipping, less what was already refunded, at
+// the multiplier of the customer's tier. An order earns points once.
+func (s *LoyaltyService) AwardPoints(ctx context.Context, orderID int, event string) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return model.Order{}, errors.New("order non existant")
+	}
+	if event != s.program.AwardOn || order.PointsEarned != 0 || !orderIsPaid(order) {
+		return order, nil
+	}
+
+	spend, err := s.orderSpend(order)
+	if err != nil {
+		return model.Order{}, err
+	}
+	rollingSpend, err := s.rollingSpend(ctx, order.CustomerId, time.Now())
+	if err != nil {
+		return model.Order{}, err
+	}
+	tier := s.tier(rollingSpend)
+	points := int(math.Floor(spend.Float() * s.program.PointsPerUnit * tier.Multiplier))
+	if points <= 0 {
+		return order, nil
+	}
+
+	note := ""
+	if tier.Name != "" {
+		note = tier.Name + " tier"
+	}
+	if _, err := s.addPoints(ctx, model.LoyaltyTransaction{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		Type:       model.LoyaltyEarn,
+		Points:     points,
+		Note:       note,
+	}); err != nil {
+		return model.Order{}, err
+	}
+
+	order.PointsEarned = points
+	return s.repoOrder.UpdateOrder(ctx, order.ID, order)
+}
+
+// ClawBack takes back the share of the points earned on an order that a refund
+// pays back. The balance of the customer goes negative if the points were
+// already spent, and is made up by the points earned next.
+func (s *LoyaltyService) ClawBack(ctx context.Context, order model.Order, refund model.Refund) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if order.PointsEarned == 0 || order.GrandTotal.Amount <= 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	clawbacks, err := s.repo.SearchLoyaltyTransactions(ctx, map[string]string{
+		"order_id": strconv.Itoa(order.ID),
+		"type":     model.LoyaltyClawback,
+	})
+	if err != nil {
+		return err
+	}
+	left := order.PointsEarned
+	for _, clawback := range clawbacks {
+		left += clawback.Points
+	}
+
+	points := int(math.Round(float64(order.PointsEarned) * float64(refund.Amount.Amount) / float64(order.GrandTotal.Amount)))
+	if points > left {
+		points = left
+	}
+	if points <= 0 {
+		return nil
+	}
+
+	_, err = s.addPoints(ctx, model.LoyaltyTransaction{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		RefundID:   refund.ID,
+		Type:       model.LoyaltyClawback,
+		Points:     -points,
+		Note:       "refund: " + refund.Reason,
+	})
+	return err
+}
+
+// addPoints adds the points of the transaction to the balance of its customer
+// and records it. Callers hold the mutex.
+func (s *LoyaltyService) addPoints(ctx context.Context, transaction model.LoyaltyTransaction) (model.LoyaltyTransaction, error) {
+	customer, err := s.repoCustomer.GetCustomer(ctx, transaction.CustomerID)
+	if err != nil {
+		return model.LoyaltyTransaction{}, errors.New("customer non existant")
+	}
+
+	customer.LoyaltyPoints += transaction.Points
+	if _, err := s.repoCustomer.UpdateCustomer(ctx, customer.ID, customer); err != nil {
+		return model.LoyaltyTransaction{}, err
+	}
+
+	transaction.Balance = customer.LoyaltyPoints
+	transaction.CreatedAt = time.Now()
+	return s.repo.CreateLoyaltyTransaction(ctx, transaction)
+}
+
+// rollingSpend sums, in the base currency, what the customer spent on the
+// orders paid over the twelve months before now.
+func (s *LoyaltyService) rollingSpend(ctx context.Context, customerID int, now time.Time) (model.Money, error) {
+	orders, err := s.repoOrder.SearchOrders(ctx, map[string]string{"customer_id": strconv.Itoa(customerID)})
+	if err != nil {
+		return model.Money{}, err
+	}
+
+	since := now.AddDate(-1, 0, 0)
+	spend := model.Money{Currency: s.currencies.BaseCurrency()}
+	for _, order := range orders {
+		if !orderIsPaid(order) || order.CreatedAt.Before(since) {
+			continue
+		}
+		orderSpend, err := s.orderSpend(order)
+		if err != nil {
+			return model.Money{}, err
+		}
+		if spend, err = spend.Add(orderSpend); err != nil {
+			return model.Money{}, err
+		}
+	}
+	return spend, nil
+}
+
+// orderSpend is what the order cost before tax and shipping, less the share of
+// it refunded, in the base currency.
+func (s *LoyaltyService) orderSpend(order model.Order) (model.Money, error) {
+	spend := s.currencies.Normalize(order.TotalPrice)
+	if !order.Refunded.IsZero() && order.GrandTotal.Amount > 0 {
+		kept := 1 - float64(order.Refunded.Amount)/float64(order.GrandTotal.Amount)
+		spend = spend.MulRate(math.Max(kept, 0))
+	}
+	return s.currencies.Convert(spend, s.currencies.BaseCurrency())
+}
+
+// tier returns the highest tier reached by the spend, or no tier with a
+// multiplier of one.
+func (s *LoyaltyService) tier(spend model.Money) model.LoyaltyTier {
+	reached := model.LoyaltyTier{Multiplier: 1}
+	for _, tier := range s.program.Tiers {
+		if spend.Float() >= tier.MinSpend {
+			reached = tier
+		}
+	}
+	return reached
+}
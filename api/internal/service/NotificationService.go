@@ -0,0 +1,49 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// NotificationService sends messages to customers about their orders, kept in
+// their inbox.
+type NotificationService struct {
+	repo         repository.NotificationStore
+	repoCustomer repository.CustomerStore
+}
+
+func NewNotificationService(repo repository.NotificationStore, repoCustomer repository.CustomerStore) *NotificationService {
+	return &NotificationService{
+		repo:         repo,
+		repoCustomer: repoCustomer,
+	}
+}
+
+func (s *NotificationService) Notify(ctx context.Context, notification model.Notification) (model.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Notification{}, err
+	}
+
+	if _, err := s.repoCustomer.GetCustomer(ctx, notification.CustomerID); err != nil {
+		return model.Notification{}, errors.New("customer non existant")
+	}
+
+	notification.CreatedAt = time.Now()
+	return s.repo.CreateNotification(ctx, notification)
+}
+
+func (s *NotificationService) GetCustomerNotifications(ctx context.Context, customerID int) ([]model.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoCustomer.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SearchNotifications(ctx, map[string]string{"customer_id": strconv.Itoa(customerID)})
+}
@@ -5,6 +5,9 @@ import (
 	"bookstore/api/api/internal/repository"
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 )
 
@@ -12,14 +15,28 @@ type OrderService struct {
 	repo         repository.OrderStore
 	repoCustomer repository.CustomerStore
 	repoBook     repository.BookStore
+	stock        *StockService
+	promotions   *PromotionService
+	taxes        *TaxService
+	shipping     *ShippingService
+	currencies   *CurrencyService
+	credits      *CreditService
+	loyalty      *LoyaltyService
 	currentID    int
 }
 
-func NewOrderService(repo repository.OrderStore, repoCustomer repository.CustomerStore, repoBook repository.BookStore) *OrderService {
+func NewOrderService(repo repository.OrderStore, repoCustomer repository.CustomerStore, repoBook repository.BookStore, stock *StockService, promotions *PromotionService, taxes *TaxService, shipping *ShippingService, currencies *CurrencyService, credits *CreditService, loyalty *LoyaltyService) *OrderService {
 	return &OrderService{
 		repo:         repo,
 		repoCustomer: repoCustomer,
 		repoBook:     repoBook,
+		stock:        stock,
+		promotions:   promotions,
+		taxes:        taxes,
+		shipping:     shipping,
+		currencies:   currencies,
+		credits:      credits,
+		loyalty:      loyalty,
 		currentID:    1,
 	}
 }
@@ -29,18 +46,26 @@ func (s *OrderService) CreateOrder(ctx context.Context, orderInput model.OrderIn
 		return model.Order{}, err
 	}
 
-	totalPrice, err := s.calculateTotalPrice(ctx, orderInput.Items)
+	coupon, err := s.promotions.RedeemCoupon(ctx, orderInput.CouponCode)
 	if err != nil {
 		return model.Order{}, err
 	}
 
+	order, err := s.createOrder(ctx, orderInput, coupon)
+	if err != nil {
+		s.promotions.ReleaseCoupon(ctx, orderInput.CouponCode)
+		return model.Order{}, err
+	}
+	return order, nil
+}
+
+func (s *OrderService) createOrder(ctx context.Context, orderInput model.OrderInput, coupon *model.Coupon) (model.Order, error) {
 	order := model.Order{
 		ID:         s.currentID,
 		CustomerId: orderInput.CustomerId,
 		Items:      orderInput.Items,
-		TotalPrice: totalPrice,
 		CreatedAt:  time.Now(),
-		Status:     "Pending",
+		Status:     model.OrderPending,
 	}
 	s.currentID++
 
@@ -51,20 +76,52 @@ func (s *OrderService) CreateOrder(ctx context.Context, orderInput model.OrderIn
 		return model.Order{}, errors.New("order must have at least one item")
 	}
 
-	_, err2 := s.repoCustomer.GetCustomer(ctx, order.CustomerId)
+	customer, err2 := s.repoCustomer.GetCustomer(ctx, order.CustomerId)
 
 	if err2 != nil {
 		return model.Order{}, errors.New("customer non existant")
 	}
 
+	if err := s.priceOrder(ctx, &order, orderInput, customer, coupon); err != nil {
+		return model.Order{}, err
+	}
+
 	for _, item := range order.Items {
 		_, err := s.repoBook.GetBook(ctx, item.BookID)
 		if err != nil {
 			return model.Order{}, errors.New("book non existant")
 		}
+		if item.Quantity <= 0 {
+			return model.Order{}, errors.New("item quantity must be positive")
+		}
 	}
 
-	return s.repo.CreateOrder(ctx, order)
+	// missing copies are back-ordered when the customer allows it
+	if !orderInput.AllowBackorder {
+		if err := s.stock.CheckAvailability(ctx, order.Items); err != nil {
+			return model.Order{}, err
+		}
+	}
+
+	if err := s.loyalty.RedeemPoints(ctx, order); err != nil {
+		return model.Order{}, err
+	}
+
+	createdOrder, err := s.repo.CreateOrder(ctx, order)
+	if err != nil {
+		s.loyalty.ReleasePoints(ctx, order)
+		return model.Order{}, err
+	}
+
+	reserved, err := s.stock.ReserveOrder(ctx, createdOrder.ID, customer, nil, createdOrder.Items, orderInput.AllowBackorder)
+	if err != nil {
+		s.repo.DeleteOrder(ctx, createdOrder.ID)
+		s.loyalty.ReleasePoints(ctx, createdOrder)
+		return model.Order{}, err
+	}
+	createdOrder.Items = reserved
+
+	return s.repo.UpdateOrder(ctx, createdOrder.ID, createdOrder)
 }
 
 func (s *OrderService) GetOrder(ctx context.Context, id int) (model.Order, error) {
@@ -83,17 +140,48 @@ func (s *OrderService) UpdateOrder(ctx context.Context, id int, orderInput model
 	if err != nil {
 		return model.Order{}, err
 	}
+	if existingOrder.Status != model.OrderPending {
+		return model.Order{}, errors.New("only pending orders can be modified")
+	}
+
+	// the coupon already on the order was redeemed when it was applied, a new
+	// one is redeemed now and the old one given back once the update succeeded
+	sameCoupon := strings.EqualFold(orderInput.CouponCode, existingOrder.CouponCode)
+	var coupon *model.Coupon
+	if sameCoupon {
+		coupon, err = s.promotions.LookupCoupon(ctx, existingOrder.CouponCode)
+	} else {
+		coupon, err = s.promotions.RedeemCoupon(ctx, orderInput.CouponCode)
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
 
-	totalPrice, err := s.calculateTotalPrice(ctx, orderInput.Items)
+	updatedOrder, err := s.updateOrder(ctx, existingOrder, orderInput, coupon)
 	if err != nil {
+		if !sameCoupon {
+			s.promotions.ReleaseCoupon(ctx, orderInput.CouponCode)
+		}
+		return model.Order{}, err
+	}
+	if !sameCoupon {
+		s.promotions.ReleaseCoupon(ctx, existingOrder.CouponCode)
+	}
+	// the order is priced anew, so the credits applied to it are given back
+	// and applied again at payment
+	if err := s.credits.ReleaseCredits(ctx, existingOrder); err != nil {
 		return model.Order{}, err
 	}
+	return updatedOrder, nil
+}
+
+func (s *OrderService) updateOrder(ctx context.Context, existingOrder model.Order, orderInput model.OrderInput, coupon *model.Coupon) (model.Order, error) {
+	id := existingOrder.ID
 
 	updatedOrder := model.Order{
 		ID:         existingOrder.ID,
 		CustomerId: orderInput.CustomerId,
 		Items:      orderInput.Items,
-		TotalPrice: totalPrice,
 		CreatedAt:  existingOrder.CreatedAt,
 		Status:     existingOrder.Status,
 	}
@@ -104,6 +192,40 @@ func (s *OrderService) UpdateOrder(ctx context.Context, id int, orderInput model
 	if len(updatedOrder.Items) == 0 {
 		return model.Order{}, errors.New("order must have at least one item")
 	}
+	for _, item := range updatedOrder.Items {
+		if item.Quantity <= 0 {
+			return model.Order{}, errors.New("item quantity must be positive")
+		}
+	}
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, updatedOrder.CustomerId)
+	if err != nil {
+		return model.Order{}, errors.New("customer non existant")
+	}
+	// the points redeemed on the order can be redeemed again
+	if customer.ID == existingOrder.CustomerId {
+		customer.LoyaltyPoints += existingOrder.PointsRedeemed
+	}
+
+	if err := s.priceOrder(ctx, &updatedOrder, orderInput, customer, coupon); err != nil {
+		return model.Order{}, err
+	}
+
+	if err := s.loyalty.ReleasePoints(ctx, existingOrder); err != nil {
+		return model.Order{}, err
+	}
+	if err := s.loyalty.RedeemPoints(ctx, updatedOrder); err != nil {
+		s.loyalty.RedeemPoints(ctx, existingOrder)
+		return model.Order{}, err
+	}
+
+	reserved, err := s.stock.ReserveOrder(ctx, id, customer, existingOrder.Items, updatedOrder.Items, orderInput.AllowBackorder)
+	if err != nil {
+		s.loyalty.ReleasePoints(ctx, updatedOrder)
+		s.loyalty.RedeemPoints(ctx, existingOrder)
+		return model.Order{}, err
+	}
+	updatedOrder.Items = reserved
 
 	return s.repo.UpdateOrder(ctx, id, updatedOrder)
 }
@@ -112,6 +234,27 @@ func (s *OrderService) DeleteOrder(ctx context.Context, id int) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
+
+	existingOrder, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.stock.CancelOrder(ctx, id, existingOrder.Items); err != nil {
+		return err
+	}
+	if existingOrder.Status == model.OrderPending {
+		if err := s.promotions.ReleaseCoupon(ctx, existingOrder.CouponCode); err != nil {
+			return err
+		}
+		if err := s.credits.ReleaseCredits(ctx, existingOrder); err != nil {
+			return err
+		}
+		if err := s.loyalty.ReleasePoints(ctx, existingOrder); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.DeleteOrder(ctx, id)
 }
 
@@ -122,14 +265,227 @@ func (s *OrderService) SearchOrders(ctx context.Context, params map[string]strin
 	return s.repo.SearchOrders(ctx, params)
 }
 
-func (s *OrderService) calculateTotalPrice(ctx context.Context, items []model.OrderItem) (float64, error) {
-	var total float64
-	for _, item := range items {
-		book, err := s.repoBook.GetBook(ctx, item.BookID)
-		if err != nil {
-			return 0, err
+// ConfirmPayment marks a pending order as paid, turning its stock reservations
+// into sales.
+func (s *OrderService) ConfirmPayment(ctx context.Context, id int) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	order, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if order.Status != model.OrderPending {
+		return model.Order{}, fmt.Errorf("order is %s, only pending orders can be paid", order.Status)
+	}
+
+	if err := s.stock.CommitOrder(ctx, id); err != nil {
+		return model.Order{}, err
+	}
+
+	paidAt := time.Now()
+	order.Status = model.OrderPaid
+	order.PaidAt = &paidAt
+	return s.repo.UpdateOrder(ctx, id, order)
+}
+
+// ApplyCredits pays part or all of what is due for a pending order with a gift
+// card and the store credit of the customer. The credits stay on the order if
+// the rest of the payment fails, until the order is paid or dropped.
+func (s *OrderService) ApplyCredits(ctx context.Context, id int, giftCardCode string, useStoreCredit bool) (model.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Order{}, err
+	}
+
+	order, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if order.Status != model.OrderPending {
+		return model.Order{}, fmt.Errorf("order is %s, only pending orders can be paid", order.Status)
+	}
+
+	due, err := orderAmountDue(order)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if due.Amount <= 0 {
+		return order, nil
+	}
+	credits, err := s.credits.RedeemCredits(ctx, order, giftCardCode, useStoreCredit, due)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	order.Credits = append(order.Credits, credits...)
+	for _, credit := range credits {
+		if order.CreditApplied, err = order.CreditApplied.Add(credit.Amount); err != nil {
+			s.credits.ReleaseCredits(ctx, model.Order{ID: order.ID, CustomerId: order.CustomerId, Credits: credits})
+			return model.Order{}, err
+		}
+	}
+	updated, err := s.repo.UpdateOrder(ctx, id, order)
+	if err != nil {
+		s.credits.ReleaseCredits(ctx, model.Order{ID: order.ID, CustomerId: order.CustomerId, Credits: credits})
+		return model.Order{}, err
+	}
+	return updated, nil
+}
+
+// ExpireOrders releases the reservations that ran out of time and marks their
+// orders as expired.
+func (s *OrderService) ExpireOrders(ctx context.Context) (int, error) {
+	orderIDs, expireErr := s.stock.ExpireReservations(ctx)
+	if expireErr != nil && len(orderIDs) == 0 {
+		return 0, expireErr
+	}
+
+	expired := 0
+	for _, id := range orderIDs {
+		order, err := s.repo.GetOrder(ctx, id)
+		if err != nil || order.Status != model.OrderPending {
+			continue
+		}
+		order.Status = model.OrderExpired
+		if _, err := s.repo.UpdateOrder(ctx, id, order); err != nil {
+			return expired, err
+		}
+		if err := s.promotions.ReleaseCoupon(ctx, order.CouponCode); err != nil {
+			return expired, err
+		}
+		if err := s.credits.ReleaseCredits(ctx, order); err != nil {
+			return expired, err
+		}
+		if err := s.loyalty.ReleasePoints(ctx, order); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, expireErr
+}
+
+func (s *OrderService) StartReservationSweeper(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpireOrders(ctx)
+			if err != nil {
+				logger.Printf("Error releasing expired stock reservations: %v\n", err)
+			} else if expired > 0 {
+				logger.Printf("Released the stock reservations of %d unpaid orders\n", expired)
+			}
+		}
+	}
+}
+
+// priceOrder prices the items of the order in the currency asked for, or else
+// in the currency of the customer, takes off the loyalty points redeemed, then
+// taxes and ships them to the customer.
+func (s *OrderService) priceOrder(ctx context.Context, order *model.Order, orderInput model.OrderInput, customer model.Customer, coupon *model.Coupon) error {
+	currency := orderInput.Currency
+	if currency == "" {
+		currency = customer.Currency
+	}
+	currency, err := s.currencies.Currency(currency)
+	if err != nil {
+		return err
+	}
+	order.Currency = currency
+	order.Refunded.Currency = currency
+	order.CreditApplied.Currency = currency
+
+	items, err := s.promotions.PriceItems(ctx, orderInput.Items, coupon, currency)
+	if err != nil {
+		return err
+	}
+	items, order.PointsRedeemed, err = s.loyalty.DiscountItems(items, orderInput.RedeemPoints, customer.LoyaltyPoints, currency)
+	if err != nil {
+		return err
+	}
+	order.Items, err = s.taxes.TaxItems(ctx, items, customer.Address)
+	if err != nil {
+		return err
+	}
+	if err := s.setShipping(ctx, order, orderInput.ShippingMethodID, customer.Address); err != nil {
+		return err
+	}
+	return setOrderTotals(order, coupon)
+}
+
+// setShipping records the shipping method of the order and what it costs to
+// deliver the items to the address. Orders without a method ship for free.
+func (s *OrderService) setShipping(ctx context.Context, order *model.Order, methodID int, address model.Address) error {
+	order.ShippingMethodID = methodID
+	order.ShippingCost = model.Money{Currency: order.Currency}
+	if methodID == 0 {
+		return nil
+	}
+
+	cost, err := s.shipping.QuoteShipping(ctx, methodID, address, order.Items, order.Currency)
+	if err != nil {
+		return err
+	}
+	order.ShippingCost = cost
+	return nil
+}
+
+// setOrderTotals sums the priced and taxed lines of the order into its
+// subtotal, discount total, total price before tax and tax, and adds the
+// shipping cost to the grand total.
+func setOrderTotals(order *model.Order, coupon *model.Coupon) error {
+	order.CouponCode = ""
+	if coupon != nil {
+		order.CouponCode = coupon.Code
+	}
+
+	zero := model.Money{Currency: order.Currency}
+	order.Subtotal, order.TotalPrice, order.Tax = zero, zero, zero
+	var err error
+	for _, item := range order.Items {
+		if order.Subtotal, err = order.Subtotal.Add(item.UnitPrice.Mul(item.Quantity)); err != nil {
+			return err
+		}
+		if order.TotalPrice, err = order.TotalPrice.Add(item.LineTotal); err != nil {
+			return err
+		}
+		if order.Tax, err = order.Tax.Add(item.Tax); err != nil {
+			return err
 		}
-		total += book.Price * float64(item.Quantity)
 	}
-	return total, nil
+	if order.DiscountTotal, err = order.Subtotal.Sub(order.TotalPrice); err != nil {
+		return err
+	}
+	order.GrandTotal, err = model.Sum(order.TotalPrice, order.Tax, order.ShippingCost)
+	return err
+}
+
+// orderIsPaid tells whether the order was paid, whether or not it was shipped
+// or delivered since.
+func orderIsPaid(order model.Order) bool {
+	switch order.Status {
+	case model.OrderPaid, model.OrderPartiallyShipped, model.OrderShipped, model.OrderDelivered:
+		return true
+	}
+	return false
+}
+
+// orderAmountDue is what is left to pay for the order once the credits applied
+// to it are taken off its grand total.
+func orderAmountDue(order model.Order) (model.Money, error) {
+	return orderGrandTotal(order).Sub(order.CreditApplied)
+}
+
+// orderGrandTotal is the amount the customer pays for the order. Orders placed
+// before taxes were charged have no grand total and cost their total price.
+func orderGrandTotal(order model.Order) model.Money {
+	if order.GrandTotal.IsZero() {
+		return order.TotalPrice
+	}
+	return order.GrandTotal
 }
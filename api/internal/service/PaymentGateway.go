@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PaymentGateway is the boundary to a payment provider. Authorize reserves the
+// amount on the customer's payment method, Capture collects it, Void releases
+// an authorization that will not be captured and Refund pays part of a
+// captured payment back. Providers that settle asynchronously answer
+// with a pending status and confirm the payment later through a webhook, which
+// ParseWebhook verifies and decodes.
+type PaymentGateway interface {
+	Name() string
+	Authorize(ctx context.Context, request GatewayRequest) (GatewayResult, error)
+	Capture(ctx context.Context, reference string, amount model.Money) (GatewayResult, error)
+	Void(ctx context.Context, reference string) (GatewayResult, error)
+	Refund(ctx context.Context, reference string, amount model.Money) (GatewayResult, error)
+	ParseWebhook(payload []byte, signature string) (GatewayEvent, error)
+}
+
+type GatewayRequest struct {
+	OrderID int
+	Amount  model.Money
+	Method  string
+	Token   string
+}
+
+// GatewayResult is the answer of the gateway to a call; Status is one of the
+// payment statuses of the model package.
+type GatewayResult struct {
+	Reference string
+	Status    string
+	Message   string
+}
+
+type GatewayEvent struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+}
+
+func NewPaymentGateway(name string, mode string, webhookSecret string) (PaymentGateway, error) {
+	switch name {
+	case "", "fake":
+		return NewFakePaymentGateway(mode, webhookSecret)
+	default:
+		return nil, fmt.Errorf("unknown payment gateway %q", name)
+	}
+}
+
+const (
+	fakeGatewayApprove = "approve"
+	fakeGatewayDecline = "decline"
+	fakeGatewayTimeout = "timeout"
+	fakeGatewayPending = "pending"
+)
+
+// FakePaymentGateway is a local gateway for development and testing. Its mode
+// decides the outcome of every authorization: approve, decline, timeout (never
+// answers) or pending (confirmed later through the webhook). A payment token
+// naming one of the modes overrides it for that payment.
+type FakePaymentGateway struct {
+	mode          string
+	webhookSecret string
+}
+
+func NewFakePaymentGateway(mode string, webhookSecret string) (*FakePaymentGateway, error) {
+	if mode == "" {
+		mode = fakeGatewayApprove
+	}
+	if !isFakeGatewayMode(mode) {
+		return nil, fmt.Errorf("unknown fake payment gateway mode %q", mode)
+	}
+	return &FakePaymentGateway{mode: mode, webhookSecret: webhookSecret}, nil
+}
+
+func (g *FakePaymentGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakePaymentGateway) Authorize(ctx context.Context, request GatewayRequest) (GatewayResult, error) {
+	mode := g.mode
+	if isFakeGatewayMode(request.Token) {
+		mode = request.Token
+	}
+
+	switch mode {
+	case fakeGatewayDecline:
+		return GatewayResult{Reference: g.nextReference(), Status: model.PaymentDeclined, Message: "card declined"}, nil
+	case fakeGatewayTimeout:
+		<-ctx.Done()
+		return GatewayResult{}, ctx.Err()
+	case fakeGatewayPending:
+		return GatewayResult{Reference: g.nextReference(), Status: model.PaymentPending}, nil
+	default:
+		return GatewayResult{Reference: g.nextReference(), Status: model.PaymentAuthorized}, nil
+	}
+}
+
+func (g *FakePaymentGateway) Capture(ctx context.Context, reference string, amount model.Money) (GatewayResult, error) {
+	if err := ctx.Err(); err != nil {
+		return GatewayResult{}, err
+	}
+	return GatewayResult{Reference: reference, Status: model.PaymentCaptured}, nil
+}
+
+func (g *FakePaymentGateway) Void(ctx context.Context, reference string) (GatewayResult, error) {
+	if err := ctx.Err(); err != nil {
+		return GatewayResult{}, err
+	}
+	return GatewayResult{Reference: reference, Status: model.PaymentFailed}, nil
+}
+
+func (g *FakePaymentGateway) Refund(ctx context.Context, reference string, amount model.Money) (GatewayResult, error) {
+	if err := ctx.Err(); err != nil {
+		return GatewayResult{}, err
+	}
+	return GatewayResult{Reference: g.nextReference()}, nil
+}
+
+// ParseWebhook decodes a JSON event such as {"reference": "fake_...", "status":
+// "captured"}. The signature must be the hex encoded HMAC-SHA256 of the
+// payload; without a webhook secret every webhook is rejected, since anyone
+// could otherwise mark an order paid.
+func (g *FakePaymentGateway) ParseWebhook(payload []byte, signature string) (GatewayEvent, error) {
+	if g.webhookSecret == "" {
+		return GatewayEvent{}, errors.New("webhooks are disabled until a webhook secret is configured")
+	}
+	mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return GatewayEvent{}, errors.New("invalid webhook signature")
+	}
+
+	var event GatewayEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return GatewayEvent{}, errors.New("invalid webhook payload")
+	}
+	if event.Reference == "" {
+		return GatewayEvent{}, errors.New("webhook event has no payment reference")
+	}
+
+	switch strings.ToLower(event.Status) {
+	case "captured":
+		event.Status = model.PaymentCaptured
+	case "declined":
+		event.Status = model.PaymentDeclined
+	case "failed":
+		event.Status = model.PaymentFailed
+	default:
+		return GatewayEvent{}, fmt.Errorf("unknown webhook payment status %q", event.Status)
+	}
+	return event, nil
+}
+
+// nextReference returns a random reference, so that references stay unique
+// across restarts.
+func (g *FakePaymentGateway) nextReference() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "fake_" + hex.EncodeToString(buf)
+}
+
+func isFakeGatewayMode(mode string) bool {
+	switch mode {
+	case fakeGatewayApprove, fakeGatewayDecline, fakeGatewayTimeout, fakeGatewayPending:
+		return true
+	}
+	return false
+}
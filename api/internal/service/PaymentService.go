@@ -0,0 +1,417 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// paymentGatewayTimeout bounds a call to the gateway, leaving the request time
+// to record the outcome.
+const paymentGatewayTimeout = 3 * time.Second
+
+type PaymentService struct {
+	repo         repository.PaymentStore
+	repoRefund   repository.RefundStore
+	repoOrder    repository.OrderStore
+	orderService *OrderService
+	invoices     *InvoiceService
+	credits      *CreditService
+	loyalty      *LoyaltyService
+	gateway      PaymentGateway
+	mutex        sync.Mutex
+}
+
+func NewPaymentService(repo repository.PaymentStore, repoRefund repository.RefundStore, repoOrder repository.OrderStore, orderService *OrderService, invoices *InvoiceService, credits *CreditService, loyalty *LoyaltyService, gateway PaymentGateway) *PaymentService {
+	return &PaymentService{
+		repo:         repo,
+		repoRefund:   repoRefund,
+		repoOrder:    repoOrder,
+		orderService: orderService,
+		invoices:     invoices,
+		credits:      credits,
+		loyalty:      loyalty,
+		gateway:      gateway,
+	}
+}
+
+// PayOrder authorizes and captures the total of a pending order, less the gift
+// card and store credit applied to it. The payment is recorded before the
+// gateway is called, so that an order can't be paid twice concurrently;
+// declines and gateway failures are recorded on the payment rather than
+// returned as errors. An authorization whose capture fails is voided, and one
+// whose capture times out stays authorized until the webhook settles it.
+// Orders paid in full with credits skip the gateway.
+func (s *PaymentService) PayOrder(ctx context.Context, orderID int, paymentInput model.PaymentInput) (model.Payment, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Payment{}, err
+	}
+
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return model.Payment{}, errors.New("order non existant")
+	}
+	if order.Status != model.OrderPending {
+		return model.Payment{}, fmt.Errorf("order is %s, only pending orders can be paid", order.Status)
+	}
+
+	if paymentInput.Method == "" {
+		paymentInput.Method = "card"
+	}
+
+	payment, err := s.startPayment(ctx, order, paymentInput)
+	if err != nil {
+		return model.Payment{}, err
+	}
+	if payment.Amount.IsZero() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return s.applyStatus(ctx, payment, model.PaymentCaptured, "")
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+
+	result, err := s.gateway.Authorize(gatewayCtx, GatewayRequest{
+		OrderID: order.ID,
+		Amount:  payment.Amount,
+		Method:  paymentInput.Method,
+		Token:   paymentInput.Token,
+	})
+	if err != nil {
+		// nothing was reserved under a reference we know of, so the customer may
+		// try again
+		result = GatewayResult{Status: model.PaymentFailed, Message: err.Error()}
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Message = "payment gateway timed out"
+		}
+	} else if result.Status == model.PaymentAuthorized {
+		payment.Reference = result.Reference
+		result, err = s.gateway.Capture(gatewayCtx, result.Reference, payment.Amount)
+		if err != nil && errors.Is(err, context.DeadlineExceeded) {
+			// the capture may still land at the provider, so the payment stays
+			// authorized until the webhook settles it instead of inviting a
+			// second charge
+			result = GatewayResult{Status: model.PaymentAuthorized}
+		} else if err != nil || result.Status != model.PaymentCaptured {
+			return s.voidPayment(ctx, payment, err)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if result.Reference != "" {
+		payment.Reference = result.Reference
+	}
+	return s.applyStatus(ctx, payment, result.Status, result.Message)
+}
+
+// voidPayment releases the authorization of a payment whose capture failed and
+// records it as failed. When the void fails too the payment stays authorized,
+// so that it is not paid a second time while the amount is still held.
+func (s *PaymentService) voidPayment(ctx context.Context, payment model.Payment, captureErr error) (model.Payment, error) {
+	message := "capture failed, authorization voided"
+	if captureErr != nil {
+		message = fmt.Sprintf("capture failed (%v), authorization voided", captureErr)
+	}
+
+	voidCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+	_, voidErr := s.gateway.Void(voidCtx, payment.Reference)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if voidErr != nil {
+		updated, err := s.applyStatus(ctx, payment, model.PaymentAuthorized, "")
+		if err != nil {
+			return model.Payment{}, err
+		}
+		return updated, fmt.Errorf("capture failed and the authorization could not be voided: %w", voidErr)
+	}
+	return s.applyStatus(ctx, payment, model.PaymentFailed, message)
+}
+
+// HandleWebhook applies an asynchronous confirmation from the gateway to the
+// payment it refers to. Events are idempotent, so that the gateway may deliver
+// them more than once.
+func (s *PaymentService) HandleWebhook(ctx context.Context, payload []byte, signature string) (model.Payment, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Payment{}, err
+	}
+
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return model.Payment{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payments, err := s.repo.SearchPayments(ctx, map[string]string{"reference": event.Reference})
+	if err != nil {
+		return model.Payment{}, err
+	}
+	if len(payments) == 0 {
+		return model.Payment{}, errors.New("payment non existant")
+	}
+
+	payment := payments[0]
+	if payment.Status == event.Status {
+		return payment, nil
+	}
+	if payment.Status != model.PaymentPending && payment.Status != model.PaymentAuthorized {
+		return model.Payment{}, fmt.Errorf("payment is already %s", payment.Status)
+	}
+
+	return s.applyStatus(ctx, payment, event.Status, event.Message)
+}
+
+func (s *PaymentService) GetOrderPayments(ctx context.Context, orderID int) ([]model.Payment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoOrder.GetOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SearchPayments(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+}
+
+// RefundOrder pays part or all of what was paid for an order back through the
+// gateway or as store credit, records the refund against the order and claws
+// back the share of the loyalty points earned on it.
+// returnID links the refund to the return request it settles, if any.
+func (s *PaymentService) RefundOrder(ctx context.Context, orderID int, refundInput model.RefundInput, returnID int) (model.Refund, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Refund{}, err
+	}
+
+	if refundInput.Amount <= 0 {
+		return model.Refund{}, errors.New("refund amount must be positive")
+	}
+	if refundInput.Reason == "" {
+		return model.Refund{}, errors.New("refund reason is mandatory")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return model.Refund{}, errors.New("order non existant")
+	}
+
+	payments, err := s.repo.SearchPayments(ctx, map[string]string{
+		"order_id": strconv.Itoa(orderID),
+		"status":   model.PaymentCaptured,
+	})
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if len(payments) == 0 {
+		return model.Refund{}, errors.New("order has no captured payment to refund")
+	}
+	payment := payments[0]
+
+	amount := model.NewMoney(refundInput.Amount, payment.Amount.Currency)
+	paid, err := payment.Amount.Add(order.CreditApplied)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	refundable, err := paid.Sub(order.Refunded)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if amount.Amount > refundable.Amount {
+		return model.Refund{}, fmt.Errorf("refund amount exceeds the refundable %s", refundable)
+	}
+
+	refund := model.Refund{
+		OrderID:     orderID,
+		PaymentID:   payment.ID,
+		ReturnID:    returnID,
+		Amount:      amount,
+		Tax:         refundedTax(order, amount),
+		Reason:      refundInput.Reason,
+		StoreCredit: refundInput.ToStoreCredit,
+		CreatedAt:   time.Now(),
+	}
+	if refundInput.ToStoreCredit {
+		refund, err = s.refundToStoreCredit(ctx, order, refund)
+	} else {
+		refund, err = s.refundThroughGateway(ctx, payment, refund)
+	}
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if order.Refunded, err = order.Refunded.Add(refund.Amount); err != nil {
+		return refund, fmt.Errorf("refund made but it could not be recorded on the order: %w", err)
+	}
+	if _, err := s.repoOrder.UpdateOrder(ctx, orderID, order); err != nil {
+		return model.Refund{}, err
+	}
+	if _, err := s.invoices.IssueCreditNote(ctx, refund); err != nil {
+		return refund, fmt.Errorf("refund made but the credit note could not be issued: %w", err)
+	}
+	if err := s.loyalty.ClawBack(ctx, order, refund); err != nil {
+		return refund, fmt.Errorf("refund made but the loyalty points could not be clawed back: %w", err)
+	}
+	return refund, nil
+}
+
+// refundThroughGateway pays the refund back to the payment it refunds. Only the
+// part of the order paid through the gateway can be refunded this way, the
+// rest goes back as store credit. Callers hold the mutex.
+func (s *PaymentService) refundThroughGateway(ctx context.Context, payment model.Payment, refund model.Refund) (model.Refund, error) {
+	refunds, err := s.repoRefund.SearchRefunds(ctx, map[string]string{"payment_id": strconv.Itoa(payment.ID)})
+	if err != nil {
+		return model.Refund{}, err
+	}
+	refundable := payment.Amount
+	for _, previous := range refunds {
+		if previous.StoreCredit {
+			continue
+		}
+		if refundable, err = refundable.Sub(previous.Amount); err != nil {
+			return model.Refund{}, err
+		}
+	}
+	if refund.Amount.Amount > refundable.Amount {
+		return model.Refund{}, fmt.Errorf("only %s can be refunded through the payment gateway, refund the rest to store credit", refundable)
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, paymentGatewayTimeout)
+	defer cancel()
+
+	result, err := s.gateway.Refund(gatewayCtx, payment.Reference, refund.Amount)
+	if err != nil {
+		return model.Refund{}, fmt.Errorf("refund failed: %v", err)
+	}
+
+	refund.Reference = result.Reference
+	return s.repoRefund.CreateRefund(ctx, refund)
+}
+
+// refundToStoreCredit pays the refund back as store credit of the customer.
+// Callers hold the mutex.
+func (s *PaymentService) refundToStoreCredit(ctx context.Context, order model.Order, refund model.Refund) (model.Refund, error) {
+	refund, err := s.repoRefund.CreateRefund(ctx, refund)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	if _, err := s.credits.RefundToStoreCredit(ctx, order, refund); err != nil {
+		s.repoRefund.DeleteRefund(ctx, refund.ID)
+		return model.Refund{}, err
+	}
+	return refund, nil
+}
+
+func (s *PaymentService) GetOrderRefunds(ctx context.Context, orderID int) ([]model.Refund, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoOrder.GetOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.repoRefund.SearchRefunds(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+}
+
+// startPayment applies the credits asked for to the order and records a pending
+// payment for the rest, refusing to do so while another payment of the order
+// is in progress or already succeeded.
+func (s *PaymentService) startPayment(ctx context.Context, order model.Order, paymentInput model.PaymentInput) (model.Payment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payments, err := s.repo.SearchPayments(ctx, map[string]string{"order_id": strconv.Itoa(order.ID)})
+	if err != nil {
+		return model.Payment{}, err
+	}
+	for _, payment := range payments {
+		switch payment.Status {
+		case model.PaymentPending, model.PaymentAuthorized:
+			return model.Payment{}, errors.New("a payment of this order is already in progress")
+		case model.PaymentCaptured:
+			return model.Payment{}, errors.New("order is already paid")
+		}
+	}
+
+	if paymentInput.GiftCardCode != "" || paymentInput.UseStoreCredit {
+		order, err = s.orderService.ApplyCredits(ctx, order.ID, paymentInput.GiftCardCode, paymentInput.UseStoreCredit)
+		if err != nil {
+			return model.Payment{}, err
+		}
+	}
+
+	amount, err := orderAmountDue(order)
+	if err != nil {
+		return model.Payment{}, err
+	}
+	gateway := s.gateway.Name()
+	if amount.IsZero() {
+		paymentInput.Method = "credit"
+		gateway = ""
+	}
+
+	now := time.Now()
+	return s.repo.CreatePayment(ctx, model.Payment{
+		OrderID:   order.ID,
+		Amount:    amount,
+		Method:    paymentInput.Method,
+		Gateway:   gateway,
+		Status:    model.PaymentPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// applyStatus records the new status of a payment, and marks its order as paid,
+// invoices it and awards its loyalty points once the payment is captured.
+// Callers hold the mutex.
+func (s *PaymentService) applyStatus(ctx context.Context, payment model.Payment, status string, message string) (model.Payment, error) {
+	payment.Status = status
+	payment.FailureReason = ""
+	if status == model.PaymentDeclined || status == model.PaymentFailed {
+		payment.FailureReason = message
+	}
+	payment.UpdatedAt = time.Now()
+
+	updated, err := s.repo.UpdatePayment(ctx, payment.ID, payment)
+	if err != nil {
+		return model.Payment{}, err
+	}
+
+	if updated.Status == model.PaymentCaptured {
+		if _, err := s.orderService.ConfirmPayment(ctx, updated.OrderID); err != nil {
+			return updated, fmt.Errorf("payment captured but the order could not be confirmed: %w", err)
+		}
+		if _, err := s.invoices.IssueInvoice(ctx, updated.OrderID); err != nil {
+			return updated, fmt.Errorf("payment captured but the invoice could not be issued: %w", err)
+		}
+		if _, err := s.loyalty.AwardPoints(ctx, updated.OrderID, model.LoyaltyAwardOnPaid); err != nil {
+			return updated, fmt.Errorf("payment captured but the loyalty points could not be awarded: %w", err)
+		}
+	}
+	return updated, nil
+}
+
+// refundedTax is the part of a refund that gives back tax, in proportion to the
+// share of tax in the order's grand total.
+func refundedTax(order model.Order, amount model.Money) model.Money {
+	if order.Tax.IsZero() || order.GrandTotal.IsZero() {
+		return model.Money{Currency: amount.Currency}
+	}
+	return amount.MulRate(float64(order.Tax.Amount) / float64(order.GrandTotal.Amount))
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromotionService manages coupons and promotion rules, and prices orders with
+// them together with the sale prices of books.
+type PromotionService struct {
+	repoCoupon repository.CouponStore
+	repoRule   repository.PromotionRuleStore
+	repoBook   repository.BookStore
+	repoAuthor repository.AuthorStore
+	currencies *CurrencyService
+	mutex      sync.Mutex
+}
+
+func NewPromotionService(repoCoupon repository.CouponStore, repoRule repository.PromotionRuleStore, repoBook repository.BookStore, repoAuthor repository.AuthorStore, currencies *CurrencyService) *PromotionService {
+	return &PromotionService{
+		repoCoupon: repoCoupon,
+		repoRule:   repoRule,
+		repoBook:   repoBook,
+		repoAuthor: repoAuthor,
+		currencies: currencies,
+	}
+}
+
+func (s *PromotionService) CreateCoupon(ctx context.Context, couponInput model.CouponInput) (model.Coupon, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Coupon{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	coupon := model.Coupon{
+		Code:       strings.TrimSpace(couponInput.Code),
+		Type:       couponInput.Type,
+		Value:      couponInput.Value,
+		StartsAt:   couponInput.StartsAt,
+		ExpiresAt:  couponInput.ExpiresAt,
+		UsageLimit: couponInput.UsageLimit,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.validateCoupon(ctx, coupon, 0); err != nil {
+		return model.Coupon{}, err
+	}
+
+	return s.repoCoupon.CreateCoupon(ctx, coupon)
+}
+
+func (s *PromotionService) GetCoupon(ctx context.Context, id int) (model.Coupon, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Coupon{}, err
+	}
+	return s.repoCoupon.GetCoupon(ctx, id)
+}
+
+func (s *PromotionService) UpdateCoupon(ctx context.Context, id int, couponInput model.CouponInput) (model.Coupon, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Coupon{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existingCoupon, err := s.repoCoupon.GetCoupon(ctx, id)
+	if err != nil {
+		return model.Coupon{}, err
+	}
+
+	updatedCoupon := model.Coupon{
+		ID:         existingCoupon.ID,
+		Code:       strings.TrimSpace(couponInput.Code),
+		Type:       couponInput.Type,
+		Value:      couponInput.Value,
+		StartsAt:   couponInput.StartsAt,
+		ExpiresAt:  couponInput.ExpiresAt,
+		UsageLimit: couponInput.UsageLimit,
+		UsageCount: existingCoupon.UsageCount,
+		CreatedAt:  existingCoupon.CreatedAt,
+	}
+	if err := s.validateCoupon(ctx, updatedCoupon, id); err != nil {
+		return model.Coupon{}, err
+	}
+
+	return s.repoCoupon.UpdateCoupon(ctx, id, updatedCoupon)
+}
+
+func (s *PromotionService) DeleteCoupon(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repoCoupon.DeleteCoupon(ctx, id)
+}
+
+func (s *PromotionService) SearchCoupons(ctx context.Context, params map[string]string) ([]model.Coupon, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repoCoupon.SearchCoupons(ctx, params)
+}
+
+func (s *PromotionService) CreatePromotionRule(ctx context.Context, ruleInput model.PromotionRuleInput) (model.PromotionRule, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PromotionRule{}, err
+	}
+
+	rule := promotionRuleFromInput(ruleInput)
+	rule.CreatedAt = time.Now()
+	if err := s.validatePromotionRule(ctx, rule); err != nil {
+		return model.PromotionRule{}, err
+	}
+
+	return s.repoRule.CreatePromotionRule(ctx, rule)
+}
+
+func (s *PromotionService) GetPromotionRule(ctx context.Context, id int) (model.PromotionRule, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PromotionRule{}, err
+	}
+	return s.repoRule.GetPromotionRule(ctx, id)
+}
+
+func (s *PromotionService) UpdatePromotionRule(ctx context.Context, id int, ruleInput model.PromotionRuleInput) (model.PromotionRule, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PromotionRule{}, err
+	}
+
+	existingRule, err := s.repoRule.GetPromotionRule(ctx, id)
+	if err != nil {
+		return model.PromotionRule{}, err
+	}
+
+	rule := promotionRuleFromInput(ruleInput)
+	rule.ID = existingRule.ID
+	rule.CreatedAt = existingRule.CreatedAt
+	if err := s.validatePromotionRule(ctx, rule); err != nil {
+		return model.PromotionRule{}, err
+	}
+
+	return s.repoRule.UpdatePromotionRule(ctx, id, rule)
+}
+
+func (s *PromotionService) DeletePromotionRule(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repoRule.DeletePromotionRule(ctx, id)
+}
+
+func (s *PromotionService) SearchPromotionRules(ctx context.Context, params map[string]string) ([]model.PromotionRule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repoRule.SearchPromotionRules(ctx, params)
+}
+
+// RedeemCoupon checks that a coupon can be used now and counts one more use of
+// it. An empty code redeems nothing and returns no coupon.
+func (s *PromotionService) RedeemCoupon(ctx context.Context, code string) (*model.Coupon, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	coupon, err := s.findCoupon(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return nil, fmt.Errorf("coupon %s is not valid yet", coupon.Code)
+	}
+	if coupon.ExpiresAt != nil && now.After(*coupon.ExpiresAt) {
+		return nil, fmt.Errorf("coupon %s has expired", coupon.Code)
+	}
+	if coupon.UsageLimit > 0 && coupon.UsageCount >= coupon.UsageLimit {
+		return nil, fmt.Errorf("coupon %s has reached its usage limit", coupon.Code)
+	}
+
+	coupon.UsageCount++
+	redeemed, err := s.repoCoupon.UpdateCoupon(ctx, coupon.ID, coupon)
+	if err != nil {
+		return nil, err
+	}
+	return &redeemed, nil
+}
+
+// ReleaseCoupon gives back a use of a coupon, when the order that redeemed it
+// is dropped.
+func (s *PromotionService) ReleaseCoupon(ctx context.Context, code string) error {
+	if code == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	coupon, err := s.findCoupon(ctx, code)
+	if err != nil {
+		return err
+	}
+	if coupon.UsageCount > 0 {
+		coupon.UsageCount--
+	}
+	_, err = s.repoCoupon.UpdateCoupon(ctx, coupon.ID, coupon)
+	return err
+}
+
+// LookupCoupon returns the coupon with the given code, whether it can still be
+// redeemed or not. An empty code returns no coupon.
+func (s *PromotionService) LookupCoupon(ctx context.Context, code string) (*model.Coupon, error) {
+	if code == "" {
+		return nil, nil
+	}
+	coupon, err := s.findCoupon(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// PriceItems prices order lines in the currency at the list price of their
+// book, then applies in turn the current sale price of the book, the best
+// promotion rule matching it and the coupon, if any. Every reduction is recorded
+// on its line.
+func (s *PromotionService) PriceItems(ctx context.Context, items []model.OrderItem, coupon *model.Coupon, currency string) ([]model.OrderItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rules, err := s.repoRule.SearchPromotionRules(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := make([]model.OrderItem, 0, len(items))
+	remaining := make([]model.Money, 0, len(items))
+	for _, item := range items {
+		book, err := s.repoBook.GetBook(ctx, item.BookID)
+		if err != nil {
+			return nil, err
+		}
+
+		listPrice, err := s.bookPrice(book, currency)
+		if err != nil {
+			return nil, err
+		}
+		item.UnitPrice = listPrice
+		item.Discounts = nil
+		unitPrice := listPrice
+
+		salePrice, ok, err := s.currentSalePrice(book, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok && salePrice.Amount < listPrice.Amount {
+			saving, err := listPrice.Sub(salePrice)
+			if err != nil {
+				return nil, err
+			}
+			item.Discounts = append(item.Discounts, model.Discount{
+				Source: model.DiscountSourceSale,
+				Name:   "sale price",
+				Amount: saving.Mul(item.Quantity),
+			})
+			unitPrice = salePrice
+		}
+
+		best := model.Discount{Amount: model.Money{Currency: listPrice.Currency}}
+		for _, rule := range rules {
+			if !isPromotionRuleActive(rule, now) || !promotionRuleMatches(rule, book) {
+				continue
+			}
+			if amount := promotionRuleDiscount(rule, unitPrice, item.Quantity); amount.Amount > best.Amount.Amount {
+				best = model.Discount{Source: model.DiscountSourceRule, Name: rule.Name, Amount: amount}
+			}
+		}
+		if best.Amount.Amount > 0 {
+			item.Discounts = append(item.Discounts, best)
+		}
+
+		left, err := unitPrice.Mul(item.Quantity).Sub(best.Amount)
+		if err != nil {
+			return nil, err
+		}
+		priced = append(priced, item)
+		remaining = append(remaining, left)
+	}
+
+	if coupon != nil {
+		discounts, err := s.couponDiscounts(*coupon, remaining, currency)
+		if err != nil {
+			return nil, err
+		}
+		for i, amount := range discounts {
+			if amount.Amount > 0 {
+				priced[i].Discounts = append(priced[i].Discounts, model.Discount{
+					Source: model.DiscountSourceCoupon,
+					Name:   coupon.Code,
+					Amount: amount,
+				})
+			}
+		}
+	}
+
+	for i := range priced {
+		lineTotal := priced[i].UnitPrice.Mul(priced[i].Quantity)
+		for _, discount := range priced[i].Discounts {
+			var err error
+			if lineTotal, err = lineTotal.Sub(discount.Amount); err != nil {
+				return nil, err
+			}
+		}
+		priced[i].LineTotal = lineTotal
+	}
+	return priced, nil
+}
+
+func (s *PromotionService) findCoupon(ctx context.Context, code string) (model.Coupon, error) {
+	coupons, err := s.repoCoupon.SearchCoupons(ctx, map[string]string{"code": strings.TrimSpace(code)})
+	if err != nil {
+		return model.Coupon{}, err
+	}
+	if len(coupons) == 0 {
+		return model.Coupon{}, errors.New("coupon non existant")
+	}
+	return coupons[0], nil
+}
+
+func (s *PromotionService) validateCoupon(ctx context.Context, coupon model.Coupon, id int) error {
+	if coupon.Code == "" {
+		return errors.New("coupon code is mandatory")
+	}
+	switch coupon.Type {
+	case model.DiscountPercentage:
+		if coupon.Value <= 0 || coupon.Value > 100 {
+			return errors.New("percentage must be between 0 and 100")
+		}
+	case model.DiscountFixed:
+		if coupon.Value <= 0 {
+			return errors.New("coupon value must be positive")
+		}
+	default:
+		return errors.New("coupon type must be percentage or fixed")
+	}
+	if coupon.UsageLimit < 0 {
+		return errors.New("usage limit cannot be negative")
+	}
+	if coupon.StartsAt != nil && coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(*coupon.StartsAt) {
+		return errors.New("coupon must expire after it starts")
+	}
+
+	if existing, err := s.findCoupon(ctx, coupon.Code); err == nil && existing.ID != id {
+		return fmt.Errorf("coupon code %s is already used", coupon.Code)
+	}
+	return nil
+}
+
+func (s *PromotionService) validatePromotionRule(ctx context.Context, rule model.PromotionRule) error {
+	if rule.Name == "" {
+		return errors.New("promotion name is mandatory")
+	}
+	switch rule.Type {
+	case model.DiscountPercentage:
+		if rule.Percentage <= 0 || rule.Percentage > 100 {
+			return errors.New("percentage must be between 0 and 100")
+		}
+	case model.DiscountBuyXGetY:
+		if rule.BuyQuantity <= 0 || rule.FreeQuantity <= 0 {
+			return errors.New("buy and free quantities must be positive")
+		}
+	default:
+		return errors.New("promotion type must be percentage or buy_x_get_y")
+	}
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+
+	if rule.BookID != 0 {
+		if _, err := s.repoBook.GetBook(ctx, rule.BookID); err != nil {
+			return errors.New("book non existant")
+		}
+	}
+	if rule.AuthorID != 0 {
+		if _, err := s.repoAuthor.GetAuthor(ctx, rule.AuthorID); err != nil {
+			return errors.New("author not found")
+		}
+	}
+	return nil
+}
+
+func promotionRuleFromInput(ruleInput model.PromotionRuleInput) model.PromotionRule {
+	return model.PromotionRule{
+		Name:         ruleInput.Name,
+		Type:         ruleInput.Type,
+		Percentage:   ruleInput.Percentage,
+		BuyQuantity:  ruleInput.BuyQuantity,
+		FreeQuantity: ruleInput.FreeQuantity,
+		Genre:        ruleInput.Genre,
+		BookID:       ruleInput.BookID,
+		AuthorID:     ruleInput.AuthorID,
+		StartsAt:     ruleInput.StartsAt,
+		EndsAt:       ruleInput.EndsAt,
+	}
+}
+
+// CurrentPrice returns the price the book sells for now in the currency: its
+// list price, or the sale price running when it is lower.
+func (s *PromotionService) CurrentPrice(book model.Book, currency string) (model.Money, error) {
+	price, err := s.bookPrice(book, currency)
+	if err != nil {
+		return model.Money{}, err
+	}
+	salePrice, onSale, err := s.currentSalePrice(book, currency, time.Now())
+	if err != nil {
+		return model.Money{}, err
+	}
+	if onSale && salePrice.Amount < price.Amount {
+		return salePrice, nil
+	}
+	return price, nil
+}
+
+// bookPrice returns the list price of the book in the currency, converting the
+// main price of the book when it has no price listed in the currency.
+func (s *PromotionService) bookPrice(book model.Book, currency string) (model.Money, error) {
+	book.Price = s.currencies.Normalize(book.Price)
+	if price, ok := bookListPrice(book, currency); ok {
+		return price, nil
+	}
+	return s.currencies.Convert(book.Price, currency)
+}
+
+// currentSalePrice returns the sale price of the book in the currency running
+// at the given time; when sales overlap the lowest price wins. Books without a
+// price listed in the currency have the sales of their main price converted.
+func (s *PromotionService) currentSalePrice(book model.Book, currency string, now time.Time) (model.Money, bool, error) {
+	book.Price = s.currencies.Normalize(book.Price)
+	saleCurrency := currency
+	if _, ok := bookListPrice(book, currency); !ok {
+		saleCurrency = book.Price.Currency
+	}
+
+	var price model.Money
+	found := false
+	for _, sale := range book.SalePrices {
+		sale.Price = s.currencies.Normalize(sale.Price)
+		if sale.Price.Currency != saleCurrency {
+			continue
+		}
+		if now.Before(sale.StartsAt) || (sale.EndsAt != nil && now.After(*sale.EndsAt)) {
+			continue
+		}
+		if !found || sale.Price.Amount < price.Amount {
+			price, found = sale.Price, true
+		}
+	}
+	if !found {
+		return model.Money{}, false, nil
+	}
+
+	converted, err := s.currencies.Convert(price, currency)
+	if err != nil {
+		return model.Money{}, false, err
+	}
+	return converted, true, nil
+}
+
+func isPromotionRuleActive(rule model.PromotionRule, now time.Time) bool {
+	if rule.StartsAt != nil && now.Before(*rule.StartsAt) {
+		return false
+	}
+	return rule.EndsAt == nil || !now.After(*rule.EndsAt)
+}
+
+// bookHasAuthor tells whether the author is one of the authors of the book.
+func bookHasAuthor(book model.Book, authorID int) bool {
+	for _, contributor := range book.Contributors {
+		if contributor.AuthorID == authorID && contributor.Role == model.ContributorAuthor {
+			return true
+		}
+	}
+	return false
+}
+
+func promotionRuleMatches(rule model.PromotionRule, book model.Book) bool {
+	if rule.BookID != 0 && rule.BookID != book.ID {
+		return false
+	}
+	if rule.AuthorID != 0 && !bookHasAuthor(book, rule.AuthorID) {
+		return false
+	}
+	if rule.Genre != "" {
+		for _, genre := range book.Genres {
+			if strings.EqualFold(genre, rule.Genre) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func promotionRuleDiscount(rule model.PromotionRule, unitPrice model.Money, quantity int) model.Money {
+	switch rule.Type {
+	case model.DiscountPercentage:
+		return unitPrice.Mul(quantity).MulRate(rule.Percentage / 100)
+	case model.DiscountBuyXGetY:
+		free := quantity / (rule.BuyQuantity + rule.FreeQuantity) * rule.FreeQuantity
+		return unitPrice.Mul(free)
+	}
+	return model.Money{Currency: unitPrice.Currency}
+}
+
+// couponDiscounts splits the discount of a coupon over the lines of an order,
+// given the amount left to pay on each line. A fixed amount is converted into
+// the currency of the order and spread over the lines.
+func (s *PromotionService) couponDiscounts(coupon model.Coupon, remaining []model.Money, currency string) ([]model.Money, error) {
+	if coupon.Type == model.DiscountPercentage {
+		discounts := make([]model.Money, len(remaining))
+		for i, amount := range remaining {
+			discounts[i] = amount.MulRate(coupon.Value / 100)
+		}
+		return discounts, nil
+	}
+
+	value, err := s.currencies.Convert(model.NewMoney(coupon.Value, s.currencies.BaseCurrency()), currency)
+	if err != nil {
+		return nil, err
+	}
+	return spreadDiscount(value, remaining), nil
+}
+
+// spreadDiscount spreads an amount off an order over its lines in proportion
+// to the amount left to pay on each, the last line taking the rounding
+// difference. The amount is capped at the total left to pay.
+func spreadDiscount(value model.Money, remaining []model.Money) []model.Money {
+	discounts := make([]model.Money, len(remaining))
+	var total int64
+	for i, amount := range remaining {
+		total += amount.Amount
+		discounts[i] = model.Money{Currency: amount.Currency}
+	}
+	if total <= 0 {
+		return discounts
+	}
+	if value.Amount > total {
+		value.Amount = total
+	}
+
+	left := value.Amount
+	last := -1
+	for i, amount := range remaining {
+		if amount.Amount > 0 {
+			last = i
+		}
+	}
+	for i, amount := range remaining {
+		if i == last {
+			discounts[i].Amount = left
+			break
+		}
+		discounts[i].Amount = int64(math.Round(float64(value.Amount) * float64(amount.Amount) / float64(total)))
+		left -= discounts[i].Amount
+	}
+	return discounts
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+)
+
+type PublisherService struct {
+	repo      repository.PublisherStore
+	repoBook  repository.BookStore
+	currentID int
+}
+
+func NewPublisherService(repo repository.PublisherStore, repoBook repository.BookStore) *PublisherService {
+	return &PublisherService{
+		repo:      repo,
+		repoBook:  repoBook,
+		currentID: 1,
+	}
+}
+
+func (s *PublisherService) CreatePublisher(ctx context.Context, publisherInput model.PublisherInput) (model.Publisher, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Publisher{}, err
+	}
+	publisher := model.Publisher{
+		ID:      s.currentID,
+		Name:    publisherInput.Name,
+		Country: publisherInput.Country,
+		Website: publisherInput.Website,
+	}
+	s.currentID++
+
+	if publisher.Name == "" {
+		return model.Publisher{}, errors.New("publisher name is mandatory")
+	}
+
+	return s.repo.CreatePublisher(ctx, publisher)
+}
+
+func (s *PublisherService) GetPublisher(ctx context.Context, id int) (model.Publisher, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Publisher{}, err
+	}
+	return s.repo.GetPublisher(ctx, id)
+}
+
+// UpdatePublisher also renames the publisher on its books.
+func (s *PublisherService) UpdatePublisher(ctx context.Context, id int, publisherInput model.PublisherInput) (model.Publisher, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Publisher{}, err
+	}
+
+	existingPublisher, err := s.repo.GetPublisher(ctx, id)
+	if err != nil {
+		return model.Publisher{}, err
+	}
+
+	updatedPublisher := model.Publisher{
+		ID:      existingPublisher.ID,
+		Name:    publisherInput.Name,
+		Country: publisherInput.Country,
+		Website: publisherInput.Website,
+	}
+
+	if updatedPublisher.Name == "" {
+		return model.Publisher{}, errors.New("publisher name is mandatory")
+	}
+
+	updated, err := s.repo.UpdatePublisher(ctx, id, updatedPublisher)
+	if err != nil || updated.Name == existingPublisher.Name {
+		return updated, err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, map[string]string{"publisher_id": strconv.Itoa(id)})
+	if err != nil {
+		return updated, err
+	}
+	for _, book := range books {
+		book.Publisher = updated.Name
+		if _, err := s.repoBook.UpdateBook(ctx, book.ID, book); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// DeletePublisher refuses to delete a publisher that still has books.
+func (s *PublisherService) DeletePublisher(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetPublisher(ctx, id); err != nil {
+		return err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, map[string]string{"publisher_id": strconv.Itoa(id)})
+	if err != nil {
+		return err
+	}
+	if len(books) > 0 {
+		return errors.New("publisher still has books")
+	}
+
+	return s.repo.DeletePublisher(ctx, id)
+}
+
+func (s *PublisherService) SearchPublishers(ctx context.Context, params map[string]string) ([]model.Publisher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchPublishers(ctx, params)
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type PurchaseOrderService struct {
+	repo          repository.PurchaseOrderStore
+	repoSupplier  repository.SupplierStore
+	repoBook      repository.BookStore
+	repoWarehouse repository.WarehouseStore
+	stock         *StockService
+	currencies    *CurrencyService
+}
+
+func NewPurchaseOrderService(repo repository.PurchaseOrderStore, repoSupplier repository.SupplierStore, repoBook repository.BookStore, repoWarehouse repository.WarehouseStore, stock *StockService, currencies *CurrencyService) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		repo:          repo,
+		repoSupplier:  repoSupplier,
+		repoBook:      repoBook,
+		repoWarehouse: repoWarehouse,
+		stock:         stock,
+		currencies:    currencies,
+	}
+}
+
+func (s *PurchaseOrderService) CreatePurchaseOrder(ctx context.Context, input model.PurchaseOrderInput) (model.PurchaseOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	items, err := s.buildItems(ctx, input)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	totalCost, err := calculateTotalCost(items)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	purchaseOrder := model.PurchaseOrder{
+		SupplierID:  input.SupplierID,
+		WarehouseID: input.WarehouseID,
+		Items:       items,
+		TotalCost:   totalCost,
+		Status:      model.PurchaseOrderPending,
+		CreatedAt:   time.Now(),
+	}
+
+	return s.repo.CreatePurchaseOrder(ctx, purchaseOrder)
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(ctx context.Context, id int) (model.PurchaseOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	return s.repo.GetPurchaseOrder(ctx, id)
+}
+
+func (s *PurchaseOrderService) UpdatePurchaseOrder(ctx context.Context, id int, input model.PurchaseOrderInput) (model.PurchaseOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	existingPurchaseOrder, err := s.repo.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if existingPurchaseOrder.Status != model.PurchaseOrderPending {
+		return model.PurchaseOrder{}, errors.New("only pending purchase orders can be modified")
+	}
+
+	items, err := s.buildItems(ctx, input)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	totalCost, err := calculateTotalCost(items)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	updatedPurchaseOrder := model.PurchaseOrder{
+		ID:          existingPurchaseOrder.ID,
+		SupplierID:  input.SupplierID,
+		WarehouseID: input.WarehouseID,
+		Items:       items,
+		TotalCost:   totalCost,
+		Status:      existingPurchaseOrder.Status,
+		CreatedAt:   existingPurchaseOrder.CreatedAt,
+	}
+
+	return s.repo.UpdatePurchaseOrder(ctx, id, updatedPurchaseOrder)
+}
+
+func (s *PurchaseOrderService) DeletePurchaseOrder(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	purchaseOrder, err := s.repo.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+	if purchaseOrder.Status != model.PurchaseOrderPending {
+		return errors.New("purchase orders with received items cannot be deleted")
+	}
+
+	return s.repo.DeletePurchaseOrder(ctx, id)
+}
+
+func (s *PurchaseOrderService) SearchPurchaseOrders(ctx context.Context, params map[string]string) ([]model.PurchaseOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchPurchaseOrders(ctx, params)
+}
+
+// ReceivePurchaseOrder books the received quantities against the purchase order
+// and adds them to the stock of each book, recording a receipt movement per line
+// in the order of the purchase order.
+func (s *PurchaseOrderService) ReceivePurchaseOrder(ctx context.Context, id int, receipt model.PurchaseOrderReceipt) (model.PurchaseOrder, error) {
+	if err := ctx.Err(); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+
+	purchaseOrder, err := s.repo.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if purchaseOrder.Status == model.PurchaseOrderReceived {
+		return model.PurchaseOrder{}, errors.New("purchase order is already fully received")
+	}
+
+	// An empty receipt means everything still outstanding has arrived.
+	if len(receipt.Items) == 0 {
+		for _, item := range purchaseOrder.Items {
+			if remaining := item.Quantity - item.ReceivedQuantity; remaining > 0 {
+				receipt.Items = append(receipt.Items, model.PurchaseOrderReceiptItem{BookID: item.BookID, Quantity: remaining})
+			}
+		}
+	}
+
+	// Validate the whole receipt first so that a bad line leaves nothing half applied.
+	lines := make([]int, len(purchaseOrder.Items))
+	for _, receiptItem := range receipt.Items {
+		if receiptItem.Quantity <= 0 {
+			return model.PurchaseOrder{}, errors.New("received quantity must be positive")
+		}
+		index := -1
+		for i, item := range purchaseOrder.Items {
+			if item.BookID == receiptItem.BookID {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return model.PurchaseOrder{}, fmt.Errorf("book %d is not part of this purchase order", receiptItem.BookID)
+		}
+		item := purchaseOrder.Items[index]
+		if item.ReceivedQuantity+lines[index]+receiptItem.Quantity > item.Quantity {
+			return model.PurchaseOrder{}, fmt.Errorf("received quantity for book %d exceeds the ordered quantity", receiptItem.BookID)
+		}
+		lines[index] += receiptItem.Quantity
+	}
+
+	// The lines recorded in stock are saved on the purchase order even when a
+	// later line fails, so that receiving it again doesn't add them twice.
+	var receiveErr, allocationErr error
+	received := 0
+	for index, quantity := range lines {
+		if quantity == 0 {
+			continue
+		}
+		item := &purchaseOrder.Items[index]
+
+		movement := model.StockMovement{
+			BookID:      item.BookID,
+			WarehouseID: purchaseOrder.WarehouseID,
+			Type:        model.StockMovementReceipt,
+			Quantity:    quantity,
+			Reason:      fmt.Sprintf("received from supplier %d", purchaseOrder.SupplierID),
+			Reference:   fmt.Sprintf("purchase_order:%d", purchaseOrder.ID),
+		}
+		recorded, err := s.stock.RecordMovement(ctx, movement)
+		if recorded.ID == 0 {
+			receiveErr = err
+			break
+		}
+		if err != nil {
+			allocationErr = err
+		}
+
+		item.ReceivedQuantity += quantity
+		received++
+	}
+	if received == 0 {
+		return model.PurchaseOrder{}, receiveErr
+	}
+	if receiveErr != nil {
+		receiveErr = fmt.Errorf("purchase order partially received: %w", receiveErr)
+	}
+
+	purchaseOrder.Status = model.PurchaseOrderReceived
+	for _, item := range purchaseOrder.Items {
+		if item.ReceivedQuantity < item.Quantity {
+			purchaseOrder.Status = model.PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+	if purchaseOrder.Status == model.PurchaseOrderReceived {
+		receivedAt := time.Now()
+		purchaseOrder.ReceivedAt = &receivedAt
+	}
+
+	updated, err := s.repo.UpdatePurchaseOrder(ctx, id, purchaseOrder)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	return updated, errors.Join(receiveErr, allocationErr)
+}
+
+func (s *PurchaseOrderService) buildItems(ctx context.Context, input model.PurchaseOrderInput) ([]model.PurchaseOrderItem, error) {
+	if input.SupplierID == 0 {
+		return nil, errors.New("supplier ID is mandatory")
+	}
+	if len(input.Items) == 0 {
+		return nil, errors.New("purchase order must have at least one item")
+	}
+
+	supplier, err := s.repoSupplier.GetSupplier(ctx, input.SupplierID)
+	if err != nil {
+		return nil, errors.New("supplier non existant")
+	}
+	if input.WarehouseID != 0 {
+		if _, err := s.repoWarehouse.GetWarehouse(ctx, input.WarehouseID); err != nil {
+			return nil, errors.New("warehouse non existant")
+		}
+	}
+
+	items := make([]model.PurchaseOrderItem, 0, len(input.Items))
+	seen := make(map[int]bool)
+	for _, itemInput := range input.Items {
+		if seen[itemInput.BookID] {
+			return nil, fmt.Errorf("book %d is listed more than once", itemInput.BookID)
+		}
+		seen[itemInput.BookID] = true
+
+		if itemInput.Quantity <= 0 {
+			return nil, errors.New("item quantity must be positive")
+		}
+		if itemInput.CostPrice.Amount < 0 {
+			return nil, errors.New("cost price is invalid")
+		}
+		if _, err := s.repoBook.GetBook(ctx, itemInput.BookID); err != nil {
+			return nil, errors.New("book non existant")
+		}
+
+		costPrice := itemInput.CostPrice
+		if costPrice.IsZero() {
+			costPrice, err = supplierCostPrice(supplier, itemInput.BookID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if costPrice.Currency, err = s.currencies.Currency(costPrice.Currency); err != nil {
+			return nil, err
+		}
+
+		items = append(items, model.PurchaseOrderItem{
+			BookID:    itemInput.BookID,
+			Quantity:  itemInput.Quantity,
+			CostPrice: costPrice,
+		})
+	}
+	return items, nil
+}
+
+func supplierCostPrice(supplier model.Supplier, bookID int) (model.Money, error) {
+	for _, price := range supplier.Catalog {
+		if price.BookID == bookID {
+			return price.CostPrice, nil
+		}
+	}
+	return model.Money{}, fmt.Errorf("no cost price for book %d in the catalog of supplier %d", bookID, supplier.ID)
+}
+
+// calculateTotalCost sums the cost of the lines, which are all in the currency
+// the purchase order is paid in.
+func calculateTotalCost(items []model.PurchaseOrderItem) (model.Money, error) {
+	var total model.Money
+	for _, item := range items {
+		var err error
+		if total, err = total.Add(item.CostPrice.Mul(item.Quantity)); err != nil {
+			return model.Money{}, fmt.Errorf("purchase order lines must share one currency: %w", err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReceivePurchaseOrderPartially(t *testing.T) {
+	// book 2 was deleted after it was ordered, so its line can't be received
+	books := &fakeBookStore{books: map[int]model.Book{
+		1: {ID: 1, Title: "Emma", PublishedAt: time.Now().AddDate(-1, 0, 0)},
+	}}
+	purchaseOrders := &fakePurchaseOrderStore{purchaseOrders: map[int]model.PurchaseOrder{
+		1: {
+			ID:          1,
+			SupplierID:  1,
+			WarehouseID: 1,
+			Items:       []model.PurchaseOrderItem{{BookID: 1, Quantity: 5}, {BookID: 2, Quantity: 3}},
+			Status:      model.PurchaseOrderPending,
+		},
+	}}
+	movements := &fakeStockMovementStore{}
+	wishlists := NewWishlistService(&fakeWishlistStore{}, books, &fakeCustomerStore{}, nil, nil, nil)
+	stock := NewStockService(movements, books, &fakeWarehouseStore{}, &fakeReservationStore{}, nil, nil, wishlists, nil, time.Hour)
+	service := NewPurchaseOrderService(purchaseOrders, nil, books, nil, stock, newTestCurrencies(t))
+
+	// the lines are received in the order of the purchase order, whatever the
+	// order of the receipt
+	receipt := model.PurchaseOrderReceipt{Items: []model.PurchaseOrderReceiptItem{{BookID: 2, Quantity: 3}, {BookID: 1, Quantity: 5}}}
+	purchaseOrder, err := service.ReceivePurchaseOrder(context.Background(), 1, receipt)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if purchaseOrder.ID != 1 {
+		t.Fatalf("got purchase order %d, want the partially received purchase order", purchaseOrder.ID)
+	}
+
+	saved := purchaseOrders.purchaseOrders[1]
+	if saved.Status != model.PurchaseOrderPartiallyReceived {
+		t.Errorf("status %q, want %q", saved.Status, model.PurchaseOrderPartiallyReceived)
+	}
+	if got := saved.Items[0].ReceivedQuantity; got != 5 {
+		t.Errorf("book 1 received %d, want 5", got)
+	}
+	if got := saved.Items[1].ReceivedQuantity; got != 0 {
+		t.Errorf("book 2 received %d, want 0", got)
+	}
+
+	// receiving what is still outstanding doesn't add book 1 again
+	if _, err := service.ReceivePurchaseOrder(context.Background(), 1, model.PurchaseOrderReceipt{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := books.books[1].Stock; got != 5 {
+		t.Errorf("stock of book 1 is %d, want 5", got)
+	}
+	if len(movements.movements) != 1 {
+		t.Errorf("%d movements recorded, want 1", len(movements.movements))
+	}
+}
+
+func TestCalculateTotalCost(t *testing.T) {
+	usd := func(amount int64) model.Money { return model.Money{Amount: amount, Currency: "USD"} }
+
+	tests := []struct {
+		name    string
+		items   []model.PurchaseOrderItem
+		want    model.Money
+		wantErr bool
+	}{
+		{
+			name:  "cost of every line",
+			items: []model.PurchaseOrderItem{{BookID: 1, Quantity: 3, CostPrice: usd(650)}, {BookID: 2, Quantity: 1, CostPrice: usd(1099)}},
+			want:  usd(3049),
+		},
+		{
+			name:    "lines in different currencies",
+			items:   []model.PurchaseOrderItem{{BookID: 1, Quantity: 1, CostPrice: usd(650)}, {BookID: 2, Quantity: 1, CostPrice: model.Money{Amount: 500, Currency: "EUR"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateTotalCost(tt.items)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
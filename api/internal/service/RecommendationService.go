@@ -0,0 +1,285 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRecommendations is the number of books recommended when the client
+// does not ask for another, and MaxRecommendations the most it may ask for.
+const (
+	DefaultRecommendations = 5
+	MaxRecommendations     = 20
+)
+
+// genreWeight is the score a book gets for every book of the customer's
+// history sharing one of its genres, against 1 for every order in which it was
+// bought together with one of those books.
+const genreWeight = 0.5
+
+// RecommendationService recommends books from the paid orders: books bought
+// in the same orders are related, and customers are offered the books related
+// to their purchases and the books of the genres they read. The co-occurrences
+// are recomputed in the background.
+type RecommendationService struct {
+	repoOrder    repository.OrderStore
+	repoBook     repository.BookStore
+	repoCustomer repository.CustomerStore
+	mutex        sync.RWMutex
+	together     map[int]map[int]int
+	sales        map[int]int
+}
+
+func NewRecommendationService(repoOrder repository.OrderStore, repoBook repository.BookStore, repoCustomer repository.CustomerStore) *RecommendationService {
+	return &RecommendationService{
+		repoOrder:    repoOrder,
+		repoBook:     repoBook,
+		repoCustomer: repoCustomer,
+		together:     make(map[int]map[int]int),
+		sales:        make(map[int]int),
+	}
+}
+
+// StartRecommendationRefresher computes the co-occurrences right away, then
+// again every interval.
+func (s *RecommendationService) StartRecommendationRefresher(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		logger.Printf("Error computing recommendations: %v\n", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				logger.Printf("Error computing recommendations: %v\n", err)
+			}
+		}
+	}
+}
+
+// Refresh counts in how many paid orders every two books were bought together,
+// and how many paid orders every book was bought in.
+func (s *RecommendationService) Refresh(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	orders, err := s.repoOrder.SearchOrders(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	together := make(map[int]map[int]int)
+	sales := make(map[int]int)
+	for _, order := range orders {
+		if !orderIsPaid(order) {
+			continue
+		}
+		books := orderBookIDs(order)
+		for _, bookID := range books {
+			sales[bookID]++
+			for _, otherID := range books {
+				if otherID == bookID {
+					continue
+				}
+				if together[bookID] == nil {
+					together[bookID] = make(map[int]int)
+				}
+				together[bookID][otherID]++
+			}
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.together = together
+	s.sales = sales
+	return nil
+}
+
+// GetBookRecommendations returns the books most often bought with the book,
+// completed by the books sharing its genres.
+func (s *RecommendationService) GetBookRecommendations(ctx context.Context, bookID int, limit int) ([]model.Recommendation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	scores := make(map[int]*model.Recommendation)
+	for otherID, count := range s.together[bookID] {
+		scores[otherID] = &model.Recommendation{Score: float64(count), Reason: model.RecommendationBoughtTogether}
+	}
+	genres := genreCounts([]model.Book{book})
+	for _, other := range books {
+		if other.ID == bookID || scores[other.ID] != nil {
+			continue
+		}
+		if score := genreScore(other, genres); score > 0 {
+			scores[other.ID] = &model.Recommendation{Score: score, Reason: model.RecommendationGenre}
+		}
+	}
+	return s.rank(books, scores, limit), nil
+}
+
+// GetCustomerRecommendations returns the books related to the purchases of the
+// customer and the books of the genres they bought, leaving out the books they
+// already have. Customers without purchases get the best sellers.
+func (s *RecommendationService) GetCustomerRecommendations(ctx context.Context, customerID int, limit int) ([]model.Recommendation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoCustomer.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	orders, err := s.repoOrder.SearchOrders(ctx, map[string]string{"customer_id": strconv.Itoa(customerID)})
+	if err != nil {
+		return nil, err
+	}
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	purchased := make(map[int]bool)
+	for _, order := range orders {
+		if orderIsPaid(order) {
+			for _, bookID := range orderBookIDs(order) {
+				purchased[bookID] = true
+			}
+		}
+	}
+	var history []model.Book
+	for _, book := range books {
+		if purchased[book.ID] {
+			history = append(history, book)
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	scores := make(map[int]*model.Recommendation)
+	for bookID := range purchased {
+		for otherID, count := range s.together[bookID] {
+			if purchased[otherID] {
+				continue
+			}
+			if scores[otherID] == nil {
+				scores[otherID] = &model.Recommendation{Reason: model.RecommendationBoughtTogether}
+			}
+			scores[otherID].Score += float64(count)
+		}
+	}
+	genres := genreCounts(history)
+	for _, book := range books {
+		if purchased[book.ID] {
+			continue
+		}
+		score := genreScore(book, genres)
+		if score == 0 {
+			continue
+		}
+		if scores[book.ID] == nil {
+			scores[book.ID] = &model.Recommendation{Reason: model.RecommendationGenre}
+		}
+		scores[book.ID].Score += score
+	}
+	if len(scores) == 0 {
+		for bookID, count := range s.sales {
+			if !purchased[bookID] {
+				scores[bookID] = &model.Recommendation{Score: float64(count), Reason: model.RecommendationPopular}
+			}
+		}
+	}
+	return s.rank(books, scores, limit), nil
+}
+
+// rank returns the limit scored books, best score first, then best rated and
+// best selling. Books that no longer exist are left out. Callers hold the
+// mutex.
+func (s *RecommendationService) rank(books []model.Book, scores map[int]*model.Recommendation, limit int) []model.Recommendation {
+	if limit <= 0 {
+		limit = DefaultRecommendations
+	}
+	limit = min(limit, MaxRecommendations)
+
+	recommendations := make([]model.Recommendation, 0, len(scores))
+	for _, book := range books {
+		if recommendation, ok := scores[book.ID]; ok {
+			recommendation.Book = book
+			recommendation.Score = math.Round(recommendation.Score*100) / 100
+			recommendations = append(recommendations, *recommendation)
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Book.RatingAverage != b.Book.RatingAverage {
+			return a.Book.RatingAverage > b.Book.RatingAverage
+		}
+		return s.sales[a.Book.ID] > s.sales[b.Book.ID]
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations
+}
+
+// orderBookIDs lists the books of the order once each.
+func orderBookIDs(order model.Order) []int {
+	seen := make(map[int]bool)
+	var bookIDs []int
+	for _, item := range order.Items {
+		if !seen[item.BookID] {
+			seen[item.BookID] = true
+			bookIDs = append(bookIDs, item.BookID)
+		}
+	}
+	return bookIDs
+}
+
+// genreCounts counts the books of every genre, by lowercased genre name.
+func genreCounts(books []model.Book) map[string]int {
+	counts := make(map[string]int)
+	for _, book := range books {
+		for _, genre := range book.Genres {
+			counts[strings.ToLower(genre)]++
+		}
+	}
+	return counts
+}
+
+func genreScore(book model.Book, genres map[string]int) float64 {
+	score := 0.0
+	for _, genre := range book.Genres {
+		score += genreWeight * float64(genres[strings.ToLower(genre)])
+	}
+	return score
+}
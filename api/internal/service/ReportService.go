@@ -13,13 +13,19 @@ import (
 )
 
 type ReportService struct {
-	OrderRepo repository.OrderStore
-	BookRepo  repository.BookStore
+	OrderRepo  repository.OrderStore
+	BookRepo   repository.BookStore
+	RefundRepo repository.RefundStore
+	SeriesRepo repository.SeriesStore
+	Currencies *CurrencyService
 }
 
-func NewReportService(orderRepo repository.OrderStore, bookRepo repository.BookStore) *ReportService {
+func NewReportService(orderRepo repository.OrderStore, bookRepo repository.BookStore, refundRepo repository.RefundStore, seriesRepo repository.SeriesStore, currencies *CurrencyService) *ReportService {
 	return &(ReportService{OrderRepo: orderRepo,
-		BookRepo: bookRepo})
+		BookRepo:   bookRepo,
+		RefundRepo: refundRepo,
+		SeriesRepo: seriesRepo,
+		Currencies: currencies})
 }
 
 func (s *ReportService) StartSalesReportGenrator(ctx context.Context, logger *log.Logger) {
@@ -66,6 +72,8 @@ func (s *ReportService) SaveReportAsJSON(report model.ReportModel) error {
 	return nil
 }
 
+// GenerateReport reports on the orders paid and the refunds made during the
+// last day; pending and expired orders never brought in anything.
 func (s *ReportService) GenerateReport(ctx context.Context) error {
 	m := make(map[string]string)
 	orders, err := s.OrderRepo.SearchOrders(ctx, m)
@@ -74,11 +82,40 @@ func (s *ReportService) GenerateReport(ctx context.Context) error {
 		return err
 	}
 
+	refunds, err := s.RefundRepo.SearchRefunds(ctx, m)
+	if err != nil {
+		return err
+	}
+
 	report := model.ReportModel{}
 	report.TotalOrders = s.TotalOrders(ctx, orders)
-	report.TotalRevenue = s.TotalRevenue(ctx, orders)
+	if report.TotalRefunds, err = s.TotalRefunds(ctx, refunds); err != nil {
+		return err
+	}
+	revenue, err := s.TotalRevenue(ctx, orders)
+	if err != nil {
+		return err
+	}
+	if report.TotalRevenue, err = revenue.Sub(report.TotalRefunds); err != nil {
+		return err
+	}
+	if report.TaxCollected, err = s.TaxCollected(ctx, orders, refunds); err != nil {
+		return err
+	}
+	if report.NetRevenue, err = report.TotalRevenue.Sub(report.TaxCollected); err != nil {
+		return err
+	}
+	if report.TotalDiscounts, err = s.TotalDiscounts(ctx, orders); err != nil {
+		return err
+	}
 	report.TotalBooksSold = s.TotalBooksSold(ctx, orders)
 	report.TopSellingBooks = s.TopSellingBooks(ctx, orders)
+	if report.PublisherSales, err = s.PublisherSales(ctx, orders); err != nil {
+		return err
+	}
+	if report.SeriesSales, err = s.SeriesSales(ctx, orders); err != nil {
+		return err
+	}
 	report.GeneratedAt = time.Now()
 
 	if err := s.SaveReportAsJSON(report); err != nil {
@@ -94,7 +131,7 @@ func (s *ReportService) TopSellingBooks(ctx context.Context, orders []model.Orde
 
 	for _, order := range orders {
 		yesterday := time.Now().AddDate(0, 0, -1)
-		if order.CreatedAt.After(yesterday) {
+		if orderPaidSince(order, yesterday) {
 
 			for _, item := range order.Items {
 				bookSales[item.BookID] += item.Quantity
@@ -128,12 +165,131 @@ func (s *ReportService) TopSellingBooks(ctx context.Context, orders []model.Orde
 
 }
 
+// PublisherSales sums the copies sold and the revenue before tax of the books
+// of every publisher over the period, best selling publisher first.
+func (s *ReportService) PublisherSales(ctx context.Context, orders []model.Order) ([]model.PublisherSale, error) {
+	bookSales, err := s.bookSales(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	type publisherKey struct {
+		id   int
+		name string
+	}
+	sales := make(map[publisherKey]*model.PublisherSale)
+	for bookID, bookSale := range bookSales {
+		book, err := s.BookRepo.GetBook(ctx, bookID)
+		if err != nil || (book.PublisherID == 0 && book.Publisher == "") {
+			continue
+		}
+		key := publisherKey{id: book.PublisherID, name: book.Publisher}
+		sale, ok := sales[key]
+		if !ok {
+			sale = &model.PublisherSale{
+				PublisherID: key.id,
+				Publisher:   key.name,
+				Revenue:     model.Money{Currency: s.Currencies.BaseCurrency()},
+			}
+			sales[key] = sale
+		}
+		sale.BooksSold += bookSale.Quantity
+		if sale.Revenue, err = sale.Revenue.Add(bookSale.Revenue); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]model.PublisherSale, 0, len(sales))
+	for _, sale := range sales {
+		result = append(result, *sale)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Revenue.Amount > result[j].Revenue.Amount
+	})
+	return result, nil
+}
+
+// SeriesSales sums the copies sold and the revenue before tax of the books of
+// every series over the period, best selling series first.
+func (s *ReportService) SeriesSales(ctx context.Context, orders []model.Order) ([]model.SeriesSale, error) {
+	bookSales, err := s.bookSales(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make(map[int]*model.SeriesSale)
+	for bookID, bookSale := range bookSales {
+		book, err := s.BookRepo.GetBook(ctx, bookID)
+		if err != nil || book.SeriesID == 0 {
+			continue
+		}
+		sale, ok := sales[book.SeriesID]
+		if !ok {
+			sale = &model.SeriesSale{
+				SeriesID: book.SeriesID,
+				Revenue:  model.Money{Currency: s.Currencies.BaseCurrency()},
+			}
+			if series, err := s.SeriesRepo.GetSeries(ctx, book.SeriesID); err == nil {
+				sale.Series = series.Name
+			}
+			sales[book.SeriesID] = sale
+		}
+		sale.BooksSold += bookSale.Quantity
+		if sale.Revenue, err = sale.Revenue.Add(bookSale.Revenue); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]model.SeriesSale, 0, len(sales))
+	for _, sale := range sales {
+		result = append(result, *sale)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Revenue.Amount > result[j].Revenue.Amount
+	})
+	return result, nil
+}
+
+// bookSales sums the copies sold and the revenue before tax in the base
+// currency of every book over the paid orders of the period.
+func (s *ReportService) bookSales(orders []model.Order) (map[int]*model.BookSale, error) {
+	sales := make(map[int]*model.BookSale)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for _, order := range orders {
+		if !orderPaidSince(order, yesterday) {
+			continue
+		}
+		for _, item := range order.Items {
+			lineTotal := item.LineTotal
+			if lineTotal.IsZero() {
+				lineTotal = item.UnitPrice.Mul(item.Quantity)
+			}
+			amount, err := s.Currencies.Convert(lineTotal, s.Currencies.BaseCurrency())
+			if err != nil {
+				return nil, err
+			}
+
+			sale, ok := sales[item.BookID]
+			if !ok {
+				sale = &model.BookSale{BookID: item.BookID, Revenue: model.Money{Currency: s.Currencies.BaseCurrency()}}
+				sales[item.BookID] = sale
+			}
+			sale.Quantity += item.Quantity
+			if sale.Revenue, err = sale.Revenue.Add(amount); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sales, nil
+}
+
 func (s *ReportService) TotalBooksSold(ctx context.Context, orders []model.Order) int {
 	var totalBooks int
 
 	for _, order := range orders {
 		yesterday := time.Now().AddDate(0, 0, -1)
-		if order.CreatedAt.After(yesterday) {
+		if orderPaidSince(order, yesterday) {
 			for _, item := range order.Items {
 				totalBooks += item.Quantity
 			}
@@ -148,22 +304,112 @@ func (s *ReportService) TotalOrders(ctx context.Context, orders []model.Order) i
 
 	for _, order := range orders {
 		yesterday := time.Now().AddDate(0, 0, -1)
-		if order.CreatedAt.After(yesterday) {
+		if orderPaidSince(order, yesterday) {
 			count++
 		}
 	}
 	return count
 }
 
-func (s *ReportService) TotalRevenue(ctx context.Context, orders []model.Order) float64 {
-	var revenue float64
+func (s *ReportService) TotalRevenue(ctx context.Context, orders []model.Order) (model.Money, error) {
+	revenue := model.Money{Currency: s.Currencies.BaseCurrency()}
+
+	for _, order := range orders {
+		yesterday := time.Now().AddDate(0, 0, -1)
+		if orderPaidSince(order, yesterday) {
+			amount, err := s.Currencies.Convert(orderGrandTotal(order), s.Currencies.BaseCurrency())
+			if err != nil {
+				return model.Money{}, err
+			}
+			if revenue, err = revenue.Add(amount); err != nil {
+				return model.Money{}, err
+			}
+		}
+
+	}
+	return revenue, nil
+}
+
+// TotalRefunds sums the refunds issued during the period, whenever the refunded
+// order was placed; they count as negative revenue.
+func (s *ReportService) TotalRefunds(ctx context.Context, refunds []model.Refund) (model.Money, error) {
+	total := model.Money{Currency: s.Currencies.BaseCurrency()}
+
+	for _, refund := range refunds {
+		yesterday := time.Now().AddDate(0, 0, -1)
+		if refund.CreatedAt.After(yesterday) {
+			amount, err := s.Currencies.Convert(refund.Amount, s.Currencies.BaseCurrency())
+			if err != nil {
+				return model.Money{}, err
+			}
+			if total, err = total.Add(amount); err != nil {
+				return model.Money{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// TotalDiscounts sums the sale price, promotion and coupon reductions granted on
+// the paid orders of the period.
+func (s *ReportService) TotalDiscounts(ctx context.Context, orders []model.Order) (model.Money, error) {
+	total := model.Money{Currency: s.Currencies.BaseCurrency()}
 
 	for _, order := range orders {
 		yesterday := time.Now().AddDate(0, 0, -1)
-		if order.CreatedAt.After(yesterday) {
-			revenue += order.TotalPrice
+		if orderPaidSince(order, yesterday) {
+			amount, err := s.Currencies.Convert(order.DiscountTotal, s.Currencies.BaseCurrency())
+			if err != nil {
+				return model.Money{}, err
+			}
+			if total, err = total.Add(amount); err != nil {
+				return model.Money{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// TaxCollected sums the tax charged on the paid orders of the period, less the
+// tax given back by the refunds of the period.
+func (s *ReportService) TaxCollected(ctx context.Context, orders []model.Order, refunds []model.Refund) (model.Money, error) {
+	total := model.Money{Currency: s.Currencies.BaseCurrency()}
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for _, order := range orders {
+		if orderPaidSince(order, yesterday) {
+			amount, err := s.Currencies.Convert(order.Tax, s.Currencies.BaseCurrency())
+			if err != nil {
+				return model.Money{}, err
+			}
+			if total, err = total.Add(amount); err != nil {
+				return model.Money{}, err
+			}
+		}
+	}
+	for _, refund := range refunds {
+		if refund.CreatedAt.After(yesterday) {
+			amount, err := s.Currencies.Convert(refund.Tax, s.Currencies.BaseCurrency())
+			if err != nil {
+				return model.Money{}, err
+			}
+			if total, err = total.Sub(amount); err != nil {
+				return model.Money{}, err
+			}
 		}
+	}
+	return total, nil
+}
 
+// orderPaidSince tells whether the order was paid after the given time. Orders
+// paid before payment times were recorded count from when they were placed.
+func orderPaidSince(order model.Order, since time.Time) bool {
+	if !orderIsPaid(order) {
+		return false
+	}
+	paidAt := order.CreatedAt
+	if order.PaidAt != nil {
+		paidAt = *order.PaidAt
 	}
-	return revenue
+	return paidAt.After(since)
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"testing"
+	"time"
+)
+
+func TestOrderPaidSince(t *testing.T) {
+	now := time.Now()
+	at := func(age time.Duration) *time.Time {
+		paidAt := now.Add(-age)
+		return &paidAt
+	}
+	since := now.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name  string
+		order model.Order
+		want  bool
+	}{
+		{
+			name:  "placed and paid during the day",
+			order: model.Order{Status: model.OrderPaid, CreatedAt: now.Add(-2 * time.Hour), PaidAt: at(time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "placed earlier and paid during the day",
+			order: model.Order{Status: model.OrderPaid, CreatedAt: now.Add(-72 * time.Hour), PaidAt: at(time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "paid before the day",
+			order: model.Order{Status: model.OrderShipped, CreatedAt: now.Add(-48 * time.Hour), PaidAt: at(30 * time.Hour)},
+			want:  false,
+		},
+		{
+			name:  "shipped since it was paid",
+			order: model.Order{Status: model.OrderShipped, CreatedAt: now.Add(-2 * time.Hour), PaidAt: at(time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "paid before payment times were recorded",
+			order: model.Order{Status: model.OrderPaid, CreatedAt: now.Add(-time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "pending",
+			order: model.Order{Status: model.OrderPending, CreatedAt: now.Add(-time.Hour)},
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderPaidSince(tt.order, since); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
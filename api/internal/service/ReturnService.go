@@ -0,0 +1,414 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReturnService runs the return workflow (RMA) of paid orders: a return is
+// requested by the customer, approved or rejected by staff, received back into
+// a warehouse and finally refunded.
+type ReturnService struct {
+	repo           repository.ReturnRequestStore
+	repoOrder      repository.OrderStore
+	repoBook       repository.BookStore
+	repoWarehouse  repository.WarehouseStore
+	stock          *StockService
+	paymentService *PaymentService
+	currencies     *CurrencyService
+	mutex          sync.Mutex
+}
+
+func NewReturnService(repo repository.ReturnRequestStore, repoOrder repository.OrderStore, repoBook repository.BookStore, repoWarehouse repository.WarehouseStore, stock *StockService, paymentService *PaymentService, currencies *CurrencyService) *ReturnService {
+	return &ReturnService{
+		repo:           repo,
+		repoOrder:      repoOrder,
+		repoBook:       repoBook,
+		repoWarehouse:  repoWarehouse,
+		stock:          stock,
+		paymentService: paymentService,
+		currencies:     currencies,
+	}
+}
+
+func (s *ReturnService) CreateReturnRequest(ctx context.Context, input model.ReturnRequestInput) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	if input.Reason == "" {
+		return model.ReturnRequest{}, errors.New("return reason is mandatory")
+	}
+	if len(input.Items) == 0 {
+		return model.ReturnRequest{}, errors.New("return must have at least one item")
+	}
+
+	order, err := s.repoOrder.GetOrder(ctx, input.OrderID)
+	if err != nil {
+		return model.ReturnRequest{}, errors.New("order non existant")
+	}
+	if !orderIsPaid(order) {
+		return model.ReturnRequest{}, fmt.Errorf("order is %s, only paid orders can be returned", order.Status)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	returnable, err := s.returnableQuantities(ctx, order)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	items := []model.ReturnItem{}
+	requested := make(map[int]int)
+	for _, item := range input.Items {
+		if item.Quantity <= 0 {
+			return model.ReturnRequest{}, errors.New("returned quantity must be positive")
+		}
+		if _, ok := returnable[item.BookID]; !ok {
+			return model.ReturnRequest{}, fmt.Errorf("book %d is not part of this order", item.BookID)
+		}
+		if requested[item.BookID]+item.Quantity > returnable[item.BookID] {
+			return model.ReturnRequest{}, fmt.Errorf("returned quantity for book %d exceeds the quantity left to return", item.BookID)
+		}
+		if requested[item.BookID] == 0 {
+			items = append(items, model.ReturnItem{BookID: item.BookID})
+		}
+		requested[item.BookID] += item.Quantity
+	}
+	for i := range items {
+		items[i].Quantity = requested[items[i].BookID]
+	}
+
+	now := time.Now()
+	return s.repo.CreateReturnRequest(ctx, model.ReturnRequest{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerId,
+		Items:      items,
+		Reason:     input.Reason,
+		Status:     model.ReturnRequested,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
+
+func (s *ReturnService) GetReturnRequest(ctx context.Context, id int) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+	return s.repo.GetReturnRequest(ctx, id)
+}
+
+func (s *ReturnService) DeleteReturnRequest(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	returnRequest, err := s.repo.GetReturnRequest(ctx, id)
+	if err != nil {
+		return err
+	}
+	if returnRequest.Status != model.ReturnRequested {
+		return errors.New("only requested returns can be deleted")
+	}
+
+	return s.repo.DeleteReturnRequest(ctx, id)
+}
+
+func (s *ReturnService) SearchReturnRequests(ctx context.Context, params map[string]string) ([]model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchReturnRequests(ctx, params)
+}
+
+func (s *ReturnService) ApproveReturnRequest(ctx context.Context, id int) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	returnRequest, err := s.returnRequestInStatus(ctx, id, model.ReturnRequested)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	returnRequest.Status = model.ReturnApproved
+	returnRequest.UpdatedAt = time.Now()
+	return s.repo.UpdateReturnRequest(ctx, id, returnRequest)
+}
+
+func (s *ReturnService) RejectReturnRequest(ctx context.Context, id int, decision model.ReturnDecision) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	if decision.Reason == "" {
+		return model.ReturnRequest{}, errors.New("rejection reason is mandatory")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	returnRequest, err := s.returnRequestInStatus(ctx, id, model.ReturnRequested)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	returnRequest.Status = model.ReturnRejected
+	returnRequest.RejectionReason = decision.Reason
+	returnRequest.UpdatedAt = time.Now()
+	return s.repo.UpdateReturnRequest(ctx, id, returnRequest)
+}
+
+// ReceiveReturnRequest books the copies received back. Resellable copies are put
+// back in stock at the receiving warehouse with a return movement; the others
+// are only recorded on the return.
+func (s *ReturnService) ReceiveReturnRequest(ctx context.Context, id int, receipt model.ReturnReceipt) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	if receipt.WarehouseID != 0 {
+		if _, err := s.repoWarehouse.GetWarehouse(ctx, receipt.WarehouseID); err != nil {
+			return model.ReturnRequest{}, errors.New("warehouse non existant")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	returnRequest, err := s.returnRequestInStatus(ctx, id, model.ReturnApproved)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	// An empty receipt means every copy came back in a resellable state.
+	if len(receipt.Items) == 0 {
+		for _, item := range returnRequest.Items {
+			receipt.Items = append(receipt.Items, model.ReturnReceiptItem{BookID: item.BookID, Quantity: item.Quantity, Resellable: item.Quantity})
+		}
+	}
+
+	// Validate the whole receipt before recording any stock, so that a bad line
+	// leaves nothing half applied.
+	received := make(map[int]model.ReturnReceiptItem)
+	for _, receiptItem := range receipt.Items {
+		if receiptItem.Quantity < 0 || receiptItem.Resellable < 0 {
+			return model.ReturnRequest{}, errors.New("received quantities cannot be negative")
+		}
+		if receiptItem.Resellable > receiptItem.Quantity {
+			return model.ReturnRequest{}, fmt.Errorf("resellable quantity for book %d exceeds the received quantity", receiptItem.BookID)
+		}
+		index := -1
+		for i, item := range returnRequest.Items {
+			if item.BookID == receiptItem.BookID {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return model.ReturnRequest{}, fmt.Errorf("book %d is not part of this return", receiptItem.BookID)
+		}
+		line := received[index]
+		line.Quantity += receiptItem.Quantity
+		line.Resellable += receiptItem.Resellable
+		if line.Quantity > returnRequest.Items[index].Quantity {
+			return model.ReturnRequest{}, fmt.Errorf("received quantity for book %d exceeds the returned quantity", receiptItem.BookID)
+		}
+		if receiptItem.Resellable > 0 {
+			if _, err := s.repoBook.GetBook(ctx, receiptItem.BookID); err != nil {
+				return model.ReturnRequest{}, fmt.Errorf("book %d non existant", receiptItem.BookID)
+			}
+		}
+		received[index] = line
+	}
+
+	// copies recorded in stock stay recorded on the return even when their
+	// back-orders could not be allocated
+	var allocationErr error
+	for index, line := range received {
+		item := &returnRequest.Items[index]
+
+		if line.Resellable > 0 {
+			movement := model.StockMovement{
+				BookID:      item.BookID,
+				WarehouseID: receipt.WarehouseID,
+				Type:        model.StockMovementReturn,
+				Quantity:    line.Resellable,
+				Reason:      fmt.Sprintf("returned by customer %d", returnRequest.CustomerID),
+				Reference:   fmt.Sprintf("return:%d", returnRequest.ID),
+			}
+			recorded, err := s.stock.RecordMovement(ctx, movement)
+			if recorded.ID == 0 {
+				return model.ReturnRequest{}, err
+			}
+			if err != nil {
+				allocationErr = err
+			}
+		}
+
+		item.ReceivedQuantity = line.Quantity
+		item.ResellableQuantity = line.Resellable
+	}
+
+	returnRequest.Status = model.ReturnReceived
+	returnRequest.WarehouseID = receipt.WarehouseID
+	returnRequest.UpdatedAt = time.Now()
+	updated, err := s.repo.UpdateReturnRequest(ctx, id, returnRequest)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+	return updated, allocationErr
+}
+
+// RefundReturnRequest refunds a received return. Without an amount the customer
+// gets back what they paid for the copies received.
+func (s *ReturnService) RefundReturnRequest(ctx context.Context, id int, refundInput model.RefundInput) (model.ReturnRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	returnRequest, err := s.returnRequestInStatus(ctx, id, model.ReturnReceived)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+
+	if refundInput.Amount == 0 {
+		value, err := s.receivedValue(ctx, returnRequest)
+		if err != nil {
+			return model.ReturnRequest{}, err
+		}
+		refundInput.Amount = value.Float()
+		if refundInput.Amount == 0 {
+			return model.ReturnRequest{}, errors.New("no copy of this return was received, nothing to refund")
+		}
+	}
+	if refundInput.Reason == "" {
+		refundInput.Reason = fmt.Sprintf("return %d", returnRequest.ID)
+	}
+
+	// a refund made without its credit note is still recorded on the return,
+	// so that it isn't refunded twice
+	refund, err := s.paymentService.RefundOrder(ctx, returnRequest.OrderID, refundInput, returnRequest.ID)
+	if refund.ID == 0 {
+		return model.ReturnRequest{}, err
+	}
+
+	returnRequest.Status = model.ReturnRefunded
+	returnRequest.RefundID = refund.ID
+	returnRequest.UpdatedAt = time.Now()
+	updated, updateErr := s.repo.UpdateReturnRequest(ctx, id, returnRequest)
+	if updateErr != nil {
+		return model.ReturnRequest{}, updateErr
+	}
+	return updated, err
+}
+
+func (s *ReturnService) returnRequestInStatus(ctx context.Context, id int, status string) (model.ReturnRequest, error) {
+	returnRequest, err := s.repo.GetReturnRequest(ctx, id)
+	if err != nil {
+		return model.ReturnRequest{}, err
+	}
+	if returnRequest.Status != status {
+		return model.ReturnRequest{}, fmt.Errorf("return is %s, expected %s", returnRequest.Status, status)
+	}
+	return returnRequest, nil
+}
+
+// returnableQuantities is, per book of the order, the quantity not yet covered
+// by another return that wasn't rejected.
+func (s *ReturnService) returnableQuantities(ctx context.Context, order model.Order) (map[int]int, error) {
+	// back-ordered copies were never sold
+	returnable := make(map[int]int)
+	for _, item := range order.Items {
+		returnable[item.BookID] += item.Quantity - item.Backordered
+	}
+
+	returnRequests, err := s.repo.SearchReturnRequests(ctx, map[string]string{"order_id": strconv.Itoa(order.ID)})
+	if err != nil {
+		return nil, err
+	}
+	for _, returnRequest := range returnRequests {
+		if returnRequest.Status == model.ReturnRejected {
+			continue
+		}
+		for _, item := range returnRequest.Items {
+			returnable[item.BookID] -= item.Quantity
+		}
+	}
+	return returnable, nil
+}
+
+// receivedValue is what the customer paid for the copies received back, after
+// discounts and including tax. Items of orders placed before unit prices were
+// recorded fall back to the current book price, in the order's currency.
+func (s *ReturnService) receivedValue(ctx context.Context, returnRequest model.ReturnRequest) (model.Money, error) {
+	order, err := s.repoOrder.GetOrder(ctx, returnRequest.OrderID)
+	if err != nil {
+		return model.Money{}, err
+	}
+
+	value := model.Money{Currency: order.Currency}
+	for _, item := range returnRequest.Items {
+		if item.ReceivedQuantity == 0 {
+			continue
+		}
+		paid, found := model.Money{}, false
+		for _, orderItem := range order.Items {
+			if orderItem.BookID == item.BookID {
+				paid, found, err = paidForItem(orderItem, item.ReceivedQuantity)
+				if err != nil {
+					return model.Money{}, err
+				}
+				break
+			}
+		}
+		if !found {
+			book, err := s.repoBook.GetBook(ctx, item.BookID)
+			if err != nil {
+				return model.Money{}, err
+			}
+			paid, err = s.currencies.Convert(book.Price.Mul(item.ReceivedQuantity), order.Currency)
+			if err != nil {
+				return model.Money{}, err
+			}
+		}
+		if value, err = value.Add(paid); err != nil {
+			return model.Money{}, err
+		}
+	}
+	return value, nil
+}
+
+// paidForItem is what the customer paid for quantity copies of an order item,
+// its share of the discounted line total and of the tax. It reports false for
+// legacy items without a recorded unit price; a line discounted to nothing is
+// worth nothing. Lines priced before discounts were recorded have no line
+// total and are worth their unit price.
+func paidForItem(orderItem model.OrderItem, quantity int) (model.Money, bool, error) {
+	if orderItem.UnitPrice == (model.Money{}) || orderItem.Quantity == 0 {
+		return model.Money{}, false, nil
+	}
+	lineTotal := orderItem.LineTotal
+	if lineTotal == (model.Money{}) && len(orderItem.Discounts) == 0 {
+		lineTotal = orderItem.UnitPrice.Mul(orderItem.Quantity)
+	}
+	paid, err := lineTotal.Add(orderItem.Tax)
+	if err != nil {
+		return model.Money{}, false, err
+	}
+	share := float64(quantity) / float64(orderItem.Quantity)
+	return paid.MulRate(share), true, nil
+}
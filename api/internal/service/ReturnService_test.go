@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"testing"
+)
+
+func TestReceivedValue(t *testing.T) {
+	eur := func(amount int64) model.Money { return model.Money{Amount: amount, Currency: "EUR"} }
+	coupon := []model.Discount{{Source: model.DiscountSourceCoupon, Name: "SPRING", Amount: eur(500)}}
+
+	tests := []struct {
+		name     string
+		item     model.OrderItem
+		received int
+		want     model.Money
+		wantErr  bool
+	}{
+		{
+			name:     "discounted line with tax",
+			item:     model.OrderItem{BookID: 1, Quantity: 2, UnitPrice: eur(1000), Discounts: coupon, LineTotal: eur(1500), Tax: eur(150)},
+			received: 1,
+			want:     eur(825),
+		},
+		{
+			name:     "fully discounted line is worth nothing",
+			item:     model.OrderItem{BookID: 1, Quantity: 1, UnitPrice: eur(500), Discounts: coupon, LineTotal: eur(0), Tax: eur(0)},
+			received: 1,
+			want:     eur(0),
+		},
+		{
+			name:     "line priced before line totals were recorded",
+			item:     model.OrderItem{BookID: 1, Quantity: 3, UnitPrice: eur(1000)},
+			received: 2,
+			want:     eur(2000),
+		},
+		{
+			name:     "legacy item falls back to the book price in the order currency",
+			item:     model.OrderItem{BookID: 1, Quantity: 2},
+			received: 2,
+			want:     eur(1299),
+		},
+		{
+			name:     "book missing from the order falls back to the book price",
+			item:     model.OrderItem{BookID: 2, Quantity: 1, UnitPrice: eur(1000), LineTotal: eur(1000)},
+			received: 1,
+			want:     eur(650),
+		},
+		{
+			name:     "nothing received",
+			item:     model.OrderItem{BookID: 1, Quantity: 2, UnitPrice: eur(1000), LineTotal: eur(2000)},
+			received: 0,
+			want:     eur(0),
+		},
+		{
+			name:     "amounts recorded in different currencies",
+			item:     model.OrderItem{BookID: 1, Quantity: 1, UnitPrice: eur(1000), LineTotal: eur(1000), Tax: model.Money{Amount: 100, Currency: "USD"}},
+			received: 1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderStore{orders: map[int]model.Order{
+				1: {ID: 1, Currency: "EUR", Items: []model.OrderItem{tt.item}},
+			}}
+			books := &fakeBookStore{books: map[int]model.Book{
+				1: {ID: 1, Price: model.Money{Amount: 1299, Currency: "USD"}},
+			}}
+			service := NewReturnService(nil, orders, books, nil, nil, nil, newTestCurrencies(t))
+
+			returnRequest := model.ReturnRequest{
+				OrderID: 1,
+				Items:   []model.ReturnItem{{BookID: 1, Quantity: tt.received, ReceivedQuantity: tt.received}},
+			}
+			got, err := service.receivedValue(context.Background(), returnRequest)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
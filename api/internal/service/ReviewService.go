@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ReviewService struct {
+	repo         repository.ReviewStore
+	repoBook     repository.BookStore
+	repoCustomer repository.CustomerStore
+	repoOrder    repository.OrderStore
+	mutex        sync.Mutex
+	currentID    int
+}
+
+func NewReviewService(repo repository.ReviewStore, repoBook repository.BookStore, repoCustomer repository.CustomerStore, repoOrder repository.OrderStore) *ReviewService {
+	return &ReviewService{
+		repo:         repo,
+		repoBook:     repoBook,
+		repoCustomer: repoCustomer,
+		repoOrder:    repoOrder,
+		currentID:    1,
+	}
+}
+
+// CreateReview adds the review of a customer to the book, pending moderation.
+// A customer reviews a book once and edits that review afterwards.
+func (s *ReviewService) CreateReview(ctx context.Context, bookID int, reviewInput model.ReviewInput) (model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Review{}, err
+	}
+
+	if err := validateRating(reviewInput.Rating); err != nil {
+		return model.Review{}, err
+	}
+	if _, err := s.repoBook.GetBook(ctx, bookID); err != nil {
+		return model.Review{}, errors.New("book not found")
+	}
+	if _, err := s.repoCustomer.GetCustomer(ctx, reviewInput.CustomerID); err != nil {
+		return model.Review{}, errors.New("customer not found")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing, err := s.repo.SearchReviews(ctx, map[string]string{
+		"book_id":     strconv.Itoa(bookID),
+		"customer_id": strconv.Itoa(reviewInput.CustomerID),
+	})
+	if err != nil {
+		return model.Review{}, err
+	}
+	if len(existing) > 0 {
+		return model.Review{}, errors.New("customer already reviewed this book")
+	}
+
+	verified, err := s.verifiedPurchase(ctx, reviewInput.CustomerID, bookID)
+	if err != nil {
+		return model.Review{}, err
+	}
+
+	review := model.Review{
+		ID:               s.currentID,
+		CustomerID:       reviewInput.CustomerID,
+		BookID:           bookID,
+		Rating:           reviewInput.Rating,
+		Text:             reviewInput.Text,
+		Status:           model.ReviewPending,
+		VerifiedPurchase: verified,
+		CreatedAt:        time.Now(),
+	}
+	s.currentID++
+
+	return s.repo.CreateReview(ctx, review)
+}
+
+func (s *ReviewService) GetReview(ctx context.Context, id int) (model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Review{}, err
+	}
+	return s.repo.GetReview(ctx, id)
+}
+
+// UpdateReview lets the customer who wrote the review edit it. The edited
+// review goes back to moderation and leaves the rating of the book meanwhile.
+func (s *ReviewService) UpdateReview(ctx context.Context, id int, reviewInput model.ReviewInput) (model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Review{}, err
+	}
+
+	if err := validateRating(reviewInput.Rating); err != nil {
+		return model.Review{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return model.Review{}, err
+	}
+	if review.CustomerID != reviewInput.CustomerID {
+		return model.Review{}, errors.New("review belongs to another customer")
+	}
+
+	verified, err := s.verifiedPurchase(ctx, review.CustomerID, review.BookID)
+	if err != nil {
+		return model.Review{}, err
+	}
+
+	wasApproved := review.Status == model.ReviewApproved
+	now := time.Now()
+	review.Rating = reviewInput.Rating
+	review.Text = reviewInput.Text
+	review.Status = model.ReviewPending
+	review.VerifiedPurchase = verified
+	review.ModerationNote = ""
+	review.UpdatedAt = &now
+
+	updated, err := s.repo.UpdateReview(ctx, id, review)
+	if err != nil {
+		return model.Review{}, err
+	}
+	if wasApproved {
+		if err := s.refreshRating(ctx, review.BookID); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// ModerateReview approves or rejects the review and updates the rating of its
+// book.
+func (s *ReviewService) ModerateReview(ctx context.Context, id int, moderationInput model.ReviewModerationInput) (model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Review{}, err
+	}
+
+	if moderationInput.Status != model.ReviewApproved && moderationInput.Status != model.ReviewRejected {
+		return model.Review{}, errors.New("review status must be approved or rejected")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return model.Review{}, err
+	}
+
+	review.Status = moderationInput.Status
+	review.ModerationNote = moderationInput.Note
+	updated, err := s.repo.UpdateReview(ctx, id, review)
+	if err != nil {
+		return model.Review{}, err
+	}
+	if err := s.refreshRating(ctx, review.BookID); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+// GetBookReviews lists the reviews of the book, only the approved ones unless
+// another status is asked for.
+func (s *ReviewService) GetBookReviews(ctx context.Context, bookID int, params map[string]string) ([]model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoBook.GetBook(ctx, bookID); err != nil {
+		return nil, err
+	}
+	if _, ok := params["status"]; !ok {
+		params["status"] = model.ReviewApproved
+	}
+	params["book_id"] = strconv.Itoa(bookID)
+	return s.repo.SearchReviews(ctx, params)
+}
+
+func (s *ReviewService) SearchReviews(ctx context.Context, params map[string]string) ([]model.Review, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchReviews(ctx, params)
+}
+
+// verifiedPurchase tells whether the customer received the book in a delivered
+// order.
+func (s *ReviewService) verifiedPurchase(ctx context.Context, customerID int, bookID int) (bool, error) {
+	orders, err := s.repoOrder.SearchOrders(ctx, map[string]string{
+		"customer_id": strconv.Itoa(customerID),
+		"status":      model.OrderDelivered,
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, order := range orders {
+		for _, item := range order.Items {
+			if item.BookID == bookID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// refreshRating recomputes the average rating of the book from its approved
+// reviews. Callers hold the mutex.
+func (s *ReviewService) refreshRating(ctx context.Context, bookID int) error {
+	reviews, err := s.repo.SearchReviews(ctx, map[string]string{
+		"book_id": strconv.Itoa(bookID),
+		"status":  model.ReviewApproved,
+	})
+	if err != nil {
+		return err
+	}
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, review := range reviews {
+		total += review.Rating
+	}
+	book.RatingCount = len(reviews)
+	book.RatingAverage = 0
+	if book.RatingCount > 0 {
+		book.RatingAverage = math.Round(float64(total)/float64(book.RatingCount)*100) / 100
+	}
+	_, err = s.repoBook.UpdateBook(ctx, bookID, book)
+	return err
+}
+
+func validateRating(rating int) error {
+	if rating < 1 || rating > 5 {
+		return errors.New("rating must be between 1 and 5")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+type SeriesService struct {
+	repo      repository.SeriesStore
+	repoBook  repository.BookStore
+	stock     *StockService
+	currentID int
+}
+
+func NewSeriesService(repo repository.SeriesStore, repoBook repository.BookStore, stock *StockService) *SeriesService {
+	return &SeriesService{
+		repo:      repo,
+		repoBook:  repoBook,
+		stock:     stock,
+		currentID: 1,
+	}
+}
+
+func (s *SeriesService) CreateSeries(ctx context.Context, seriesInput model.SeriesInput) (model.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Series{}, err
+	}
+	series := model.Series{
+		ID:          s.currentID,
+		Name:        seriesInput.Name,
+		Description: seriesInput.Description,
+	}
+	s.currentID++
+
+	if series.Name == "" {
+		return model.Series{}, errors.New("series name is mandatory")
+	}
+
+	return s.repo.CreateSeries(ctx, series)
+}
+
+// GetSeries returns the series with its books in reading order and the copies
+// of each available to order.
+func (s *SeriesService) GetSeries(ctx context.Context, id int) (model.SeriesDetail, error) {
+	if err := ctx.Err(); err != nil {
+		return model.SeriesDetail{}, err
+	}
+
+	series, err := s.repo.GetSeries(ctx, id)
+	if err != nil {
+		return model.SeriesDetail{}, err
+	}
+	books, err := seriesBooks(ctx, s.repoBook, id)
+	if err != nil {
+		return model.SeriesDetail{}, err
+	}
+
+	detail := model.SeriesDetail{Series: series, Books: make([]model.SeriesBook, 0, len(books))}
+	for _, book := range books {
+		availability, err := s.stock.GetAvailability(ctx, book.ID)
+		if err != nil {
+			return model.SeriesDetail{}, err
+		}
+		detail.Books = append(detail.Books, model.SeriesBook{
+			Position:  book.SeriesPosition,
+			Book:      book,
+			Available: availability.Available,
+		})
+	}
+	return detail, nil
+}
+
+func (s *SeriesService) UpdateSeries(ctx context.Context, id int, seriesInput model.SeriesInput) (model.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Series{}, err
+	}
+
+	existingSeries, err := s.repo.GetSeries(ctx, id)
+	if err != nil {
+		return model.Series{}, err
+	}
+
+	updatedSeries := model.Series{
+		ID:          existingSeries.ID,
+		Name:        seriesInput.Name,
+		Description: seriesInput.Description,
+	}
+
+	if updatedSeries.Name == "" {
+		return model.Series{}, errors.New("series name is mandatory")
+	}
+
+	return s.repo.UpdateSeries(ctx, id, updatedSeries)
+}
+
+// DeleteSeries refuses to delete a series that still has books.
+func (s *SeriesService) DeleteSeries(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetSeries(ctx, id); err != nil {
+		return err
+	}
+
+	books, err := seriesBooks(ctx, s.repoBook, id)
+	if err != nil {
+		return err
+	}
+	if len(books) > 0 {
+		return errors.New("series still has books")
+	}
+
+	return s.repo.DeleteSeries(ctx, id)
+}
+
+func (s *SeriesService) SearchSeries(ctx context.Context, params map[string]string) ([]model.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchSeries(ctx, params)
+}
+
+// seriesBooks lists the books of the series in reading order.
+func seriesBooks(ctx context.Context, repoBook repository.BookStore, seriesID int) ([]model.Book, error) {
+	books, err := repoBook.SearchBooks(ctx, map[string]string{"series_id": strconv.Itoa(seriesID)})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].SeriesPosition < books[j].SeriesPosition })
+	return books, nil
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShippingService manages the shipping methods that price the delivery of
+// orders, and the shipments sent for paid orders.
+type ShippingService struct {
+	repo         repository.ShippingMethodStore
+	repoShipment repository.ShipmentStore
+	repoOrder    repository.OrderStore
+	repoBook     repository.BookStore
+	currencies   *CurrencyService
+	loyalty      *LoyaltyService
+	mutex        sync.Mutex
+}
+
+func NewShippingService(repo repository.ShippingMethodStore, repoShipment repository.ShipmentStore, repoOrder repository.OrderStore, repoBook repository.BookStore, currencies *CurrencyService, loyalty *LoyaltyService) *ShippingService {
+	return &ShippingService{
+		repo:         repo,
+		repoShipment: repoShipment,
+		repoOrder:    repoOrder,
+		repoBook:     repoBook,
+		currencies:   currencies,
+		loyalty:      loyalty,
+	}
+}
+
+func (s *ShippingService) CreateShippingMethod(ctx context.Context, methodInput model.ShippingMethodInput) (model.ShippingMethod, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ShippingMethod{}, err
+	}
+
+	method := model.ShippingMethod{
+		Name:      strings.TrimSpace(methodInput.Name),
+		Carrier:   methodInput.Carrier,
+		Rates:     methodInput.Rates,
+		CreatedAt: time.Now(),
+	}
+	if err := validateShippingMethod(method); err != nil {
+		return model.ShippingMethod{}, err
+	}
+
+	return s.repo.CreateShippingMethod(ctx, method)
+}
+
+func (s *ShippingService) GetShippingMethod(ctx context.Context, id int) (model.ShippingMethod, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ShippingMethod{}, err
+	}
+	return s.repo.GetShippingMethod(ctx, id)
+}
+
+func (s *ShippingService) UpdateShippingMethod(ctx context.Context, id int, methodInput model.ShippingMethodInput) (model.ShippingMethod, error) {
+	if err := ctx.Err(); err != nil {
+		return model.ShippingMethod{}, err
+	}
+
+	existingMethod, err := s.repo.GetShippingMethod(ctx, id)
+	if err != nil {
+		return model.ShippingMethod{}, err
+	}
+
+	updatedMethod := model.ShippingMethod{
+		ID:        existingMethod.ID,
+		Name:      strings.TrimSpace(methodInput.Name),
+		Carrier:   methodInput.Carrier,
+		Rates:     methodInput.Rates,
+		CreatedAt: existingMethod.CreatedAt,
+	}
+	if err := validateShippingMethod(updatedMethod); err != nil {
+		return model.ShippingMethod{}, err
+	}
+
+	return s.repo.UpdateShippingMethod(ctx, id, updatedMethod)
+}
+
+func (s *ShippingService) DeleteShippingMethod(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repo.DeleteShippingMethod(ctx, id)
+}
+
+func (s *ShippingService) SearchShippingMethods(ctx context.Context, params map[string]string) ([]model.ShippingMethod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchShippingMethods(ctx, params)
+}
+
+// QuoteShipping prices the delivery of the items to the address with the first
+// rate of the shipping method that covers the country, the weight and the
+// number of items. Rates are in the base currency and converted into the given
+// currency.
+func (s *ShippingService) QuoteShipping(ctx context.Context, methodID int, address model.Address, items []model.OrderItem, currency string) (model.Money, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Money{}, err
+	}
+
+	method, err := s.repo.GetShippingMethod(ctx, methodID)
+	if err != nil {
+		return model.Money{}, errors.New("shipping method non existant")
+	}
+
+	weight, count := 0, 0
+	for _, item := range items {
+		book, err := s.repoBook.GetBook(ctx, item.BookID)
+		if err != nil {
+			return model.Money{}, errors.New("book non existant")
+		}
+		weight += book.Weight * item.Quantity
+		count += item.Quantity
+	}
+
+	for _, rate := range method.Rates {
+		if shippingRateMatches(rate, address.Country, weight, count) {
+			cost := model.NewMoney(rate.Cost+rate.CostPerItem*float64(count), s.currencies.BaseCurrency())
+			return s.currencies.Convert(cost, currency)
+		}
+	}
+	return model.Money{}, fmt.Errorf("shipping method %s cannot deliver this order to %s", method.Name, address.Country)
+}
+
+// CreateShipment ships items of a paid order. The order becomes partially
+// shipped, then shipped once every item left in a shipment.
+func (s *ShippingService) CreateShipment(ctx context.Context, orderID int, shipmentInput model.ShipmentInput) (model.Shipment, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Shipment{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return model.Shipment{}, errors.New("order non existant")
+	}
+	if order.Status != model.OrderPaid && order.Status != model.OrderPartiallyShipped {
+		return model.Shipment{}, fmt.Errorf("order is %s, only paid orders can be shipped", order.Status)
+	}
+
+	unshipped, err := s.unshippedQuantities(ctx, order)
+	if err != nil {
+		return model.Shipment{}, err
+	}
+
+	items := []model.ShipmentItem{}
+	if len(shipmentInput.Items) == 0 {
+		for _, item := range order.Items {
+			if unshipped[item.BookID] > 0 {
+				items = append(items, model.ShipmentItem{BookID: item.BookID, Quantity: unshipped[item.BookID]})
+				unshipped[item.BookID] = 0
+			}
+		}
+	} else {
+		for _, item := range shipmentInput.Items {
+			if item.Quantity <= 0 {
+				return model.Shipment{}, errors.New("item quantity must be positive")
+			}
+			if item.Quantity > unshipped[item.BookID] {
+				return model.Shipment{}, fmt.Errorf("only %d copies of book %d are left to ship", unshipped[item.BookID], item.BookID)
+			}
+			unshipped[item.BookID] -= item.Quantity
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		for _, item := range order.Items {
+			if item.Backordered > 0 {
+				return model.Shipment{}, errors.New("the items left to ship are back-ordered")
+			}
+		}
+		return model.Shipment{}, errors.New("every item of the order is already shipped")
+	}
+
+	carrier := shipmentInput.Carrier
+	if carrier == "" && order.ShippingMethodID != 0 {
+		if method, err := s.repo.GetShippingMethod(ctx, order.ShippingMethodID); err == nil {
+			carrier = method.Carrier
+		}
+	}
+	trackingNumber := strings.TrimSpace(shipmentInput.TrackingNumber)
+	if trackingNumber == "" {
+		trackingNumber = nextTrackingNumber()
+	}
+
+	shipment, err := s.repoShipment.CreateShipment(ctx, model.Shipment{
+		OrderID:        orderID,
+		Items:          items,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		Status:         model.ShipmentShipped,
+		ShippedAt:      time.Now(),
+	})
+	if err != nil {
+		return model.Shipment{}, err
+	}
+
+	order.Status = model.OrderShipped
+	for _, quantity := range unshipped {
+		if quantity > 0 {
+			order.Status = model.OrderPartiallyShipped
+			break
+		}
+	}
+	for _, item := range order.Items {
+		if item.Backordered > 0 {
+			order.Status = model.OrderPartiallyShipped
+		}
+	}
+	if _, err := s.repoOrder.UpdateOrder(ctx, orderID, order); err != nil {
+		return model.Shipment{}, err
+	}
+
+	return shipment, nil
+}
+
+func (s *ShippingService) GetShipment(ctx context.Context, id int) (model.Shipment, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Shipment{}, err
+	}
+	return s.repoShipment.GetShipment(ctx, id)
+}
+
+func (s *ShippingService) SearchShipments(ctx context.Context, params map[string]string) ([]model.Shipment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repoShipment.SearchShipments(ctx, params)
+}
+
+func (s *ShippingService) GetOrderShipments(ctx context.Context, orderID int) ([]model.Shipment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoOrder.GetOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.repoShipment.SearchShipments(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+}
+
+// DeliverShipment records that the carrier delivered the shipment. Once every
+// shipment of a shipped order is delivered, the order is marked as delivered.
+func (s *ShippingService) DeliverShipment(ctx context.Context, id int) (model.Shipment, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Shipment{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	shipment, err := s.repoShipment.GetShipment(ctx, id)
+	if err != nil {
+		return model.Shipment{}, errors.New("shipment non existant")
+	}
+	if shipment.Status == model.ShipmentDelivered {
+		return model.Shipment{}, errors.New("shipment is already delivered")
+	}
+
+	now := time.Now()
+	shipment.Status = model.ShipmentDelivered
+	shipment.DeliveredAt = &now
+	delivered, err := s.repoShipment.UpdateShipment(ctx, id, shipment)
+	if err != nil {
+		return model.Shipment{}, err
+	}
+
+	if err := s.deliverOrder(ctx, delivered.OrderID); err != nil {
+		return delivered, fmt.Errorf("shipment delivered but the order could not be updated: %w", err)
+	}
+	return delivered, nil
+}
+
+// deliverOrder marks a shipped order as delivered when all its shipments are,
+// and awards its loyalty points if the program awards them on delivery.
+// Callers hold the mutex.
+func (s *ShippingService) deliverOrder(ctx context.Context, orderID int) error {
+	order, err := s.repoOrder.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != model.OrderShipped {
+		return nil
+	}
+
+	shipments, err := s.repoShipment.SearchShipments(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+	if err != nil {
+		return err
+	}
+	for _, shipment := range shipments {
+		if shipment.Status != model.ShipmentDelivered {
+			return nil
+		}
+	}
+
+	order.Status = model.OrderDelivered
+	if _, err := s.repoOrder.UpdateOrder(ctx, orderID, order); err != nil {
+		return err
+	}
+	_, err = s.loyalty.AwardPoints(ctx, orderID, model.LoyaltyAwardOnDelivered)
+	return err
+}
+
+// unshippedQuantities returns, per book, how many copies of the order are not
+// in a shipment yet, leaving out those still back-ordered.
+func (s *ShippingService) unshippedQuantities(ctx context.Context, order model.Order) (map[int]int, error) {
+	unshipped := make(map[int]int)
+	for _, item := range order.Items {
+		unshipped[item.BookID] += item.Quantity - item.Backordered
+	}
+
+	shipments, err := s.repoShipment.SearchShipments(ctx, map[string]string{"order_id": strconv.Itoa(order.ID)})
+	if err != nil {
+		return nil, err
+	}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			unshipped[item.BookID] -= item.Quantity
+		}
+	}
+	return unshipped, nil
+}
+
+func validateShippingMethod(method model.ShippingMethod) error {
+	if method.Name == "" {
+		return errors.New("shipping method name is mandatory")
+	}
+	if len(method.Rates) == 0 {
+		return errors.New("shipping method must have at least one rate")
+	}
+	for _, rate := range method.Rates {
+		if rate.Cost < 0 || rate.CostPerItem < 0 {
+			return errors.New("shipping rate costs must not be negative")
+		}
+		if rate.MaxWeight < 0 || rate.MaxItems < 0 {
+			return errors.New("shipping rate limits must not be negative")
+		}
+	}
+	return nil
+}
+
+func shippingRateMatches(rate model.ShippingRate, country string, weight int, count int) bool {
+	if rate.MaxWeight > 0 && weight > rate.MaxWeight {
+		return false
+	}
+	if rate.MaxItems > 0 && count > rate.MaxItems {
+		return false
+	}
+	if len(rate.Countries) == 0 {
+		return true
+	}
+	for _, rateCountry := range rate.Countries {
+		if strings.EqualFold(rateCountry, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextTrackingNumber returns a random tracking number for shipments whose
+// carrier did not give one.
+func nextTrackingNumber() string {
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	return "BS" + strings.ToUpper(hex.EncodeToString(buf))
+}
@@ -0,0 +1,1205 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const systemActor = "system"
+
+// StockService is the only place allowed to change the stock of a book: every
+// change is written to the stock ledger first so the stock held at each
+// location can always be explained. It also holds the reservations of pending
+// orders, which keep copies aside until the order is paid, and the back-orders
+// that wait for copies to come in.
+type StockService struct {
+	repo            repository.StockMovementStore
+	repoBook        repository.BookStore
+	repoWarehouse   repository.WarehouseStore
+	repoReservation repository.ReservationStore
+	repoOrder       repository.OrderStore
+	notifications   *NotificationService
+	wishlists       *WishlistService
+	strategy        AllocationStrategy
+	reservationTTL  time.Duration
+	mutex           sync.Mutex
+}
+
+func NewStockService(repo repository.StockMovementStore, repoBook repository.BookStore, repoWarehouse repository.WarehouseStore, repoReservation repository.ReservationStore, repoOrder repository.OrderStore, notifications *NotificationService, wishlists *WishlistService, strategy AllocationStrategy, reservationTTL time.Duration) *StockService {
+	return &StockService{
+		repo:            repo,
+		repoBook:        repoBook,
+		repoWarehouse:   repoWarehouse,
+		repoReservation: repoReservation,
+		repoOrder:       repoOrder,
+		notifications:   notifications,
+		wishlists:       wishlists,
+		strategy:        strategy,
+		reservationTTL:  reservationTTL,
+	}
+}
+
+// RecordMovement writes a stock change to the ledger. Copies coming in are
+// allocated to the back-orders of the book.
+func (s *StockService) RecordMovement(ctx context.Context, movement model.StockMovement) (model.StockMovement, error) {
+	if err := ctx.Err(); err != nil {
+		return model.StockMovement{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	available, err := s.availableQuantities(ctx, []int{movement.BookID})
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+	recorded, err := s.recordMovement(ctx, movement)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+	if recorded.Quantity > 0 {
+		if _, err := s.fillBackorders(ctx, recorded.BookID); err != nil {
+			return recorded, fmt.Errorf("stock recorded but back-orders could not be allocated: %w", err)
+		}
+		if err := s.notifyBackInStock(ctx, available); err != nil {
+			return recorded, fmt.Errorf("stock recorded but wishlists could not be notified: %w", err)
+		}
+	}
+	return recorded, nil
+}
+
+func (s *StockService) recordMovement(ctx context.Context, movement model.StockMovement) (model.StockMovement, error) {
+	if err := validateMovement(movement); err != nil {
+		return model.StockMovement{}, err
+	}
+
+	warehouseID, err := s.resolveWarehouse(ctx, movement.WarehouseID)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+	movement.WarehouseID = warehouseID
+
+	book, err := s.repoBook.GetBook(ctx, movement.BookID)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+	location := locationStock(book, warehouseID)
+	if location.Quantity+movement.Quantity < 0 {
+		return model.StockMovement{}, fmt.Errorf("insufficient stock for book %d at warehouse %d", book.ID, warehouseID)
+	}
+	if movement.Quantity < 0 {
+		// copies reserved for pending orders cannot be taken by anything else
+		reserved, err := s.reservedQuantities(ctx, 0)
+		if err != nil {
+			return model.StockMovement{}, err
+		}
+		if location.Quantity+movement.Quantity < reserved[book.ID][warehouseID] {
+			return model.StockMovement{}, fmt.Errorf("stock of book %d at warehouse %d is reserved for pending orders", book.ID, warehouseID)
+		}
+	}
+
+	if movement.Actor == "" {
+		movement.Actor = systemActor
+	}
+	movement.CreatedAt = time.Now()
+
+	recorded, err := s.repo.CreateStockMovement(ctx, movement)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	location.Quantity += movement.Quantity
+	setLocationStock(&book, location)
+	if _, err := s.repoBook.UpdateBook(ctx, book.ID, book); err != nil {
+		return model.StockMovement{}, err
+	}
+	return recorded, nil
+}
+
+func (s *StockService) AdjustStock(ctx context.Context, bookID int, input model.StockAdjustmentInput) (model.StockMovement, error) {
+	if err := ctx.Err(); err != nil {
+		return model.StockMovement{}, err
+	}
+
+	if input.Reason == "" {
+		return model.StockMovement{}, errors.New("adjustment reason is mandatory")
+	}
+	if input.Actor == "" {
+		return model.StockMovement{}, errors.New("adjustment actor is mandatory")
+	}
+
+	quantity := input.Quantity
+	switch input.Type {
+	case model.StockMovementAdjustment:
+	case model.StockMovementDamage:
+		// damaged copies always leave the stock, whatever sign the client used
+		if quantity > 0 {
+			quantity = -quantity
+		}
+	default:
+		return model.StockMovement{}, errors.New("adjustment type must be adjustment or damage")
+	}
+
+	return s.RecordMovement(ctx, model.StockMovement{
+		BookID:      bookID,
+		WarehouseID: input.WarehouseID,
+		Type:        input.Type,
+		Quantity:    quantity,
+		Reason:      input.Reason,
+		Actor:       input.Actor,
+	})
+}
+
+func (s *StockService) GetStockMovements(ctx context.Context, bookID int) ([]model.StockMovement, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoBook.GetBook(ctx, bookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SearchStockMovements(ctx, map[string]string{"book_id": strconv.Itoa(bookID)})
+}
+
+// CheckAvailability reports an error when one of the items asks for more copies
+// than the book has available, i.e. on hand and not reserved, over all locations.
+// Books not released yet are pre-ordered, whatever their stock.
+func (s *StockService) CheckAvailability(ctx context.Context, items []model.OrderItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	requested := make(map[int]int)
+	for _, item := range items {
+		requested[item.BookID] += item.Quantity
+	}
+
+	now := time.Now()
+	for bookID, quantity := range requested {
+		book, err := s.repoBook.GetBook(ctx, bookID)
+		if err != nil {
+			return errors.New("book non existant")
+		}
+		if !isBookReleased(book, now) {
+			continue
+		}
+		availability, err := s.GetAvailability(ctx, bookID)
+		if err != nil {
+			return errors.New("book non existant")
+		}
+		if availability.Available < quantity {
+			return fmt.Errorf("insufficient stock for book %d", bookID)
+		}
+	}
+	return nil
+}
+
+// ReserveOrder replaces the reservations of an order by reservations for its
+// current items, picked by the allocation strategy among the copies nobody else
+// has reserved. Everything is planned before anything is written, so the order
+// is either fully reserved or left untouched. Books not released yet are
+// back-ordered, and so are the copies missing from the stock when backorder is
+// set.
+func (s *StockService) ReserveOrder(ctx context.Context, orderID int, customer model.Customer, previous []model.OrderItem, current []model.OrderItem, backorder bool) ([]model.OrderItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	warehouses, err := s.repoWarehouse.SearchWarehouses(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repoReservation.SearchReservations(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservedQuantities(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// orders placed before reservations existed sold their copies right away,
+	// those copies are returned before the order is reserved again
+	var sold []model.OrderItem
+	if len(existing) == 0 {
+		sold = previous
+	}
+	returned, err := s.soldAllocations(ctx, sold)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	available := make(map[int]map[int]int)
+	released := make(map[int]bool)
+	for _, item := range current {
+		if _, ok := available[item.BookID]; ok {
+			continue
+		}
+		book, err := s.repoBook.GetBook(ctx, item.BookID)
+		if err != nil {
+			return nil, errors.New("book non existant")
+		}
+		released[item.BookID] = isBookReleased(book, now)
+		available[item.BookID] = make(map[int]int)
+		for _, location := range book.Locations {
+			available[item.BookID][location.WarehouseID] = location.Quantity - reserved[item.BookID][location.WarehouseID]
+		}
+		for _, allocation := range returned[item.BookID] {
+			available[item.BookID][allocation.WarehouseID] += allocation.Quantity
+		}
+	}
+
+	allocated := make([]model.OrderItem, 0, len(current))
+	for _, item := range current {
+		item.Backordered = 0
+		if !released[item.BookID] {
+			item.Backordered = item.Quantity
+		} else if backorder {
+			inStock := 0
+			for _, quantity := range available[item.BookID] {
+				inStock += max(quantity, 0)
+			}
+			item.Backordered = max(item.Quantity-inStock, 0)
+		}
+
+		var allocations []model.Allocation
+		if quantity := item.Quantity - item.Backordered; quantity > 0 {
+			allocations, err = allocate(s.strategy, warehouses, available[item.BookID], customer, quantity)
+			if err != nil {
+				return nil, fmt.Errorf("book %d: %v", item.BookID, err)
+			}
+		}
+		for _, allocation := range allocations {
+			available[item.BookID][allocation.WarehouseID] -= allocation.Quantity
+		}
+		item.Allocations = allocations
+		allocated = append(allocated, item)
+	}
+
+	if err := s.returnAllocations(ctx, orderID, returned); err != nil {
+		return nil, err
+	}
+	if err := s.closeReservations(ctx, existing, model.ReservationReleased); err != nil {
+		return nil, err
+	}
+
+	for _, item := range allocated {
+		for _, allocation := range item.Allocations {
+			_, err := s.repoReservation.CreateReservation(ctx, model.Reservation{
+				OrderID:     orderID,
+				BookID:      item.BookID,
+				WarehouseID: allocation.WarehouseID,
+				Quantity:    allocation.Quantity,
+				Status:      model.ReservationActive,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(s.reservationTTL),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if item.Backordered > 0 {
+			_, err := s.repoReservation.CreateReservation(ctx, model.Reservation{
+				OrderID:   orderID,
+				BookID:    item.BookID,
+				Quantity:  item.Backordered,
+				Status:    model.ReservationBackordered,
+				CreatedAt: now,
+				ExpiresAt: now.Add(s.reservationTTL),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return allocated, nil
+}
+
+// CommitOrder turns the active reservations of an order into sales once it is
+// paid; its back-orders wait for stock. It fails when the reservations have
+// already expired.
+func (s *StockService) CommitOrder(ctx context.Context, orderID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing, err := s.repoReservation.SearchReservations(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+	if err != nil {
+		return err
+	}
+	reservations := []model.Reservation{}
+	backordered := false
+	for _, reservation := range existing {
+		switch reservation.Status {
+		case model.ReservationActive:
+			reservations = append(reservations, reservation)
+		case model.ReservationBackordered:
+			backordered = true
+		}
+	}
+	if len(reservations) == 0 && !backordered {
+		return errors.New("order has no active stock reservation")
+	}
+
+	if err := s.closeReservations(ctx, reservations, model.ReservationCommitted); err != nil {
+		return err
+	}
+
+	reference := fmt.Sprintf("order:%d", orderID)
+	for _, reservation := range reservations {
+		_, err := s.recordMovement(ctx, model.StockMovement{
+			BookID:      reservation.BookID,
+			WarehouseID: reservation.WarehouseID,
+			Type:        model.StockMovementSale,
+			Quantity:    -reservation.Quantity,
+			Reason:      "order paid",
+			Reference:   reference,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelOrder gives the stock of a cancelled order back: active reservations
+// and back-orders are released and copies already sold are returned to their
+// locations, and go to the back-orders of other orders.
+func (s *StockService) CancelOrder(ctx context.Context, orderID int, items []model.OrderItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reservations, err := s.repoReservation.SearchReservations(ctx, map[string]string{"order_id": strconv.Itoa(orderID)})
+	if err != nil {
+		return err
+	}
+	bookIDs := []int{}
+	for _, item := range items {
+		bookIDs = append(bookIDs, item.BookID)
+	}
+	available, err := s.availableQuantities(ctx, bookIDs)
+	if err != nil {
+		return err
+	}
+
+	// orders placed before reservations existed sold their copies right away
+	if len(reservations) == 0 {
+		returned, err := s.soldAllocations(ctx, items)
+		if err != nil {
+			return err
+		}
+		if err := s.returnAllocations(ctx, orderID, returned); err != nil {
+			return err
+		}
+		return s.notifyBackInStock(ctx, available)
+	}
+
+	active := []model.Reservation{}
+	returned := make(map[int][]model.Allocation)
+	books := []int{}
+	for _, reservation := range reservations {
+		switch reservation.Status {
+		case model.ReservationActive, model.ReservationBackordered:
+			active = append(active, reservation)
+		case model.ReservationCommitted:
+			returned[reservation.BookID] = append(returned[reservation.BookID], model.Allocation{WarehouseID: reservation.WarehouseID, Quantity: reservation.Quantity})
+		default:
+			continue
+		}
+		books = append(books, reservation.BookID)
+	}
+
+	if err := s.closeReservations(ctx, active, model.ReservationReleased); err != nil {
+		return err
+	}
+	if err := s.returnAllocations(ctx, orderID, returned); err != nil {
+		return err
+	}
+	for _, bookID := range books {
+		if _, err := s.fillBackorders(ctx, bookID); err != nil {
+			return err
+		}
+	}
+	return s.notifyBackInStock(ctx, available)
+}
+
+// ExpireReservations releases every active reservation past its expiry date,
+// with the back-orders of orders still pending, and returns the orders that
+// lost their reservations. Once released, the orders are returned even when
+// what follows fails, so they can still be expired.
+func (s *StockService) ExpireReservations(ctx context.Context) ([]int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	reservations, err := s.repoReservation.SearchReservations(ctx, map[string]string{"status": model.ReservationActive})
+	if err != nil {
+		return nil, err
+	}
+	backorders, err := s.repoReservation.SearchReservations(ctx, map[string]string{"status": model.ReservationBackordered})
+	if err != nil {
+		return nil, err
+	}
+	for _, backorder := range backorders {
+		order, err := s.repoOrder.GetOrder(ctx, backorder.OrderID)
+		if err == nil && order.Status == model.OrderPending {
+			reservations = append(reservations, backorder)
+		}
+	}
+
+	now := time.Now()
+	expired := []model.Reservation{}
+	orders := []int{}
+	seen := make(map[int]bool)
+	for _, reservation := range reservations {
+		if now.Before(reservation.ExpiresAt) {
+			continue
+		}
+		expired = append(expired, reservation)
+		if !seen[reservation.OrderID] {
+			seen[reservation.OrderID] = true
+			orders = append(orders, reservation.OrderID)
+		}
+	}
+
+	bookIDs := []int{}
+	for _, reservation := range expired {
+		bookIDs = append(bookIDs, reservation.BookID)
+	}
+	available, err := s.availableQuantities(ctx, bookIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.closeReservations(ctx, expired, model.ReservationReleased); err != nil {
+		return nil, err
+	}
+	for _, reservation := range expired {
+		if _, err := s.fillBackorders(ctx, reservation.BookID); err != nil {
+			return orders, fmt.Errorf("reservations released but back-orders could not be filled: %w", err)
+		}
+	}
+	if err := s.notifyBackInStock(ctx, available); err != nil {
+		return orders, fmt.Errorf("reservations released but wishlists could not be notified: %w", err)
+	}
+	return orders, nil
+}
+
+func (s *StockService) GetAvailability(ctx context.Context, bookID int) (model.StockAvailability, error) {
+	if err := ctx.Err(); err != nil {
+		return model.StockAvailability{}, err
+	}
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return model.StockAvailability{}, err
+	}
+	reserved, err := s.reservedQuantities(ctx, 0)
+	if err != nil {
+		return model.StockAvailability{}, err
+	}
+	backordered, err := s.backorderedQuantities(ctx)
+	if err != nil {
+		return model.StockAvailability{}, err
+	}
+	wishlisted, err := s.wishlists.WishlistCounts(ctx)
+	if err != nil {
+		return model.StockAvailability{}, err
+	}
+
+	return bookAvailability(book, reserved[book.ID], backordered[book.ID], wishlisted[book.ID]), nil
+}
+
+func (s *StockService) ListAvailability(ctx context.Context) ([]model.StockAvailability, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservedQuantities(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	backordered, err := s.backorderedQuantities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wishlisted, err := s.wishlists.WishlistCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	availabilities := make([]model.StockAvailability, 0, len(books))
+	for _, book := range books {
+		availabilities = append(availabilities, bookAvailability(book, reserved[book.ID], backordered[book.ID], wishlisted[book.ID]))
+	}
+	return availabilities, nil
+}
+
+// DeleteBook deletes a book that has no stock left, in any location or on its
+// way to one, and that no order reserved or back-ordered. It holds the mutex
+// so that no order reserves the book while it is checked.
+func (s *StockService) DeleteBook(ctx context.Context, bookID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if book.Stock != 0 {
+		return errors.New("book still has stock")
+	}
+	for _, location := range book.Locations {
+		if location.Quantity != 0 || location.InTransit != 0 {
+			return errors.New("book still has stock")
+		}
+	}
+
+	reservations, err := s.repoReservation.SearchReservations(ctx, map[string]string{"book_id": strconv.Itoa(bookID)})
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		switch reservation.Status {
+		case model.ReservationActive:
+			return errors.New("book still has copies reserved for orders")
+		case model.ReservationBackordered:
+			return errors.New("book still has back-orders")
+		}
+	}
+
+	return s.repoBook.DeleteBook(ctx, bookID)
+}
+
+// AllocateBackorders allocates the copies available, and the books released
+// since, to the back-orders waiting for them, and returns how many copies were
+// allocated.
+func (s *StockService) AllocateBackorders(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	backordered, err := s.backorderedQuantities(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	allocated := 0
+	for bookID := range backordered {
+		filled, err := s.fillBackorders(ctx, bookID)
+		allocated += filled
+		if err != nil {
+			return allocated, err
+		}
+	}
+	return allocated, nil
+}
+
+func (s *StockService) StartBackorderSweeper(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			allocated, err := s.AllocateBackorders(ctx)
+			if err != nil {
+				logger.Printf("Error allocating back-orders: %v\n", err)
+			} else if allocated > 0 {
+				logger.Printf("Allocated %d back-ordered copies\n", allocated)
+			}
+		}
+	}
+}
+
+// fillBackorders allocates the copies of a released book that nobody reserved
+// to its back-orders, oldest first, and returns how many copies were allocated.
+// Callers hold the mutex.
+func (s *StockService) fillBackorders(ctx context.Context, bookID int) (int, error) {
+	backorders, err := s.repoReservation.SearchReservations(ctx, map[string]string{
+		"book_id": strconv.Itoa(bookID),
+		"status":  model.ReservationBackordered,
+	})
+	if err != nil || len(backorders) == 0 {
+		return 0, err
+	}
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		// a book deleted while it had back-orders, before deletes checked for
+		// them, can't fill them; their orders expire like any other
+		return 0, ctx.Err()
+	}
+	if !isBookReleased(book, time.Now()) {
+		return 0, nil
+	}
+	reserved, err := s.reservedQuantities(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	sort.Slice(backorders, func(i, j int) bool {
+		if !backorders[i].CreatedAt.Equal(backorders[j].CreatedAt) {
+			return backorders[i].CreatedAt.Before(backorders[j].CreatedAt)
+		}
+		return backorders[i].ID < backorders[j].ID
+	})
+	locations := append([]model.LocationStock{}, book.Locations...)
+	sort.Slice(locations, func(i, j int) bool { return locations[i].WarehouseID < locations[j].WarehouseID })
+	taken := make(map[int]int)
+
+	allocated := 0
+	for _, backorder := range backorders {
+		order, err := s.repoOrder.GetOrder(ctx, backorder.OrderID)
+		if err != nil || (order.Status != model.OrderPending && !orderIsPaid(order)) {
+			continue
+		}
+
+		var allocations []model.Allocation
+		left := backorder.Quantity
+		for _, location := range locations {
+			free := location.Quantity - reserved[bookID][location.WarehouseID] - taken[location.WarehouseID]
+			if free <= 0 {
+				continue
+			}
+			quantity := min(left, free)
+			allocations = append(allocations, model.Allocation{WarehouseID: location.WarehouseID, Quantity: quantity})
+			taken[location.WarehouseID] += quantity
+			left -= quantity
+			if left == 0 {
+				break
+			}
+		}
+		if len(allocations) == 0 {
+			break
+		}
+
+		if err := s.allocateBackorder(ctx, book, backorder, order, allocations); err != nil {
+			return allocated, err
+		}
+		allocated += backorder.Quantity - left
+	}
+	return allocated, nil
+}
+
+// allocateBackorder gives a back-order the copies allocated to it: they are
+// reserved for a pending order and sold to a paid one. What is still missing
+// stays back-ordered, in its place in the queue. The order is updated and its
+// customer notified. Callers hold the mutex.
+func (s *StockService) allocateBackorder(ctx context.Context, book model.Book, backorder model.Reservation, order model.Order, allocations []model.Allocation) error {
+	now := time.Now()
+	paid := orderIsPaid(order)
+	reference := fmt.Sprintf("order:%d", order.ID)
+
+	quantity := 0
+	for _, allocation := range allocations {
+		quantity += allocation.Quantity
+		reservation := model.Reservation{
+			OrderID:     order.ID,
+			BookID:      book.ID,
+			WarehouseID: allocation.WarehouseID,
+			Quantity:    allocation.Quantity,
+			Status:      model.ReservationActive,
+			CreatedAt:   now,
+			ExpiresAt:   backorder.ExpiresAt,
+		}
+		if paid {
+			reservation.Status = model.ReservationCommitted
+			reservation.ClosedAt = &now
+		}
+		if _, err := s.repoReservation.CreateReservation(ctx, reservation); err != nil {
+			return err
+		}
+		if paid {
+			_, err := s.recordMovement(ctx, model.StockMovement{
+				BookID:      book.ID,
+				WarehouseID: allocation.WarehouseID,
+				Type:        model.StockMovementSale,
+				Quantity:    -allocation.Quantity,
+				Reason:      "back-order allocated",
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if quantity < backorder.Quantity {
+		rest := backorder
+		rest.ID = 0
+		rest.Quantity = backorder.Quantity - quantity
+		if _, err := s.repoReservation.CreateReservation(ctx, rest); err != nil {
+			return err
+		}
+		backorder.Quantity = quantity
+	}
+	backorder.Status = model.ReservationAllocated
+	backorder.ClosedAt = &now
+	if _, err := s.repoReservation.UpdateReservation(ctx, backorder.ID, backorder); err != nil {
+		return err
+	}
+
+	for i, item := range order.Items {
+		if item.BookID != book.ID || item.Backordered == 0 {
+			continue
+		}
+		taken := min(item.Backordered, quantity)
+		order.Items[i].Backordered -= taken
+		order.Items[i].Allocations = mergeAllocations(item.Allocations, allocations)
+		break
+	}
+	if _, err := s.repoOrder.UpdateOrder(ctx, order.ID, order); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%d of your back-ordered copies of %s are now reserved for order #%d.", quantity, book.Title, order.ID)
+	if paid {
+		message = fmt.Sprintf("%d of your back-ordered copies of %s are now in stock and will ship with order #%d.", quantity, book.Title, order.ID)
+	}
+	_, err := s.notifications.Notify(ctx, model.Notification{
+		CustomerID: order.CustomerId,
+		OrderID:    order.ID,
+		Type:       model.NotificationBackorderAllocated,
+		Subject:    fmt.Sprintf("%s is in stock", book.Title),
+		Message:    message,
+	})
+	return err
+}
+
+// reservedQuantities sums the active reservations per book and warehouse,
+// leaving out those of the given order.
+func (s *StockService) reservedQuantities(ctx context.Context, excludedOrderID int) (map[int]map[int]int, error) {
+	reservations, err := s.repoReservation.SearchReservations(ctx, map[string]string{"status": model.ReservationActive})
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[int]map[int]int)
+	for _, reservation := range reservations {
+		if excludedOrderID != 0 && reservation.OrderID == excludedOrderID {
+			continue
+		}
+		if reserved[reservation.BookID] == nil {
+			reserved[reservation.BookID] = make(map[int]int)
+		}
+		reserved[reservation.BookID][reservation.WarehouseID] += reservation.Quantity
+	}
+	return reserved, nil
+}
+
+// availableQuantities returns, per book, the copies in stock that no pending
+// order reserved. Books that don't exist have none. Callers hold the mutex.
+func (s *StockService) availableQuantities(ctx context.Context, bookIDs []int) (map[int]int, error) {
+	reserved, err := s.reservedQuantities(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[int]int)
+	for _, bookID := range bookIDs {
+		book, err := s.repoBook.GetBook(ctx, bookID)
+		if err != nil {
+			available[bookID] = 0
+			continue
+		}
+		available[bookID] = bookAvailability(book, reserved[bookID], 0, 0).Available
+	}
+	return available, nil
+}
+
+// notifyBackInStock tells the wishlists of the books that had no copy
+// available before a change, and still have some once their back-orders took
+// what they needed. Callers hold the mutex.
+func (s *StockService) notifyBackInStock(ctx context.Context, before map[int]int) error {
+	bookIDs := []int{}
+	for bookID, available := range before {
+		if available <= 0 {
+			bookIDs = append(bookIDs, bookID)
+		}
+	}
+	if len(bookIDs) == 0 {
+		return nil
+	}
+	after, err := s.availableQuantities(ctx, bookIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, bookID := range bookIDs {
+		if after[bookID] <= 0 {
+			continue
+		}
+		book, err := s.repoBook.GetBook(ctx, bookID)
+		if err != nil {
+			return err
+		}
+		if err := s.wishlists.NotifyBackInStock(ctx, book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backorderedQuantities sums the copies of every book waiting for stock.
+func (s *StockService) backorderedQuantities(ctx context.Context) (map[int]int, error) {
+	backorders, err := s.repoReservation.SearchReservations(ctx, map[string]string{"status": model.ReservationBackordered})
+	if err != nil {
+		return nil, err
+	}
+
+	backordered := make(map[int]int)
+	for _, backorder := range backorders {
+		backordered[backorder.BookID] += backorder.Quantity
+	}
+	return backordered, nil
+}
+
+func (s *StockService) closeReservations(ctx context.Context, reservations []model.Reservation, status string) error {
+	now := time.Now()
+	for _, reservation := range reservations {
+		if reservation.Status != model.ReservationActive && reservation.Status != model.ReservationBackordered {
+			continue
+		}
+		reservation.Status = status
+		reservation.ClosedAt = &now
+		if _, err := s.repoReservation.UpdateReservation(ctx, reservation.ID, reservation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// soldAllocations lists per book the locations the items were sold from. Items
+// without allocations predate warehouses and were sold from the default one.
+func (s *StockService) soldAllocations(ctx context.Context, items []model.OrderItem) (map[int][]model.Allocation, error) {
+	sold := make(map[int][]model.Allocation)
+	if len(items) == 0 {
+		return sold, nil
+	}
+
+	defaultWarehouseID, err := s.resolveWarehouse(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		allocations := item.Allocations
+		if len(allocations) == 0 {
+			allocations = []model.Allocation{{WarehouseID: defaultWarehouseID, Quantity: item.Quantity}}
+		}
+		sold[item.BookID] = append(sold[item.BookID], allocations...)
+	}
+	return sold, nil
+}
+
+func (s *StockService) returnAllocations(ctx context.Context, orderID int, returned map[int][]model.Allocation) error {
+	reference := fmt.Sprintf("order:%d", orderID)
+	for bookID, allocations := range returned {
+		for _, allocation := range allocations {
+			_, err := s.recordMovement(ctx, model.StockMovement{
+				BookID:      bookID,
+				WarehouseID: allocation.WarehouseID,
+				Type:        model.StockMovementReturn,
+				Quantity:    allocation.Quantity,
+				Reason:      "order changed or cancelled",
+				Reference:   reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func bookAvailability(book model.Book, reserved map[int]int, backordered int, wishlisted int) model.StockAvailability {
+	availability := model.StockAvailability{
+		BookID:      book.ID,
+		Title:       book.Title,
+		Backordered: backordered,
+		Wishlisted:  wishlisted,
+		Locations:   []model.LocationAvailability{},
+	}
+	for _, location := range book.Locations {
+		locationAvailability := model.LocationAvailability{
+			WarehouseID: location.WarehouseID,
+			OnHand:      location.Quantity,
+			Reserved:    reserved[location.WarehouseID],
+			Available:   max(location.Quantity-reserved[location.WarehouseID], 0),
+			InTransit:   location.InTransit,
+		}
+		availability.OnHand += locationAvailability.OnHand
+		availability.Reserved += locationAvailability.Reserved
+		availability.Available += locationAvailability.Available
+		availability.InTransit += locationAvailability.InTransit
+		availability.Locations = append(availability.Locations, locationAvailability)
+	}
+	return availability
+}
+
+// StartTransfer takes the copies out of the source location and marks them as
+// in transit to the destination.
+func (s *StockService) StartTransfer(ctx context.Context, transfer model.Transfer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.recordMovement(ctx, model.StockMovement{
+		BookID:      transfer.BookID,
+		WarehouseID: transfer.FromWarehouseID,
+		Type:        model.StockMovementTransferOut,
+		Quantity:    -transfer.Quantity,
+		Reason:      fmt.Sprintf("transfer to warehouse %d", transfer.ToWarehouseID),
+		Reference:   fmt.Sprintf("transfer:%d", transfer.ID),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.updateInTransit(ctx, transfer.BookID, transfer.ToWarehouseID, transfer.Quantity)
+}
+
+// CompleteTransfer books the copies in transit into the destination, or back
+// into the source when the transfer is cancelled.
+func (s *StockService) CompleteTransfer(ctx context.Context, transfer model.Transfer, cancelled bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	available, err := s.availableQuantities(ctx, []int{transfer.BookID})
+	if err != nil {
+		return err
+	}
+	if err := s.updateInTransit(ctx, transfer.BookID, transfer.ToWarehouseID, -transfer.Quantity); err != nil {
+		return err
+	}
+
+	movement := model.StockMovement{
+		BookID:      transfer.BookID,
+		WarehouseID: transfer.ToWarehouseID,
+		Type:        model.StockMovementTransferIn,
+		Quantity:    transfer.Quantity,
+		Reason:      fmt.Sprintf("transfer from warehouse %d", transfer.FromWarehouseID),
+		Reference:   fmt.Sprintf("transfer:%d", transfer.ID),
+	}
+	if cancelled {
+		movement.WarehouseID = transfer.FromWarehouseID
+		movement.Reason = "transfer cancelled"
+	}
+
+	if _, err := s.recordMovement(ctx, movement); err != nil {
+		return err
+	}
+	if _, err := s.fillBackorders(ctx, transfer.BookID); err != nil {
+		return err
+	}
+	return s.notifyBackInStock(ctx, available)
+}
+
+func (s *StockService) updateInTransit(ctx context.Context, bookID int, warehouseID int, delta int) error {
+	book, err := s.repoBook.GetBook(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	location := locationStock(book, warehouseID)
+	location.InTransit += delta
+	setLocationStock(&book, location)
+	_, err = s.repoBook.UpdateBook(ctx, book.ID, book)
+	return err
+}
+
+// ReconcileStock compares the stock of every book at every location with the
+// balance of its ledger and books the difference as an adjustment. Books that
+// only have a total stock, from before warehouses existed, get it assigned to
+// the default warehouse first.
+func (s *StockService) ReconcileStock(ctx context.Context, logger *log.Logger) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	defaultWarehouseID, err := s.resolveWarehouse(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return err
+	}
+	movements, err := s.repo.SearchStockMovements(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	balances := make(map[int]map[int]int)
+	for _, movement := range movements {
+		warehouseID := movement.WarehouseID
+		if warehouseID == 0 {
+			warehouseID = defaultWarehouseID
+		}
+		if balances[movement.BookID] == nil {
+			balances[movement.BookID] = make(map[int]int)
+		}
+		balances[movement.BookID][warehouseID] += movement.Quantity
+	}
+
+	for _, book := range books {
+		if len(book.Locations) == 0 && book.Stock != 0 {
+			logger.Printf("Assigning the stock of book %d to warehouse %d\n", book.ID, defaultWarehouseID)
+			setLocationStock(&book, model.LocationStock{WarehouseID: defaultWarehouseID, Quantity: book.Stock})
+			if _, err := s.repoBook.UpdateBook(ctx, book.ID, book); err != nil {
+				return err
+			}
+		}
+
+		for _, location := range book.Locations {
+			difference := location.Quantity - balances[book.ID][location.WarehouseID]
+			if difference == 0 {
+				continue
+			}
+
+			logger.Printf("Stock of book %d at warehouse %d differs from its ledger by %d, recording an adjustment\n", book.ID, location.WarehouseID, difference)
+			movement := model.StockMovement{
+				BookID:      book.ID,
+				WarehouseID: location.WarehouseID,
+				Type:        model.StockMovementAdjustment,
+				Quantity:    difference,
+				Reason:      "reconciliation against the stock ledger",
+				Actor:       systemActor,
+				CreatedAt:   time.Now(),
+			}
+			if _, err := s.repo.CreateStockMovement(ctx, movement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveWarehouse checks that the warehouse exists, 0 standing for the default
+// warehouse, which is the oldest one.
+func (s *StockService) resolveWarehouse(ctx context.Context, warehouseID int) (int, error) {
+	if warehouseID != 0 {
+		if _, err := s.repoWarehouse.GetWarehouse(ctx, warehouseID); err != nil {
+			return 0, errors.New("warehouse non existant")
+		}
+		return warehouseID, nil
+	}
+
+	warehouses, err := s.repoWarehouse.SearchWarehouses(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(warehouses) == 0 {
+		return 0, errors.New("no warehouse configured")
+	}
+
+	defaultID := warehouses[0].ID
+	for _, warehouse := range warehouses {
+		defaultID = min(defaultID, warehouse.ID)
+	}
+	return defaultID, nil
+}
+
+// isBookReleased tells whether the book is published at the given time. Books
+// with a release date in the future are pre-ordered.
+func isBookReleased(book model.Book, now time.Time) bool {
+	return !book.PublishedAt.After(now)
+}
+
+// mergeAllocations adds allocations to those of an order item, one allocation
+// per warehouse.
+func mergeAllocations(allocations []model.Allocation, added []model.Allocation) []model.Allocation {
+	merged := append([]model.Allocation{}, allocations...)
+	for _, allocation := range added {
+		found := false
+		for i := range merged {
+			if merged[i].WarehouseID == allocation.WarehouseID {
+				merged[i].Quantity += allocation.Quantity
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, allocation)
+		}
+	}
+	return merged
+}
+
+func locationStock(book model.Book, warehouseID int) model.LocationStock {
+	for _, location := range book.Locations {
+		if location.WarehouseID == warehouseID {
+			return location
+		}
+	}
+	return model.LocationStock{WarehouseID: warehouseID}
+}
+
+// setLocationStock stores the location on the book and recomputes its total stock.
+func setLocationStock(book *model.Book, location model.LocationStock) {
+	locations := []model.LocationStock{location}
+	for _, existing := range book.Locations {
+		if existing.WarehouseID != location.WarehouseID {
+			locations = append(locations, existing)
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].WarehouseID < locations[j].WarehouseID
+	})
+
+	book.Locations = locations
+	book.Stock = 0
+	for _, existing := range locations {
+		book.Stock += existing.Quantity
+	}
+}
+
+func validateMovement(movement model.StockMovement) error {
+	switch movement.Type {
+	case model.StockMovementSale, model.StockMovementDamage, model.StockMovementTransferOut:
+		if movement.Quantity >= 0 {
+			return fmt.Errorf("%s movements must remove stock", movement.Type)
+		}
+	case model.StockMovementReturn, model.StockMovementReceipt, model.StockMovementTransferIn:
+		if movement.Quantity <= 0 {
+			return fmt.Errorf("%s movements must add stock", movement.Type)
+		}
+	case model.StockMovementAdjustment:
+		if movement.Quantity == 0 {
+			return errors.New("adjustment quantity cannot be zero")
+		}
+	default:
+		return fmt.Errorf("unknown stock movement type %q", movement.Type)
+	}
+	return nil
+}
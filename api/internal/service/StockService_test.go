@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"context"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestFillBackorders(t *testing.T) {
+	now := time.Now()
+	backorder := func(orderID int, quantity int, age time.Duration) model.Reservation {
+		return model.Reservation{
+			OrderID:   orderID,
+			BookID:    1,
+			Quantity:  quantity,
+			Status:    model.ReservationBackordered,
+			CreatedAt: now.Add(-age),
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		locations    []model.LocationStock
+		publishedAt  time.Time
+		reservations []model.Reservation
+		orders       map[int]string
+		// expected quantities per order ID
+		wantAllocated   int
+		wantActive      map[int]int
+		wantCommitted   map[int]int
+		wantBackordered map[int]int
+		wantStock       int
+	}{
+		{
+			name:            "oldest back-orders first",
+			locations:       []model.LocationStock{{WarehouseID: 1, Quantity: 3}},
+			reservations:    []model.Reservation{backorder(2, 2, time.Minute), backorder(1, 2, time.Hour)},
+			orders:          map[int]string{1: model.OrderPending, 2: model.OrderPending},
+			wantAllocated:   3,
+			wantActive:      map[int]int{1: 2, 2: 1},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{2: 1},
+			wantStock:       3,
+		},
+		{
+			name:      "copies reserved for other orders are left alone",
+			locations: []model.LocationStock{{WarehouseID: 1, Quantity: 3}},
+			reservations: []model.Reservation{
+				{OrderID: 3, BookID: 1, WarehouseID: 1, Quantity: 2, Status: model.ReservationActive, ExpiresAt: now.Add(time.Hour)},
+				backorder(1, 2, time.Hour),
+			},
+			orders:          map[int]string{1: model.OrderPending, 3: model.OrderPending},
+			wantAllocated:   1,
+			wantActive:      map[int]int{1: 1, 3: 2},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{1: 1},
+			wantStock:       3,
+		},
+		{
+			name:            "paid orders buy the copies",
+			locations:       []model.LocationStock{{WarehouseID: 1, Quantity: 3}},
+			reservations:    []model.Reservation{backorder(1, 2, time.Hour)},
+			orders:          map[int]string{1: model.OrderPaid},
+			wantAllocated:   2,
+			wantActive:      map[int]int{},
+			wantCommitted:   map[int]int{1: 2},
+			wantBackordered: map[int]int{},
+			wantStock:       1,
+		},
+		{
+			name:            "copies spread over warehouses",
+			locations:       []model.LocationStock{{WarehouseID: 1, Quantity: 1}, {WarehouseID: 2, Quantity: 2}},
+			reservations:    []model.Reservation{backorder(1, 3, time.Hour)},
+			orders:          map[int]string{1: model.OrderPending},
+			wantAllocated:   3,
+			wantActive:      map[int]int{1: 3},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{},
+			wantStock:       3,
+		},
+		{
+			name:            "expired orders are skipped",
+			locations:       []model.LocationStock{{WarehouseID: 1, Quantity: 2}},
+			reservations:    []model.Reservation{backorder(1, 2, time.Hour), backorder(2, 2, time.Minute)},
+			orders:          map[int]string{1: model.OrderExpired, 2: model.OrderPending},
+			wantAllocated:   2,
+			wantActive:      map[int]int{2: 2},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{1: 2},
+			wantStock:       2,
+		},
+		{
+			name:            "pre-orders wait for the release",
+			locations:       []model.LocationStock{{WarehouseID: 1, Quantity: 5}},
+			publishedAt:     now.Add(24 * time.Hour),
+			reservations:    []model.Reservation{backorder(1, 2, time.Hour)},
+			orders:          map[int]string{1: model.OrderPending},
+			wantAllocated:   0,
+			wantActive:      map[int]int{},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{1: 2},
+			wantStock:       5,
+		},
+		{
+			name:            "no stock",
+			reservations:    []model.Reservation{backorder(1, 2, time.Hour)},
+			orders:          map[int]string{1: model.OrderPaid},
+			wantAllocated:   0,
+			wantActive:      map[int]int{},
+			wantCommitted:   map[int]int{},
+			wantBackordered: map[int]int{1: 2},
+			wantStock:       0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publishedAt := tt.publishedAt
+			if publishedAt.IsZero() {
+				publishedAt = now.AddDate(-1, 0, 0)
+			}
+			book := model.Book{ID: 1, Title: "Emma", PublishedAt: publishedAt, Locations: tt.locations}
+			for _, location := range tt.locations {
+				book.Stock += location.Quantity
+			}
+			books := &fakeBookStore{books: map[int]model.Book{1: book}}
+
+			reservations := &fakeReservationStore{}
+			for _, reservation := range tt.reservations {
+				reservations.CreateReservation(context.Background(), reservation)
+			}
+			backordered := summarize(reservations.reservations, model.ReservationBackordered)
+			orders := &fakeOrderStore{orders: make(map[int]model.Order)}
+			for id, status := range tt.orders {
+				orders.orders[id] = model.Order{
+					ID:         id,
+					CustomerId: id,
+					Status:     status,
+					Items:      []model.OrderItem{{BookID: 1, Quantity: backordered[id], Backordered: backordered[id]}},
+				}
+			}
+
+			notifications := NewNotificationService(&fakeNotificationStore{}, &fakeCustomerStore{})
+			service := NewStockService(&fakeStockMovementStore{}, books, &fakeWarehouseStore{}, reservations, orders, notifications, nil, nil, time.Hour)
+
+			allocated, err := service.fillBackorders(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allocated != tt.wantAllocated {
+				t.Errorf("allocated %d, want %d", allocated, tt.wantAllocated)
+			}
+			if got := summarize(reservations.reservations, model.ReservationActive); !maps.Equal(got, tt.wantActive) {
+				t.Errorf("active reservations %v, want %v", got, tt.wantActive)
+			}
+			if got := summarize(reservations.reservations, model.ReservationCommitted); !maps.Equal(got, tt.wantCommitted) {
+				t.Errorf("committed reservations %v, want %v", got, tt.wantCommitted)
+			}
+			if got := summarize(reservations.reservations, model.ReservationBackordered); !maps.Equal(got, tt.wantBackordered) {
+				t.Errorf("back-orders %v, want %v", got, tt.wantBackordered)
+			}
+			if got := books.books[1].Stock; got != tt.wantStock {
+				t.Errorf("stock %d, want %d", got, tt.wantStock)
+			}
+			// the orders keep count of what still waits for stock
+			for id, order := range orders.orders {
+				if got := order.Items[0].Backordered; got != tt.wantBackordered[id] {
+					t.Errorf("order %d has %d copies back-ordered, want %d", id, got, tt.wantBackordered[id])
+				}
+			}
+		})
+	}
+}
+
+func TestBackorderSweepersSkipDeletedBooks(t *testing.T) {
+	now := time.Now()
+	newService := func() (*StockService, *fakeReservationStore) {
+		// book 2 was deleted while orders 2 and 3 waited for it
+		books := &fakeBookStore{books: map[int]model.Book{
+			1: {ID: 1, Title: "Emma", PublishedAt: now.AddDate(-1, 0, 0), Stock: 2, Locations: []model.LocationStock{{WarehouseID: 1, Quantity: 2}}},
+		}}
+		reservations := &fakeReservationStore{}
+		for _, reservation := range []model.Reservation{
+			{OrderID: 1, BookID: 1, Quantity: 2, Status: model.ReservationBackordered, CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+			{OrderID: 2, BookID: 2, Quantity: 1, Status: model.ReservationBackordered, CreatedAt: now, ExpiresAt: now.Add(-time.Minute)},
+			{OrderID: 3, BookID: 2, Quantity: 1, Status: model.ReservationBackordered, CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		} {
+			reservations.CreateReservation(context.Background(), reservation)
+		}
+		orders := &fakeOrderStore{orders: map[int]model.Order{
+			1: {ID: 1, CustomerId: 1, Status: model.OrderPending, Items: []model.OrderItem{{BookID: 1, Quantity: 2, Backordered: 2}}},
+			2: {ID: 2, CustomerId: 2, Status: model.OrderPending, Items: []model.OrderItem{{BookID: 2, Quantity: 1, Backordered: 1}}},
+			3: {ID: 3, CustomerId: 3, Status: model.OrderPending, Items: []model.OrderItem{{BookID: 2, Quantity: 1, Backordered: 1}}},
+		}}
+		notifications := NewNotificationService(&fakeNotificationStore{}, &fakeCustomerStore{})
+		service := NewStockService(&fakeStockMovementStore{}, books, &fakeWarehouseStore{}, reservations, orders, notifications, nil, nil, time.Hour)
+		return service, reservations
+	}
+
+	t.Run("allocation", func(t *testing.T) {
+		service, reservations := newService()
+		allocated, err := service.AllocateBackorders(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allocated != 2 {
+			t.Errorf("allocated %d, want 2", allocated)
+		}
+		if got, want := summarize(reservations.reservations, model.ReservationBackordered), map[int]int{2: 1, 3: 1}; !maps.Equal(got, want) {
+			t.Errorf("back-orders %v, want %v", got, want)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		service, reservations := newService()
+		orderIDs, err := service.ExpireReservations(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(orderIDs) != 1 || orderIDs[0] != 2 {
+			t.Errorf("expired orders %v, want [2]", orderIDs)
+		}
+		if got, want := summarize(reservations.reservations, model.ReservationReleased), map[int]int{2: 1}; !maps.Equal(got, want) {
+			t.Errorf("released reservations %v, want %v", got, want)
+		}
+	})
+}
+
+func TestReservedQuantities(t *testing.T) {
+	reservations := &fakeReservationStore{}
+	for _, reservation := range []model.Reservation{
+		{OrderID: 1, BookID: 1, WarehouseID: 1, Quantity: 2, Status: model.ReservationActive},
+		{OrderID: 2, BookID: 1, WarehouseID: 1, Quantity: 1, Status: model.ReservationActive},
+		{OrderID: 2, BookID: 1, WarehouseID: 2, Quantity: 4, Status: model.ReservationActive},
+		{OrderID: 3, BookID: 1, WarehouseID: 1, Quantity: 5, Status: model.ReservationReleased},
+		{OrderID: 4, BookID: 1, WarehouseID: 1, Quantity: 5, Status: model.ReservationCommitted},
+		{OrderID: 5, BookID: 1, Quantity: 5, Status: model.ReservationBackordered},
+		{OrderID: 1, BookID: 2, WarehouseID: 1, Quantity: 3, Status: model.ReservationActive},
+	} {
+		reservations.CreateReservation(context.Background(), reservation)
+	}
+	service := NewStockService(nil, nil, nil, reservations, nil, nil, nil, nil, time.Hour)
+
+	tests := []struct {
+		name            string
+		excludedOrderID int
+		want            map[int]map[int]int
+	}{
+		{
+			name: "active reservations only",
+			want: map[int]map[int]int{1: {1: 3, 2: 4}, 2: {1: 3}},
+		},
+		{
+			name:            "without the reservations of an order",
+			excludedOrderID: 2,
+			want:            map[int]map[int]int{1: {1: 2}, 2: {1: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.reservedQuantities(context.Background(), tt.excludedOrderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for bookID, warehouses := range tt.want {
+				if !maps.Equal(got[bookID], warehouses) {
+					t.Errorf("book %d: got %v, want %v", bookID, got[bookID], warehouses)
+				}
+			}
+		})
+	}
+}
+
+// summarize sums the quantities of the reservations in the status per order.
+func summarize(reservations []model.Reservation, status string) map[int]int {
+	quantities := make(map[int]int)
+	for _, reservation := range reservations {
+		if reservation.Status == status {
+			quantities[reservation.OrderID] += reservation.Quantity
+		}
+	}
+	return quantities
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type SupplierService struct {
+	repo       repository.SupplierStore
+	repoBook   repository.BookStore
+	currencies *CurrencyService
+}
+
+func NewSupplierService(repo repository.SupplierStore, repoBook repository.BookStore, currencies *CurrencyService) *SupplierService {
+	return &SupplierService{
+		repo:       repo,
+		repoBook:   repoBook,
+		currencies: currencies,
+	}
+}
+
+func (s *SupplierService) CreateSupplier(ctx context.Context, supplierInput model.SupplierInput) (model.Supplier, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Supplier{}, err
+	}
+
+	supplier := model.Supplier{
+		Name:      supplierInput.Name,
+		Email:     supplierInput.Email,
+		Phone:     supplierInput.Phone,
+		Address:   supplierInput.Address,
+		Catalog:   supplierInput.Catalog,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.validateSupplier(ctx, &supplier); err != nil {
+		return model.Supplier{}, err
+	}
+
+	return s.repo.CreateSupplier(ctx, supplier)
+}
+
+func (s *SupplierService) GetSupplier(ctx context.Context, id int) (model.Supplier, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Supplier{}, err
+	}
+	return s.repo.GetSupplier(ctx, id)
+}
+
+func (s *SupplierService) UpdateSupplier(ctx context.Context, id int, supplierInput model.SupplierInput) (model.Supplier, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Supplier{}, err
+	}
+
+	existingSupplier, err := s.repo.GetSupplier(ctx, id)
+	if err != nil {
+		return model.Supplier{}, err
+	}
+
+	updatedSupplier := model.Supplier{
+		ID:        existingSupplier.ID,
+		Name:      supplierInput.Name,
+		Email:     supplierInput.Email,
+		Phone:     supplierInput.Phone,
+		Address:   supplierInput.Address,
+		Catalog:   supplierInput.Catalog,
+		CreatedAt: existingSupplier.CreatedAt,
+	}
+
+	if err := s.validateSupplier(ctx, &updatedSupplier); err != nil {
+		return model.Supplier{}, err
+	}
+
+	return s.repo.UpdateSupplier(ctx, id, updatedSupplier)
+}
+
+func (s *SupplierService) DeleteSupplier(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repo.DeleteSupplier(ctx, id)
+}
+
+func (s *SupplierService) SearchSuppliers(ctx context.Context, params map[string]string) ([]model.Supplier, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchSuppliers(ctx, params)
+}
+
+// validateSupplier also gives the cost prices without a currency the base
+// currency.
+func (s *SupplierService) validateSupplier(ctx context.Context, supplier *model.Supplier) error {
+	if supplier.Name == "" {
+		return errors.New("supplier name is mandatory")
+	}
+
+	seen := make(map[int]bool)
+	for i, price := range supplier.Catalog {
+		if price.CostPrice.Amount < 0 {
+			return errors.New("supplier cost price is invalid")
+		}
+		currency, err := s.currencies.Currency(price.CostPrice.Currency)
+		if err != nil {
+			return err
+		}
+		supplier.Catalog[i].CostPrice.Currency = currency
+		if seen[price.BookID] {
+			return fmt.Errorf("book %d is listed more than once in the supplier catalog", price.BookID)
+		}
+		seen[price.BookID] = true
+
+		if _, err := s.repoBook.GetBook(ctx, price.BookID); err != nil {
+			return errors.New("book non existant")
+		}
+	}
+	return nil
+}
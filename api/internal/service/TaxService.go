@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TaxService derives the tax of order items from the address of the customer,
+// using a rate table loaded from a file.
+type TaxService struct {
+	repoBook repository.BookStore
+	rates    []model.TaxRate
+}
+
+func NewTaxService(repoBook repository.BookStore, filename string) (*TaxService, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax rates: %v", err)
+	}
+
+	var table model.TaxTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse tax rates: %v", err)
+	}
+	for _, rate := range table.TaxRates {
+		if rate.Country == "" {
+			return nil, errors.New("tax rate country is mandatory")
+		}
+		if rate.Rate < 0 {
+			return nil, fmt.Errorf("tax rate of %s must not be negative", rate.Country)
+		}
+		for category, categoryRate := range rate.CategoryRates {
+			if categoryRate < 0 {
+				return nil, fmt.Errorf("tax rate of %s for %s must not be negative", rate.Country, category)
+			}
+		}
+	}
+
+	return &TaxService{
+		repoBook: repoBook,
+		rates:    table.TaxRates,
+	}, nil
+}
+
+// TaxItems sets the tax rate and tax of every priced item shipped to the
+// address. The tax is charged on the line total, after discounts.
+func (s *TaxService) TaxItems(ctx context.Context, items []model.OrderItem, address model.Address) ([]model.OrderItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	taxed := make([]model.OrderItem, len(items))
+	for i, item := range items {
+		book, err := s.repoBook.GetBook(ctx, item.BookID)
+		if err != nil {
+			return nil, errors.New("book non existant")
+		}
+
+		item.TaxRate = s.RateFor(address, book.TaxCategory)
+		item.Tax = item.LineTotal.MulRate(item.TaxRate)
+		taxed[i] = item
+	}
+	return taxed, nil
+}
+
+// RateFor returns the rate charged on the tax category at the address. A rate
+// for the state of the address wins over the rate of its country, and
+// addresses the table does not cover are not taxed.
+func (s *TaxService) RateFor(address model.Address, category string) float64 {
+	if category == "" {
+		category = model.TaxCategoryBooks
+	}
+
+	var match *model.TaxRate
+	for i, rate := range s.rates {
+		if !strings.EqualFold(rate.Country, address.Country) {
+			continue
+		}
+		if rate.State == "" && match == nil {
+			match = &s.rates[i]
+		} else if rate.State != "" && strings.EqualFold(rate.State, address.State) {
+			match = &s.rates[i]
+			break
+		}
+	}
+	if match == nil {
+		return 0
+	}
+
+	if categoryRate, ok := match.CategoryRates[category]; ok {
+		return categoryRate
+	}
+	return match.Rate
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+type TransferService struct {
+	repo          repository.TransferStore
+	repoBook      repository.BookStore
+	repoWarehouse repository.WarehouseStore
+	stock         *StockService
+}
+
+func NewTransferService(repo repository.TransferStore, repoBook repository.BookStore, repoWarehouse repository.WarehouseStore, stock *StockService) *TransferService {
+	return &TransferService{
+		repo:          repo,
+		repoBook:      repoBook,
+		repoWarehouse: repoWarehouse,
+		stock:         stock,
+	}
+}
+
+func (s *TransferService) CreateTransfer(ctx context.Context, transferInput model.TransferInput) (model.Transfer, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Transfer{}, err
+	}
+
+	if transferInput.Quantity <= 0 {
+		return model.Transfer{}, errors.New("transfer quantity must be positive")
+	}
+	if transferInput.FromWarehouseID == transferInput.ToWarehouseID {
+		return model.Transfer{}, errors.New("source and destination warehouses must differ")
+	}
+	if _, err := s.repoBook.GetBook(ctx, transferInput.BookID); err != nil {
+		return model.Transfer{}, errors.New("book non existant")
+	}
+	if _, err := s.repoWarehouse.GetWarehouse(ctx, transferInput.FromWarehouseID); err != nil {
+		return model.Transfer{}, errors.New("source warehouse non existant")
+	}
+	if _, err := s.repoWarehouse.GetWarehouse(ctx, transferInput.ToWarehouseID); err != nil {
+		return model.Transfer{}, errors.New("destination warehouse non existant")
+	}
+
+	transfer, err := s.repo.CreateTransfer(ctx, model.Transfer{
+		BookID:          transferInput.BookID,
+		FromWarehouseID: transferInput.FromWarehouseID,
+		ToWarehouseID:   transferInput.ToWarehouseID,
+		Quantity:        transferInput.Quantity,
+		Status:          model.TransferInTransit,
+		CreatedAt:       time.Now(),
+	})
+	if err != nil {
+		return model.Transfer{}, err
+	}
+
+	if err := s.stock.StartTransfer(ctx, transfer); err != nil {
+		s.repo.DeleteTransfer(ctx, transfer.ID)
+		return model.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (s *TransferService) GetTransfer(ctx context.Context, id int) (model.Transfer, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Transfer{}, err
+	}
+	return s.repo.GetTransfer(ctx, id)
+}
+
+func (s *TransferService) SearchTransfers(ctx context.Context, params map[string]string) ([]model.Transfer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchTransfers(ctx, params)
+}
+
+func (s *TransferService) ReceiveTransfer(ctx context.Context, id int) (model.Transfer, error) {
+	return s.completeTransfer(ctx, id, model.TransferReceived)
+}
+
+func (s *TransferService) CancelTransfer(ctx context.Context, id int) (model.Transfer, error) {
+	return s.completeTransfer(ctx, id, model.TransferCancelled)
+}
+
+func (s *TransferService) completeTransfer(ctx context.Context, id int, status string) (model.Transfer, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Transfer{}, err
+	}
+
+	transfer, err := s.repo.GetTransfer(ctx, id)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	if transfer.Status != model.TransferInTransit {
+		return model.Transfer{}, errors.New("transfer is no longer in transit")
+	}
+
+	if err := s.stock.CompleteTransfer(ctx, transfer, status == model.TransferCancelled); err != nil {
+		return model.Transfer{}, err
+	}
+
+	completedAt := time.Now()
+	transfer.Status = status
+	transfer.CompletedAt = &completedAt
+
+	return s.repo.UpdateTransfer(ctx, id, transfer)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+type WarehouseService struct {
+	repo     repository.WarehouseStore
+	repoBook repository.BookStore
+}
+
+func NewWarehouseService(repo repository.WarehouseStore, repoBook repository.BookStore) *WarehouseService {
+	return &WarehouseService{
+		repo:     repo,
+		repoBook: repoBook,
+	}
+}
+
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, warehouseInput model.WarehouseInput) (model.Warehouse, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Warehouse{}, err
+	}
+
+	warehouse := model.Warehouse{
+		Name:      warehouseInput.Name,
+		Type:      warehouseInput.Type,
+		Address:   warehouseInput.Address,
+		CreatedAt: time.Now(),
+	}
+
+	if err := validateWarehouse(warehouse); err != nil {
+		return model.Warehouse{}, err
+	}
+
+	return s.repo.CreateWarehouse(ctx, warehouse)
+}
+
+func (s *WarehouseService) GetWarehouse(ctx context.Context, id int) (model.Warehouse, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Warehouse{}, err
+	}
+	return s.repo.GetWarehouse(ctx, id)
+}
+
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, id int, warehouseInput model.WarehouseInput) (model.Warehouse, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Warehouse{}, err
+	}
+
+	existingWarehouse, err := s.repo.GetWarehouse(ctx, id)
+	if err != nil {
+		return model.Warehouse{}, err
+	}
+
+	updatedWarehouse := model.Warehouse{
+		ID:        existingWarehouse.ID,
+		Name:      warehouseInput.Name,
+		Type:      warehouseInput.Type,
+		Address:   warehouseInput.Address,
+		CreatedAt: existingWarehouse.CreatedAt,
+	}
+
+	if err := validateWarehouse(updatedWarehouse); err != nil {
+		return model.Warehouse{}, err
+	}
+
+	return s.repo.UpdateWarehouse(ctx, id, updatedWarehouse)
+}
+
+func (s *WarehouseService) DeleteWarehouse(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetWarehouse(ctx, id); err != nil {
+		return err
+	}
+
+	books, err := s.repoBook.SearchBooks(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		location := locationStock(book, id)
+		if location.Quantity != 0 || location.InTransit != 0 {
+			return errors.New("warehouse still holds stock")
+		}
+	}
+
+	return s.repo.DeleteWarehouse(ctx, id)
+}
+
+func (s *WarehouseService) SearchWarehouses(ctx context.Context, params map[string]string) ([]model.Warehouse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.SearchWarehouses(ctx, params)
+}
+
+// EnsureDefaultWarehouse creates a first warehouse when none exists yet, so the
+// stock held before warehouses were introduced has a location to live in.
+func (s *WarehouseService) EnsureDefaultWarehouse(ctx context.Context) error {
+	warehouses, err := s.repo.SearchWarehouses(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if len(warehouses) > 0 {
+		return nil
+	}
+
+	_, err = s.repo.CreateWarehouse(ctx, model.Warehouse{
+		Name:      "Main warehouse",
+		Type:      model.WarehouseTypeWarehouse,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+func validateWarehouse(warehouse model.Warehouse) error {
+	if warehouse.Name == "" {
+		return errors.New("warehouse name is mandatory")
+	}
+	if warehouse.Type != model.WarehouseTypeWarehouse && warehouse.Type != model.WarehouseTypeRetail {
+		return errors.New("warehouse type must be warehouse or retail")
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WishlistService keeps the books customers wish for and tells them when a
+// book on their wishlist is back in stock or gets cheaper. The price of every
+// item is the price the customer last saw, in the currency the book is listed
+// in for them, so that a drop is only notified once and exchange rates moving
+// never look like one.
+type WishlistService struct {
+	repo          repository.WishlistStore
+	repoBook      repository.BookStore
+	repoCustomer  repository.CustomerStore
+	notifications *NotificationService
+	promotions    *PromotionService
+	currencies    *CurrencyService
+	mutex         sync.Mutex
+}
+
+func NewWishlistService(repo repository.WishlistStore, repoBook repository.BookStore, repoCustomer repository.CustomerStore, notifications *NotificationService, promotions *PromotionService, currencies *CurrencyService) *WishlistService {
+	return &WishlistService{
+		repo:          repo,
+		repoBook:      repoBook,
+		repoCustomer:  repoCustomer,
+		notifications: notifications,
+		promotions:    promotions,
+		currencies:    currencies,
+	}
+}
+
+func (s *WishlistService) AddToWishlist(ctx context.Context, customerID int, input model.WishlistItemInput) (model.WishlistItem, error) {
+	if err := ctx.Err(); err != nil {
+		return model.WishlistItem{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	customer, err := s.repoCustomer.GetCustomer(ctx, customerID)
+	if err != nil {
+		return model.WishlistItem{}, errors.New("customer non existant")
+	}
+	book, err := s.repoBook.GetBook(ctx, input.BookID)
+	if err != nil {
+		return model.WishlistItem{}, errors.New("book non existant")
+	}
+
+	existing, err := s.repo.SearchWishlistItems(ctx, map[string]string{
+		"customer_id": strconv.Itoa(customerID),
+		"book_id":     strconv.Itoa(book.ID),
+	})
+	if err != nil {
+		return model.WishlistItem{}, err
+	}
+	if len(existing) > 0 {
+		return model.WishlistItem{}, errors.New("book is already on the wishlist")
+	}
+
+	price, err := s.listedPrice(customer, book)
+	if err != nil {
+		return model.WishlistItem{}, err
+	}
+	item, err := s.repo.CreateWishlistItem(ctx, model.WishlistItem{
+		CustomerID: customerID,
+		BookID:     book.ID,
+		Price:      price,
+		AddedAt:    time.Now(),
+	})
+	if err != nil {
+		return model.WishlistItem{}, err
+	}
+	book.Price = s.currencies.Normalize(book.Price)
+	item.Book = &book
+	return item, nil
+}
+
+func (s *WishlistService) RemoveFromWishlist(ctx context.Context, customerID int, bookID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items, err := s.repo.SearchWishlistItems(ctx, map[string]string{
+		"customer_id": strconv.Itoa(customerID),
+		"book_id":     strconv.Itoa(bookID),
+	})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errors.New("book is not on the wishlist")
+	}
+	return s.repo.DeleteWishlistItem(ctx, items[0].ID)
+}
+
+// GetWishlist returns the wishlist of the customer with the books filled in,
+// oldest first.
+func (s *WishlistService) GetWishlist(ctx context.Context, customerID int) ([]model.WishlistItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoCustomer.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.SearchWishlistItems(ctx, map[string]string{"customer_id": strconv.Itoa(customerID)})
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist := make([]model.WishlistItem, 0, len(items))
+	for _, item := range items {
+		if book, err := s.repoBook.GetBook(ctx, item.BookID); err == nil {
+			book.Price = s.currencies.Normalize(book.Price)
+			item.Book = &book
+		}
+		wishlist = append(wishlist, item)
+	}
+	return wishlist, nil
+}
+
+// WishlistCounts returns how many customers wish for each book.
+func (s *WishlistService) WishlistCounts(ctx context.Context) (map[int]int, error) {
+	items, err := s.repo.SearchWishlistItems(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int)
+	for _, item := range items {
+		counts[item.BookID]++
+	}
+	return counts, nil
+}
+
+// NotifyBackInStock tells the customers wishing for the book that it is in
+// stock again.
+func (s *WishlistService) NotifyBackInStock(ctx context.Context, book model.Book) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items, err := s.repo.SearchWishlistItems(ctx, map[string]string{"book_id": strconv.Itoa(book.ID)})
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := s.repoCustomer.GetCustomer(ctx, item.CustomerID); err != nil {
+			continue
+		}
+		_, err := s.notifications.Notify(ctx, model.Notification{
+			CustomerID: item.CustomerID,
+			BookID:     book.ID,
+			Type:       model.NotificationBackInStock,
+			Subject:    fmt.Sprintf("%s is back in stock", book.Title),
+			Message:    fmt.Sprintf("%s from your wishlist is back in stock.", book.Title),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPrice compares the current price of the book with the price the
+// customers wishing for it last saw, and tells those it dropped for.
+func (s *WishlistService) CheckPrice(ctx context.Context, bookID int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items, err := s.repo.SearchWishlistItems(ctx, map[string]string{"book_id": strconv.Itoa(bookID)})
+	if err != nil {
+		return 0, err
+	}
+	return s.checkPrices(ctx, items)
+}
+
+// CheckPrices compares the prices of every wishlist, which catches the sales
+// starting and ending, and returns how many price drops were notified.
+func (s *WishlistService) CheckPrices(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items, err := s.repo.SearchWishlistItems(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return s.checkPrices(ctx, items)
+}
+
+func (s *WishlistService) StartWishlistSweeper(ctx context.Context, logger *log.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notified, err := s.CheckPrices(ctx)
+			if err != nil {
+				logger.Printf("Error checking wishlist prices: %v\n", err)
+			} else if notified > 0 {
+				logger.Printf("Notified %d wishlist price drops\n", notified)
+			}
+		}
+	}
+}
+
+// checkPrices notifies the price drops of the items and remembers the current
+// prices, so a price going back up is measured from there. Items of customers
+// or books that no longer exist are skipped. Callers hold the mutex.
+func (s *WishlistService) checkPrices(ctx context.Context, items []model.WishlistItem) (int, error) {
+	notified := 0
+	for _, item := range items {
+		customer, err := s.repoCustomer.GetCustomer(ctx, item.CustomerID)
+		if err != nil {
+			continue
+		}
+		book, err := s.repoBook.GetBook(ctx, item.BookID)
+		if err != nil {
+			continue
+		}
+		price, err := s.listedPrice(customer, book)
+		if err != nil {
+			return notified, err
+		}
+		if price == item.Price {
+			continue
+		}
+
+		// items saved in another currency only take the new price
+		if price.Currency == item.Price.Currency && price.Amount < item.Price.Amount {
+			now, err := s.currencies.Convert(price, customer.Currency)
+			if err != nil {
+				return notified, err
+			}
+			before, err := s.currencies.Convert(item.Price, customer.Currency)
+			if err != nil {
+				return notified, err
+			}
+			_, err = s.notifications.Notify(ctx, model.Notification{
+				CustomerID: customer.ID,
+				BookID:     book.ID,
+				Type:       model.NotificationPriceDrop,
+				Subject:    fmt.Sprintf("%s dropped in price", book.Title),
+				Message:    fmt.Sprintf("%s from your wishlist now costs %s instead of %s.", book.Title, now, before),
+			})
+			if err != nil {
+				return notified, err
+			}
+			notified++
+		}
+		item.Price = price
+		if _, err := s.repo.UpdateWishlistItem(ctx, item.ID, item); err != nil {
+			return notified, err
+		}
+	}
+	return notified, nil
+}
+
+// listedPrice returns the current price of the book in the currency it is
+// listed in for the customer: the customer's currency when the book has a
+// price in it, otherwise the currency of its main price.
+func (s *WishlistService) listedPrice(customer model.Customer, book model.Book) (model.Money, error) {
+	currency, err := s.currencies.Currency(customer.Currency)
+	if err != nil {
+		return model.Money{}, err
+	}
+	if _, ok := bookListPrice(book, currency); !ok {
+		currency = s.currencies.Normalize(book.Price).Currency
+	}
+	return s.promotions.CurrentPrice(book, currency)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bookstore/api/api/internal/model"
+	"bookstore/api/api/internal/repository"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// The fakes keep their records in memory and implement only what the tests
+// reach; the embedded store is nil, so any other call fails the test loudly.
+
+type fakeOrderStore struct {
+	repository.OrderStore
+	orders map[int]model.Order
+}
+
+func (s *fakeOrderStore) GetOrder(ctx context.Context, id int) (model.Order, error) {
+	order, ok := s.orders[id]
+	if !ok {
+		return model.Order{}, errors.New("order not found")
+	}
+	return order, nil
+}
+
+func (s *fakeOrderStore) UpdateOrder(ctx context.Context, id int, order model.Order) (model.Order, error) {
+	s.orders[id] = order
+	return order, nil
+}
+
+type fakeBookStore struct {
+	repository.BookStore
+	books map[int]model.Book
+}
+
+func (s *fakeBookStore) GetBook(ctx context.Context, id int) (model.Book, error) {
+	book, ok := s.books[id]
+	if !ok {
+		return model.Book{}, errors.New("book not found")
+	}
+	return book, nil
+}
+
+func (s *fakeBookStore) UpdateBook(ctx context.Context, id int, book model.Book) (model.Book, error) {
+	s.books[id] = book
+	return book, nil
+}
+
+func (s *fakeBookStore) DeleteBook(ctx context.Context, id int) error {
+	if _, ok := s.books[id]; !ok {
+		return errors.New("book not found")
+	}
+	delete(s.books, id)
+	return nil
+}
+
+type fakeReservationStore struct {
+	repository.ReservationStore
+	reservations []model.Reservation
+}
+
+func (s *fakeReservationStore) CreateReservation(ctx context.Context, reservation model.Reservation) (model.Reservation, error) {
+	reservation.ID = len(s.reservations) + 1
+	s.reservations = append(s.reservations, reservation)
+	return reservation, nil
+}
+
+func (s *fakeReservationStore) UpdateReservation(ctx context.Context, id int, reservation model.Reservation) (model.Reservation, error) {
+	for i := range s.reservations {
+		if s.reservations[i].ID == id {
+			s.reservations[i] = reservation
+			return reservation, nil
+		}
+	}
+	return model.Reservation{}, errors.New("reservation not found")
+}
+
+func (s *fakeReservationStore) SearchReservations(ctx context.Context, params map[string]string) ([]model.Reservation, error) {
+	result := []model.Reservation{}
+	for _, reservation := range s.reservations {
+		if (params["order_id"] == "" || params["order_id"] == strconv.Itoa(reservation.OrderID)) &&
+			(params["book_id"] == "" || params["book_id"] == strconv.Itoa(reservation.BookID)) &&
+			(params["status"] == "" || params["status"] == reservation.Status) {
+			result = append(result, reservation)
+		}
+	}
+	return result, nil
+}
+
+type fakeStockMovementStore struct {
+	repository.StockMovementStore
+	movements []model.StockMovement
+}
+
+func (s *fakeStockMovementStore) CreateStockMovement(ctx context.Context, movement model.StockMovement) (model.StockMovement, error) {
+	movement.ID = len(s.movements) + 1
+	s.movements = append(s.movements, movement)
+	return movement, nil
+}
+
+type fakeWarehouseStore struct {
+	repository.WarehouseStore
+}
+
+func (s *fakeWarehouseStore) GetWarehouse(ctx context.Context, id int) (model.Warehouse, error) {
+	return model.Warehouse{ID: id}, nil
+}
+
+type fakeNotificationStore struct {
+	repository.NotificationStore
+	notifications []model.Notification
+}
+
+func (s *fakeNotificationStore) CreateNotification(ctx context.Context, notification model.Notification) (model.Notification, error) {
+	notification.ID = len(s.notifications) + 1
+	s.notifications = append(s.notifications, notification)
+	return notification, nil
+}
+
+// fakeCustomerStore knows every customer.
+type fakeCustomerStore struct {
+	repository.CustomerStore
+}
+
+func (s *fakeCustomerStore) GetCustomer(ctx context.Context, id int) (model.Customer, error) {
+	return model.Customer{ID: id}, nil
+}
+
+// newTestCurrencies returns a currency service with USD as the base currency,
+// where one USD buys half a EUR.
+func newTestCurrencies(t *testing.T) *CurrencyService {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "exchange_rates.json")
+	if err := os.WriteFile(filename, []byte(`{"base_currency": "USD", "rates": {"EUR": 0.5}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	currencies, err := NewCurrencyService(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return currencies
+}
+
+type fakePurchaseOrderStore struct {
+	repository.PurchaseOrderStore
+	purchaseOrders map[int]model.PurchaseOrder
+}
+
+func (s *fakePurchaseOrderStore) GetPurchaseOrder(ctx context.Context, id int) (model.PurchaseOrder, error) {
+	purchaseOrder, ok := s.purchaseOrders[id]
+	if !ok {
+		return model.PurchaseOrder{}, errors.New("purchase order not found")
+	}
+	return purchaseOrder, nil
+}
+
+func (s *fakePurchaseOrderStore) UpdatePurchaseOrder(ctx context.Context, id int, purchaseOrder model.PurchaseOrder) (model.PurchaseOrder, error) {
+	s.purchaseOrders[id] = purchaseOrder
+	return purchaseOrder, nil
+}
+
+// fakeWishlistStore has nobody waiting for any book.
+type fakeWishlistStore struct {
+	repository.WishlistStore
+}
+
+func (s *fakeWishlistStore) SearchWishlistItems(ctx context.Context, params map[string]string) ([]model.WishlistItem, error) {
+	return []model.WishlistItem{}, nil
+}